@@ -0,0 +1,28 @@
+package v1beta3
+
+type InventoryStatus struct {
+	// Provides the cluster peers the operator created for the custom resource
+	ClusterPeers []OwnedObject `json:"clusterPeers,omitempty"`
+
+	// Provides the intercluster LIFs the operator created for the custom resource
+	InterclusterLifs []OwnedObject `json:"interclusterLifs,omitempty"`
+}
+
+type OwnedObject struct {
+	// Provides the object name in ONTAP
+	Name string `json:"name"`
+
+	// Provides the object uuid in ONTAP - empty until the creation request completed
+	Uuid string `json:"uuid,omitempty"`
+}
+
+type JobStatus struct {
+	// Provides the operation the job performs (svmCreate, svmPatch, s3BucketCreate or s3BucketPatch)
+	Operation string `json:"operation"`
+
+	// Provides the name of the SVM or bucket the job works on
+	Target string `json:"target"`
+
+	// Provides the ONTAP job uuid
+	Uuid string `json:"uuid"`
+}
@@ -1,5 +1,7 @@
 package v1beta3
 
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 type IscsiSubSpec struct {
 	// Provides required iSCSI enablement
 	// +kubebuilder:validation:Required
@@ -12,4 +14,92 @@ type IscsiSubSpec struct {
 	// Provides optional alias
 	// +kubebuilder:validation:Optional
 	Alias string `json:"alias,omitempty"`
+
+	// Provides optional initiator groups
+	// +kubebuilder:validation:Optional
+	Igroups []IscsiIgroup `json:"igroups,omitempty"`
+
+	// Provides optional per-initiator CHAP authentication
+	// +kubebuilder:validation:Optional
+	Chap []IscsiChap `json:"chap,omitempty"`
+
+	// Provides optional igroup kept in sync with the IQNs of Kubernetes nodes
+	// +kubebuilder:validation:Optional
+	NodeIgroup *IscsiNodeIgroup `json:"nodeIgroup,omitempty"`
+}
+
+type IscsiNodeIgroup struct {
+	// Provides required initiator group name
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Name string `json:"name"`
+
+	// Provides optional host operating system type
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=linux
+	// +kubebuilder:validation:Enum="aix";"hpux";"hyper_v";"linux";"netware";"openvms";"solaris";"vmware";"windows";"xen"
+	OsType string `json:"osType,omitempty"`
+
+	// Provides optional label selector of the nodes - all nodes if empty
+	// +kubebuilder:validation:Optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+}
+
+type IscsiIgroup struct {
+	// Provides required initiator group name
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Name string `json:"name"`
+
+	// Provides optional host operating system type
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=linux
+	// +kubebuilder:validation:Enum="aix";"hpux";"hyper_v";"linux";"netware";"openvms";"solaris";"vmware";"windows";"xen"
+	OsType string `json:"osType,omitempty"`
+
+	// Provides optional initiator IQNs
+	// +kubebuilder:validation:Optional
+	Initiators []string `json:"initiators,omitempty"`
+
+	// Provides optional name of an existing portset to bind
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	Portset string `json:"portset,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="!has(self.outbound) || has(self.inbound)",message="outbound CHAP requires inbound CHAP"
+type IscsiChap struct {
+	// Provides required initiator IQN
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Initiator string `json:"initiator"`
+
+	// Provides optional inbound CHAP secret with username and password - the initiator authenticates to the target
+	// +kubebuilder:validation:Optional
+	Inbound *NamespacedName `json:"inbound,omitempty"`
+
+	// Provides optional outbound CHAP secret with username and password - the target authenticates to the initiator
+	// requires inbound, ONTAP only accepts outbound CHAP for mutual authentication
+	// +kubebuilder:validation:Optional
+	Outbound *NamespacedName `json:"outbound,omitempty"`
+}
+
+// IscsiStatus reports the iSCSI objects managed on the SVM
+type IscsiStatus struct {
+	Igroups []IscsiIgroupStatus `json:"igroups,omitempty"`
+	Chap    []IscsiChapStatus   `json:"chap,omitempty"`
+}
+
+type IscsiIgroupStatus struct {
+	Name       string   `json:"name"`
+	Uuid       string   `json:"uuid,omitempty"`
+	Initiators []string `json:"initiators,omitempty"`
+}
+
+type IscsiChapStatus struct {
+	Initiator          string `json:"initiator"`
+	AuthenticationType string `json:"authenticationType"`
+	// Resource versions of the secrets last applied - passwords can't be read back from ONTAP
+	InboundSecretVersion  string `json:"inboundSecretVersion,omitempty"`
+	OutboundSecretVersion string `json:"outboundSecretVersion,omitempty"`
 }
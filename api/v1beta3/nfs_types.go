@@ -1,5 +1,7 @@
 package v1beta3
 
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 type NfsSubSpec struct {
 
 	// Provides required NFS enablement
@@ -18,13 +20,74 @@ type NfsSubSpec struct {
 	// +kubebuilder:validation:Optional
 	Nfsv41 bool `json:"v41,omitempty"`
 
+	// Provides optional NFS v4.2 enablement
+	// +kubebuilder:validation:Optional
+	Nfsv42 bool `json:"v42,omitempty"`
+
+	// Provides optional NFS v4 ID domain
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	V4IdDomain string `json:"v4IdDomain,omitempty"`
+
+	// Provides optional showmount enablement
+	// +kubebuilder:validation:Optional
+	Showmount *bool `json:"showmount,omitempty"`
+
+	// Provides optional vStorage enablement
+	// +kubebuilder:validation:Optional
+	Vstorage *bool `json:"vstorage,omitempty"`
+
+	// Provides optional 64-bit file ID enablement for NFS v3 and v4
+	// +kubebuilder:validation:Optional
+	FileId64bit *bool `json:"fileId64bit,omitempty"`
+
+	// Provides optional TCP maximum transfer size in bytes
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=8192
+	// +kubebuilder:validation:Maximum=1048576
+	// +kubebuilder:validation:MultipleOf=4096
+	TcpMaxTransferSize *int `json:"tcpMaxTransferSize,omitempty"`
+
+	// Provides optional qtree export enablement
+	// +kubebuilder:validation:Optional
+	QtreeExports *bool `json:"qtreeExports,omitempty"`
+
+	// Provides optional NFS Kerberos configuration of the NFS LIFs
+	// +kubebuilder:validation:Optional
+	Kerberos []NfsKerberosInterface `json:"kerberos,omitempty"`
+
 	// Provides optional NFS LIFs
 	// +kubebuilder:validation:Optional
 	Lifs []LIF `json:"interfaces,omitempty"`
 
 	// Provides optional NFS export definition
+	// Deprecated: rules are applied to the SVM's default export policy - use exportPolicies instead
 	// +kubebuilder:validation:Optional
 	Export *NfsExport `json:"export,omitempty"`
+
+	// Provides optional named NFS export policies with ordered rules
+	// +kubebuilder:validation:Optional
+	ExportPolicies []NfsExportPolicy `json:"exportPolicies,omitempty"`
+}
+
+type NfsKerberosInterface struct {
+	// Provides required NFS LIF name
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Interface string `json:"interface"`
+
+	// Provides required Kerberos enablement
+	// +kubebuilder:validation:Required
+	Enabled bool `json:"enabled"`
+
+	// Provides optional service principal name - required when enabled
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	Spn string `json:"spn,omitempty"`
+
+	// Provides optional KDC admin credentials secret with username and password - required when enabled
+	// +kubebuilder:validation:Optional
+	Credentials *NamespacedName `json:"credentials,omitempty"`
 }
 
 type NfsExport struct {
@@ -38,26 +101,7 @@ type NfsExport struct {
 }
 
 type NfsRule struct {
-	//Simplifed NFS Rule for now - but it should be like this
-	/* 	// Provides required NFS rule client match
-	   	// +kubebuilder:validation:Required
-	   	Clients []NFSRuleClient `json:"clients"`
-
-	   	// Provides required NFS rule protocol
-	   	// +kubebuilder:validation:Required
-	   	Protocols []NFSRuleVal `json:"protocols"`
-
-	   	// Provides required NFS rule read-write
-	   	// +kubebuilder:validation:Optional
-	   	Rw []NFSRuleVal `json:"rw,omitempty"`
-
-	   	// Provides required NFS rule read-only
-	   	// +kubebuilder:validation:Optional
-	   	Ro []NFSRuleVal `json:"ro,omitempty"`
-
-	   	// Provides required NFS rule superuser
-	   	// +kubebuilder:validation:Optional
-	   	Superuser []NFSRuleVal `json:"superuser,omitempty"` */
+	// Simplified NFS rule - use NfsExportRule in exportPolicies for the full rule model
 
 	// Provides required NFS rule client match
 	// +kubebuilder:validation:Required
@@ -84,10 +128,127 @@ type NfsRule struct {
 	Anon string `json:"anon,omitempty"`
 }
 
-// type NFSRuleVal struct {
-// 	Value string
-// }
+type NfsExportPolicy struct {
+	// Provides required NFS export policy name
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Name string `json:"name"`
+
+	// Provides optional ordered NFS export rules - rule indexes follow list order
+	// +kubebuilder:validation:Optional
+	Rules []NfsExportRule `json:"rules,omitempty"`
+
+	// Provides optional volume names to assign this export policy to
+	// +kubebuilder:validation:Optional
+	Volumes []string `json:"volumes,omitempty"`
+
+	// Provides optional qtrees to assign this export policy to
+	// +kubebuilder:validation:Optional
+	Qtrees []NfsQtree `json:"qtrees,omitempty"`
+}
 
-// type NFSRuleClient struct {
-// 	Match string `json:"match,omitempty"`
-// }
+type NfsExportRule struct {
+	// Provides optional NFS rule client matches - required unless clientsFromNodes is set
+	// +kubebuilder:validation:Optional
+	Clients []NfsRuleClient `json:"clients,omitempty"`
+
+	// Provides optional Kubernetes nodes whose addresses are added to the rule client matches
+	// +kubebuilder:validation:Optional
+	ClientsFromNodes *NfsNodeClients `json:"clientsFromNodes,omitempty"`
+
+	// Provides optional NFS rule protocols
+	// +kubebuilder:validation:Optional
+	Protocols []NfsRuleProtocol `json:"protocols,omitempty"`
+
+	// Provides optional NFS rule read-write auth flavors - defaults to never
+	// +kubebuilder:validation:Optional
+	Rw []NfsRuleAuthFlavor `json:"rw,omitempty"`
+
+	// Provides optional NFS rule read-only auth flavors - defaults to any
+	// +kubebuilder:validation:Optional
+	Ro []NfsRuleAuthFlavor `json:"ro,omitempty"`
+
+	// Provides optional NFS rule superuser auth flavors
+	// +kubebuilder:validation:Optional
+	Superuser []NfsRuleAuthFlavor `json:"superuser,omitempty"`
+
+	// Provides optional NFS rule anonymous user UID
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	Anon string `json:"anon,omitempty"`
+
+	// Provides optional NFS rule chown mode
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum="restricted";"unrestricted"
+	ChownMode string `json:"chownMode,omitempty"`
+
+	// Provides optional NFS rule NTFS unix security
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum="fail";"ignore"
+	NtfsUnixSecurity string `json:"ntfsUnixSecurity,omitempty"`
+
+	// Provides optional NFS rule set user ID enablement
+	// +kubebuilder:validation:Optional
+	AllowSuid *bool `json:"allowSuid,omitempty"`
+}
+
+// NfsRuleProtocol is an access protocol matched by an export rule
+// +kubebuilder:validation:Enum="any";"nfs";"nfs3";"nfs4";"cifs"
+type NfsRuleProtocol string
+
+// NfsRuleAuthFlavor is a security type granted by an export rule
+// +kubebuilder:validation:Enum="any";"none";"never";"krb5";"krb5i";"krb5p";"ntlm";"sys"
+type NfsRuleAuthFlavor string
+
+type NfsRuleClient struct {
+	// Provides required client match - hostname, IP address, netgroup or CIDR
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Match string `json:"match"`
+}
+
+type NfsNodeClients struct {
+	// Provides optional label selector of the nodes - all nodes if empty
+	// +kubebuilder:validation:Optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+
+	// Provides optional prefix length - when set the node network CIDR is used instead of the node address
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=128
+	PrefixLength *int `json:"prefixLength,omitempty"`
+}
+
+// NfsStatus reports the effective NFS service settings read back from ONTAP
+type NfsStatus struct {
+	Enabled            bool                         `json:"enabled"`
+	Nfsv3              bool                         `json:"v3"`
+	Nfsv4              bool                         `json:"v4"`
+	Nfsv41             bool                         `json:"v41"`
+	Nfsv42             bool                         `json:"v42"`
+	V4IdDomain         string                       `json:"v4IdDomain,omitempty"`
+	Showmount          bool                         `json:"showmount"`
+	Vstorage           bool                         `json:"vstorage"`
+	FileId64bit        bool                         `json:"fileId64bit"`
+	TcpMaxTransferSize int                          `json:"tcpMaxTransferSize,omitempty"`
+	QtreeExports       bool                         `json:"qtreeExports"`
+	Kerberos           []NfsKerberosInterfaceStatus `json:"kerberos,omitempty"`
+}
+
+type NfsKerberosInterfaceStatus struct {
+	Interface string `json:"interface"`
+	Enabled   bool   `json:"enabled"`
+	Spn       string `json:"spn,omitempty"`
+}
+
+type NfsQtree struct {
+	// Provides required volume name of the qtree
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Volume string `json:"volume"`
+
+	// Provides required qtree name
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Name string `json:"name"`
+}
@@ -1,5 +1,7 @@
 package v1beta3
 
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 type NvmeSubSpec struct {
 	// Provides required NVMe enablement
 	// +kubebuilder:validation:Required
@@ -8,4 +10,92 @@ type NvmeSubSpec struct {
 	// Provides optional NVMe LIFs
 	// +kubebuilder:validation:Optional
 	Lifs []LIF `json:"interfaces,omitempty"`
+
+	// Provides optional subsystem whose hosts are kept in sync with the NQNs of Kubernetes nodes
+	// +kubebuilder:validation:Optional
+	NodeSubsystem *NvmeNodeSubsystem `json:"nodeSubsystem,omitempty"`
+
+	// Provides optional subsystems with host access and namespace maps
+	// +kubebuilder:validation:Optional
+	Subsystems []NvmeSubsystem `json:"subsystems,omitempty"`
+}
+
+type NvmeSubsystem struct {
+	// Provides required subsystem name
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Name string `json:"name"`
+
+	// Provides optional host operating system type - can't be changed after creation
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=linux
+	// +kubebuilder:validation:Enum="aix";"linux";"vmware";"windows"
+	OsType string `json:"osType,omitempty"`
+
+	// Provides optional hosts allowed to access the subsystem
+	// +kubebuilder:validation:Optional
+	Hosts []NvmeHost `json:"hosts,omitempty"`
+
+	// Provides optional paths of existing namespaces to map to the subsystem - /vol/<volume>/<namespace>
+	// +kubebuilder:validation:Optional
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+type NvmeHost struct {
+	// Provides required host NQN
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Nqn string `json:"nqn"`
+
+	// Provides optional in-band DH-HMAC-CHAP authentication
+	// +kubebuilder:validation:Optional
+	DhHmacChap *NvmeDhHmacChap `json:"dhHmacChap,omitempty"`
+}
+
+type NvmeDhHmacChap struct {
+	// Provides required secret with hostSecretKey and optional controllerSecretKey for bidirectional authentication
+	// +kubebuilder:validation:Required
+	Secret NamespacedName `json:"secret"`
+
+	// Provides optional hash function
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum="sha_256";"sha_512"
+	HashFunction string `json:"hashFunction,omitempty"`
+
+	// Provides optional Diffie-Hellman group size
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum="none";"2048_bit";"3072_bit";"4096_bit";"6144_bit";"8192_bit"
+	GroupSize string `json:"groupSize,omitempty"`
+}
+
+// NvmeStatus reports the NVMe subsystems managed on the SVM
+type NvmeStatus struct {
+	Subsystems []NvmeSubsystemStatus `json:"subsystems,omitempty"`
+}
+
+type NvmeSubsystemStatus struct {
+	Name       string   `json:"name"`
+	Uuid       string   `json:"uuid,omitempty"`
+	TargetNqn  string   `json:"targetNqn,omitempty"`
+	Hosts      []string `json:"hosts,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Resource versions of the DH-HMAC-CHAP secrets last applied by host NQN - keys can't be read back from ONTAP
+	KeyVersions map[string]string `json:"keyVersions,omitempty"`
+}
+
+type NvmeNodeSubsystem struct {
+	// Provides required subsystem name
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Name string `json:"name"`
+
+	// Provides optional host operating system type
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=linux
+	// +kubebuilder:validation:Enum="aix";"linux";"vmware";"windows"
+	OsType string `json:"osType,omitempty"`
+
+	// Provides optional label selector of the nodes - all nodes if empty
+	// +kubebuilder:validation:Optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
 }
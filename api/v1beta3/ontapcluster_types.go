@@ -0,0 +1,156 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OntapClusterSpec defines the connection to an ONTAP cluster shared by StorageVirtualMachines
+type OntapClusterSpec struct {
+	// Provides required cluster management LIF IP address or host name
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength:=1
+	ManagementHost string `json:"managementHost"`
+
+	// Provides optional further management endpoints, such as the node management LIFs,
+	// tried in order when managementHost can't be reached - IP addresses or host names
+	// +kubebuilder:validation:Optional
+	Endpoints []string `json:"endpoints,omitempty"`
+
+	// Provides required ONTAP cluster administrator credentials - the namespace defaults to the OntapCluster's
+	// +kubebuilder:validation:Required
+	Credentials NamespacedName `json:"credentials"`
+
+	// Provides optional TLS settings of the connection
+	// +kubebuilder:validation:Optional
+	Tls *OntapClusterTls `json:"tls,omitempty"`
+
+	// Provides optional limit of the ONTAP requests of all StorageVirtualMachines on the cluster
+	// +kubebuilder:validation:Optional
+	RateLimit *OntapClusterRateLimit `json:"rateLimit,omitempty"`
+}
+
+type OntapClusterTls struct {
+	// Provides optional skipping of the cluster certificate verification
+	// defaults to ontap.trustSSL of the operator configuration
+	// +kubebuilder:validation:Optional
+	InsecureSkipVerify *bool `json:"insecureSkipVerify,omitempty"`
+
+	// Provides optional secret with the CA certificate (ca.crt) that signed the cluster certificate
+	// the namespace defaults to the OntapCluster's
+	// +kubebuilder:validation:Optional
+	CaSecret *NamespacedName `json:"caSecret,omitempty"`
+}
+
+type OntapClusterRateLimit struct {
+	// Provides required sustained number of requests per second
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum:=1
+	RequestsPerSecond int `json:"requestsPerSecond"`
+
+	// Provides optional number of requests allowed in a burst - defaults to requestsPerSecond
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum:=1
+	Burst int `json:"burst,omitempty"`
+}
+
+// OntapClusterStatus defines the observed state of OntapCluster
+type OntapClusterStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Whether the last connectivity check succeeded
+	Connected bool `json:"connected"`
+
+	// Time of the last connectivity check
+	LastChecked *metav1.Time `json:"lastChecked,omitempty"`
+
+	// Management endpoint the operator sends its requests to
+	ActiveEndpoint string `json:"activeEndpoint,omitempty"`
+
+	// Health of each management endpoint at the last check
+	Endpoints []OntapClusterEndpoint `json:"endpoints,omitempty"`
+
+	// Cluster name and uuid reported by ONTAP
+	ClusterName string `json:"clusterName,omitempty"`
+	ClusterUuid string `json:"clusterUuid,omitempty"`
+
+	// ONTAP version, for example 9.14.1
+	Version string `json:"version,omitempty"`
+
+	// Version-dependent features the operator uses that the cluster supports
+	Features []string `json:"features,omitempty"`
+
+	// Nodes of the cluster
+	Nodes []OntapClusterNode `json:"nodes,omitempty"`
+
+	// Data aggregates of the cluster
+	Aggregates []OntapClusterAggregate `json:"aggregates,omitempty"`
+}
+
+type OntapClusterEndpoint struct {
+	Host    string `json:"host"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+type OntapClusterNode struct {
+	Name  string `json:"name"`
+	Uuid  string `json:"uuid,omitempty"`
+	Model string `json:"model,omitempty"`
+	State string `json:"state,omitempty"`
+}
+
+type OntapClusterAggregate struct {
+	Name  string `json:"name"`
+	Uuid  string `json:"uuid,omitempty"`
+	Node  string `json:"node,omitempty"`
+	State string `json:"state,omitempty"`
+
+	// Available space in bytes
+	Available int64 `json:"available,omitempty"`
+}
+
+// +kubebuilder:printcolumn:name="Host",type="string",JSONPath=`.spec.managementHost`
+// +kubebuilder:printcolumn:name="Active",type="string",JSONPath=`.status.activeEndpoint`
+// +kubebuilder:printcolumn:name="Connected",type="boolean",JSONPath=`.status.connected`
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=`.status.version`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=ontapclusters,shortName=ontap
+// OntapCluster is the Schema for the ontapclusters API
+type OntapCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OntapClusterSpec   `json:"spec,omitempty"`
+	Status OntapClusterStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// OntapClusterList contains a list of OntapCluster
+type OntapClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OntapCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OntapCluster{}, &OntapClusterList{})
+}
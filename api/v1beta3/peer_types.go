@@ -1,5 +1,6 @@
 package v1beta3
 
+// +kubebuilder:validation:XValidation:rule="has(self.passphrase) || has(self.passphraseSecret) || has(self.remoteRef)",message="one of passphrase, passphraseSecret or remoteRef is required"
 type PeerSubSpec struct {
 
 	// Provides required peering relationship name
@@ -7,10 +8,21 @@ type PeerSubSpec struct {
 	// +kubebuilder:validation:Format:=string
 	Name string `json:"name"`
 
-	// Provides required peering relationship passphrase
-	// +kubebuilder:validation:Required
+	// Provides optional peering relationship passphrase
+	// Deprecated: the passphrase is stored in plain text - use passphraseSecret or remoteRef instead
+	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Format:=string
-	Passphrase string `json:"passphrase"`
+	Passphrase string `json:"passphrase,omitempty"`
+
+	// Provides optional secret holding the peering passphrase in the passphrase key
+	// takes precedence over passphrase
+	// +kubebuilder:validation:Optional
+	PassphraseSecret *NamespacedName `json:"passphraseSecret,omitempty"`
+
+	// Provides optional StorageVirtualMachine custom resource of the remote SVM in this Kubernetes cluster
+	// the remote intercluster LIFs and SVM name are taken from it and both sides are paired automatically
+	// +kubebuilder:validation:Optional
+	RemoteRef *NamespacedName `json:"remoteRef,omitempty"`
 
 	// Provides required peering encryption
 	// +kubebuilder:validation:Required
@@ -54,3 +66,29 @@ type PeerRemote struct {
 	// +kubebuilder:validation:Format:=string
 	Svmname string `json:"svmName,omitempty"`
 }
+
+type PeerStatus struct {
+	// Provides the peering relationship name
+	Name string `json:"name"`
+
+	// Provides the local name of the cluster peer used by the relationship
+	ClusterPeerName string `json:"clusterPeerName,omitempty"`
+
+	// Provides the cluster peer state (for example available)
+	ClusterPeerState string `json:"clusterPeerState,omitempty"`
+
+	// Provides the remote cluster name
+	RemoteClusterName string `json:"remoteClusterName,omitempty"`
+
+	// Provides the remote SVM name
+	RemoteSvmName string `json:"remoteSvmName,omitempty"`
+
+	// Provides the SVM peer state (for example initiated, pending or peered)
+	SvmPeerState string `json:"svmPeerState,omitempty"`
+
+	// Provides whether this side initiates the peering with the remoteRef StorageVirtualMachine
+	Initiator bool `json:"initiator,omitempty"`
+
+	// Provides the secret holding the peering passphrase
+	PassphraseSecret *NamespacedName `json:"passphraseSecret,omitempty"`
+}
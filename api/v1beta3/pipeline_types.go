@@ -0,0 +1,30 @@
+package v1beta3
+
+// StepResult is the outcome of a reconcile step
+type StepResult string
+
+// StepResults defined here
+const (
+	StepResultSucceeded   StepResult = "Succeeded"
+	StepResultWaiting     StepResult = "Waiting"
+	StepResultFailed      StepResult = "Failed"
+	StepResultStopped     StepResult = "Stopped"
+	StepResultBlocked     StepResult = "Blocked"
+	StepResultUnsupported StepResult = "Unsupported" // uses ONTAP features the cluster's version doesn't support
+)
+
+type StepStatus struct {
+	// Provides the reconcile step name
+	Name string `json:"name"`
+
+	// Provides the condition type the step reports
+	ConditionType string `json:"conditionType,omitempty"`
+
+	// Provides the step's outcome of the last reconcile
+	// (Succeeded, Waiting, Failed, Stopped, Blocked by a step it depends on,
+	// or Unsupported by the cluster's ONTAP version)
+	Result StepResult `json:"result"`
+
+	// Provides the error or the step blocking this one
+	Message string `json:"message,omitempty"`
+}
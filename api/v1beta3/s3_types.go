@@ -1,5 +1,9 @@
 package v1beta3
 
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 type S3SubSpec struct {
 
 	// Provides required S3 enablement
@@ -19,6 +23,14 @@ type S3SubSpec struct {
 	// +kubebuilder:validation:Optional
 	Users []S3User `json:"users,omitempty"`
 
+	// Provides optional S3 group definitions
+	// +kubebuilder:validation:Optional
+	Groups []S3Group `json:"groups,omitempty"`
+
+	// Provides optional S3 server-level access policy definitions
+	// +kubebuilder:validation:Optional
+	Policies []S3Policy `json:"policies,omitempty"`
+
 	// Provides optional S3 Http definition
 	// +kubebuilder:validation:Optional
 	Http *S3Http `json:"http,omitempty"`
@@ -30,6 +42,27 @@ type S3SubSpec struct {
 	// Provides optional buckets definition
 	// +kubebuilder:validation:Optional
 	Buckets []S3Bucket `json:"buckets,omitempty"`
+
+	// Provides optional UNIX user for S3 users without a name mapping accessing NAS buckets
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	DefaultUnixUser string `json:"defaultUnixUser,omitempty"`
+
+	// Provides optional ordered S3 to UNIX user name mappings for NAS buckets - mapping indexes follow list order
+	// +kubebuilder:validation:Optional
+	NameMappings []S3NameMapping `json:"nameMappings,omitempty"`
+}
+
+type S3NameMapping struct {
+	// Provides required S3 user name pattern as a regular expression (for example ^app-(.*)$)
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Pattern string `json:"pattern"`
+
+	// Provides required UNIX user name replacement (for example \1 or appuser)
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Replacement string `json:"replacement"`
 }
 
 type S3User struct {
@@ -41,6 +74,134 @@ type S3User struct {
 	// Provides optional namespace
 	//+kubebuilder:validation:Optional
 	Namespace *string `json:"namespace,omitempty"`
+
+	// Provides optional interval after which the user's keys are regenerated (for example 720h)
+	// keys can also be regenerated on demand with the gateway.netapp.com/rotate-keys annotation on the user's secret
+	// +kubebuilder:validation:Optional
+	RotationInterval *metav1.Duration `json:"rotationInterval,omitempty"`
+
+	// Provides optional period both the previous and the new keys are valid after a rotation (for example 1h)
+	// ONTAP keeps a single key pair per user, so the new keys belong to a second ONTAP user
+	// named <name>-rotated (or <name> again on the next rotation) that replaces the previous one after the period
+	// +kubebuilder:validation:Optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+type S3Status struct {
+	// Provides the managed S3 users
+	Users []S3UserStatus `json:"users,omitempty"`
+
+	// Provides the server certificate bound to the S3 server
+	Certificate *S3CertificateStatus `json:"certificate,omitempty"`
+
+	// Provides the S3 to UNIX name mappings applied by the operator
+	// only these are deleted when removed from the custom resource
+	NameMappings []S3NameMapping `json:"nameMappings,omitempty"`
+
+	// Provides the names of the S3 groups created by the operator
+	// only these and groups with the operator's comment are deleted when removed from the custom resource
+	Groups []string `json:"groups,omitempty"`
+
+	// Provides the names of the S3 policies created by the operator
+	// only these and policies with the operator's comment are deleted when removed from the custom resource
+	Policies []string `json:"policies,omitempty"`
+}
+
+type S3CertificateStatus struct {
+	// Provides the certificate name on the SVM
+	Name string `json:"name,omitempty"`
+
+	// Provides the certificate uuid on the SVM
+	Uuid string `json:"uuid,omitempty"`
+
+	// Provides the certificate common name
+	CommonName string `json:"commonName,omitempty"`
+
+	// Provides the SHA-256 of the certificate installed from the tls secret
+	SecretHash string `json:"secretHash,omitempty"`
+
+	// Provides the certificate expiry
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+}
+
+type S3UserStatus struct {
+	// Provides the user name
+	Name string `json:"name"`
+
+	// Provides the secret holding the user's keys
+	Secret NamespacedName `json:"secret"`
+
+	// Provides the time the current keys were generated
+	KeysGenerated *metav1.Time `json:"keysGenerated,omitempty"`
+
+	// Provides the ONTAP user holding the current keys when it isn't the user name
+	OntapUser string `json:"ontapUser,omitempty"`
+
+	// Provides the ONTAP user holding the previous keys during the grace period
+	PreviousOntapUser string `json:"previousOntapUser,omitempty"`
+
+	// Provides the time the previous keys stop being valid
+	PreviousKeysExpire *metav1.Time `json:"previousKeysExpire,omitempty"`
+}
+
+type S3Group struct {
+	// Provides required group name
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Name string `json:"name"`
+
+	// Provides optional group comment
+	// groups created by the operator are recorded in the status whatever their comment
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	Comment string `json:"comment,omitempty"`
+
+	// Provides required S3 user names in the group
+	// +kubebuilder:validation:Required
+	Users []string `json:"users"`
+
+	// Provides required policy names attached to the group
+	// built-in policies FullAccess, NoS3Access and ReadOnlyAccess can be used as well
+	// +kubebuilder:validation:Required
+	Policies []string `json:"policies"`
+}
+
+type S3Policy struct {
+	// Provides required policy name
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Name string `json:"name"`
+
+	// Provides optional policy comment
+	// policies created by the operator are recorded in the status whatever their comment
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	Comment string `json:"comment,omitempty"`
+
+	// Provides required policy statements
+	// +kubebuilder:validation:Required
+	Statements []S3PolicyStatement `json:"statements"`
+}
+
+type S3PolicyStatement struct {
+	// Provides optional statement identifier
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	Sid string `json:"sid,omitempty"`
+
+	// Provides optional statement effect
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=allow;deny
+	// +kubebuilder:default:=allow
+	Effect string `json:"effect,omitempty"`
+
+	// Provides required S3 actions (for example GetObject, PutObject, ListBucket or *)
+	// +kubebuilder:validation:Required
+	Actions []string `json:"actions"`
+
+	// Provides required resources as bucket names or bucket/prefix (for example tp-src/logs/*)
+	// +kubebuilder:validation:Required
+	Resources []string `json:"resources"`
 }
 
 type S3Http struct {
@@ -54,6 +215,7 @@ type S3Http struct {
 	Port int `json:"port"`
 }
 
+// +kubebuilder:validation:XValidation:rule="has(self.caCertificate) || has(self.tlsSecret)",message="either caCertificate or tlsSecret is required"
 type S3Https struct {
 	// Provides required S3 https enablement
 	// +kubebuilder:validation:Required
@@ -64,9 +226,14 @@ type S3Https struct {
 	// +kubebuilder:default:=443
 	Port int `json:"port"`
 
-	// Provides required S3 certificate
-	// +kubebuilder:validation:Required
-	Certificate Certificate `json:"caCertificate"`
+	// Provides optional CA certificate the operator creates on the SVM to sign the server certificate
+	// +kubebuilder:validation:Optional
+	Certificate *Certificate `json:"caCertificate,omitempty"`
+
+	// Provides optional kubernetes.io/tls secret (for example issued by cert-manager) holding the server certificate
+	// takes precedence over caCertificate and is re-installed when the secret changes
+	// +kubebuilder:validation:Optional
+	TlsSecret *NamespacedName `json:"tlsSecret,omitempty"`
 }
 
 type Certificate struct {
@@ -86,6 +253,7 @@ type Certificate struct {
 	ExpiryTime string `json:"expiryTime"`
 }
 
+// +kubebuilder:validation:XValidation:rule="has(self.nas) == (has(self.type) && self.type == 'nas')",message="nas is required for and only allowed on buckets of type nas"
 type S3Bucket struct {
 	// Provides required S3 bucket name
 	// +kubebuilder:validation:Required
@@ -103,6 +271,123 @@ type S3Bucket struct {
 
 	// Provides optional S3 bucket type
 	// +kubebuilder:validation:Optional
-	// +kubebuilder:validation:Format:=string
+	// +kubebuilder:validation:Enum=s3;nas
 	Type string `json:"type,omitempty"`
+
+	// Provides optional NFS volume path served by a bucket of type nas
+	// +kubebuilder:validation:Optional
+	Nas *S3NasBucket `json:"nas,omitempty"`
+
+	// Provides optional S3 bucket access policy
+	// when omitted, a new bucket grants all S3 users read/write access
+	// +kubebuilder:validation:Optional
+	Policy *S3BucketPolicy `json:"policy,omitempty"`
+
+	// Provides optional S3 bucket versioning state
+	// ONTAP doesn't allow disabling versioning once it has been enabled
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=enabled;suspended
+	Versioning string `json:"versioning,omitempty"`
+
+	// Provides optional S3 bucket lifecycle rules
+	// +kubebuilder:validation:Optional
+	LifecycleRules []S3LifecycleRule `json:"lifecycleRules,omitempty"`
+
+	// Provides optional S3 bucket object locking and retention
+	// +kubebuilder:validation:Optional
+	Retention *S3BucketRetention `json:"retention,omitempty"`
+}
+
+type S3NasBucket struct {
+	// Provides required name of the FlexVol volume backing the bucket
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Volume string `json:"volume"`
+
+	// Provides optional junction path served by the bucket - defaults to the volume's junction path
+	// a path below the junction path (for example a qtree) can be used as well
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	Path string `json:"path,omitempty"`
+
+	// Provides optional volume size in bytes - when set, the volume is created if it doesn't exist
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=20971520
+	Size int `json:"size,omitempty"`
+}
+
+type S3BucketPolicy struct {
+	// Provides required S3 bucket policy statements
+	// +kubebuilder:validation:Required
+	Statements []S3BucketPolicyStatement `json:"statements"`
+}
+
+type S3BucketPolicyStatement struct {
+	// Provides optional statement identifier
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	Sid string `json:"sid,omitempty"`
+
+	// Provides optional statement effect
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=allow;deny
+	// +kubebuilder:default:=allow
+	Effect string `json:"effect,omitempty"`
+
+	// Provides required S3 actions (for example GetObject, PutObject, ListBucket or *)
+	// +kubebuilder:validation:Required
+	Actions []string `json:"actions"`
+
+	// Provides required S3 users or groups (group/<name>) the statement applies to
+	// +kubebuilder:validation:Required
+	Principals []string `json:"principals"`
+
+	// Provides required resources as the bucket name or bucket/prefix (for example tp-src/logs/*)
+	// +kubebuilder:validation:Required
+	Resources []string `json:"resources"`
+}
+
+type S3LifecycleRule struct {
+	// Provides required lifecycle rule name
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format:=string
+	Name string `json:"name"`
+
+	// Provides optional lifecycle rule enablement
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:=true
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Provides optional object key prefix the rule applies to
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	Prefix string `json:"prefix,omitempty"`
+
+	// Provides optional number of days after which objects expire
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	ExpirationDays *int `json:"expirationDays,omitempty"`
+
+	// Provides optional number of days after which non-current object versions expire
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	NonCurrentExpirationDays *int `json:"nonCurrentExpirationDays,omitempty"`
+
+	// Provides optional number of days after which incomplete multipart uploads are aborted
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	AbortIncompleteMultipartUploadDays *int `json:"abortIncompleteMultipartUploadDays,omitempty"`
+}
+
+type S3BucketRetention struct {
+	// Provides optional object locking mode
+	// the mode can only be set when the bucket is created and requires SnapLock
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=no_lock;compliance;governance
+	Mode string `json:"mode,omitempty"`
+
+	// Provides optional default retention period in ISO-8601 duration format (for example P30D or P1Y)
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format:=string
+	DefaultPeriod string `json:"defaultPeriod,omitempty"`
 }
@@ -28,6 +28,7 @@ const (
 )
 
 // StorageVirtualMachineSpec defines the desired state of StorageVirtualMachine
+// +kubebuilder:validation:XValidation:rule="has(self.clusterRef) || (has(self.clusterHost) && has(self.clusterCredentials))",message="either clusterRef or clusterHost and clusterCredentials are required"
 type StorageVirtualMachineSpec struct {
 	// Provides required SVM name
 	// +kubebuilder:validation:Required
@@ -36,12 +37,19 @@ type StorageVirtualMachineSpec struct {
 	/// +kubebuilder:validation:Format:=hostname
 	SvmName string `json:"svmName"`
 
-	// Provides required Cluster management LIF host IP address or host name
-	// +kubebuilder:validation:Required
+	// Provides optional OntapCluster holding the cluster connection - the namespace defaults to the custom resource's
+	// +kubebuilder:validation:Optional
+	ClusterRef *NamespacedName `json:"clusterRef,omitempty"`
+
+	// Provides Cluster management LIF host IP address or host name
+	// Deprecated: use clusterRef - only used when clusterRef isn't set
+	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Pattern=`((^\s*((([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])\.){3}([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5]))\s*$)|(^\s*((([0-9A-Fa-f]{1,4}:){7}([0-9A-Fa-f]{1,4}|:))|(([0-9A-Fa-f]{1,4}:){6}(:[0-9A-Fa-f]{1,4}|((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3})|:))|(([0-9A-Fa-f]{1,4}:){5}(((:[0-9A-Fa-f]{1,4}){1,2})|:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3})|:))|(([0-9A-Fa-f]{1,4}:){4}(((:[0-9A-Fa-f]{1,4}){1,3})|((:[0-9A-Fa-f]{1,4})?:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3}))|:))|(([0-9A-Fa-f]{1,4}:){3}(((:[0-9A-Fa-f]{1,4}){1,4})|((:[0-9A-Fa-f]{1,4}){0,2}:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3}))|:))|(([0-9A-Fa-f]{1,4}:){2}(((:[0-9A-Fa-f]{1,4}){1,5})|((:[0-9A-Fa-f]{1,4}){0,3}:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3}))|:))|(([0-9A-Fa-f]{1,4}:){1}(((:[0-9A-Fa-f]{1,4}){1,6})|((:[0-9A-Fa-f]{1,4}){0,4}:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3}))|:))|(:(((:[0-9A-Fa-f]{1,4}){1,7})|((:[0-9A-Fa-f]{1,4}){0,5}:((25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)(\.(25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)){3}))|:)))(%.+)?\s*$))`
-	ClusterManagementHost string `json:"clusterHost"`
+	ClusterManagementHost string `json:"clusterHost,omitempty"`
 
-	// Stores SVM's uuid after it is created
+	// Provides optional uuid of an existing SVM to manage instead of creating one
+	// the operator reports the uuid of the managed SVM in status.svmUuid
+	// +kubebuilder:validation:Optional
 	SvmUuid string `json:"svmUuid,omitempty"`
 
 	// Stores optional SVM's comment
@@ -66,9 +74,10 @@ type StorageVirtualMachineSpec struct {
 	// +kubebuilder:validation:Optional
 	ManagementLIF *LIF `json:"management,omitempty"`
 
-	// Provides required ONTAP cluster administrator credentials
-	// +kubebuilder:validation:Required
-	ClusterCredentialSecret NamespacedName `json:"clusterCredentials"`
+	// Provides ONTAP cluster administrator credentials
+	// Deprecated: use clusterRef - only used when clusterRef isn't set
+	// +kubebuilder:validation:Optional
+	ClusterCredentialSecret NamespacedName `json:"clusterCredentials,omitempty"`
 
 	// Provides optional SVM administrator credentials
 	// +kubebuilder:validation:Optional
@@ -91,8 +100,15 @@ type StorageVirtualMachineSpec struct {
 	S3Config *S3SubSpec `json:"s3,omitempty"`
 
 	// Provide optional SVM peering configuration
+	// Deprecated: a single relationship - use peers instead
 	// +kubebuilder:validation:Optional
 	PeerConfig *PeerSubSpec `json:"peer,omitempty"`
+
+	// Provide optional SVM peering relationships, each with its own remote cluster and SVM
+	// +kubebuilder:validation:Optional
+	// +listType=map
+	// +listMapKey=name
+	Peers []PeerSubSpec `json:"peers,omitempty"`
 }
 
 // StorageVirtualMachineStatus defines the observed state of StorageVirtualMachine
@@ -100,11 +116,41 @@ type StorageVirtualMachineStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 	Conditions []metav1.Condition `json:"conditions"`
+
+	// SVM's uuid after it is created or adopted
+	SvmUuid string `json:"svmUuid,omitempty"`
+
+	// Cluster management endpoint the last reconcile sent its requests to
+	ClusterEndpoint string `json:"clusterEndpoint,omitempty"`
+
+	// Effective NFS service settings
+	Nfs *NfsStatus `json:"nfs,omitempty"`
+
+	// Managed iSCSI igroups and CHAP settings
+	Iscsi *IscsiStatus `json:"iscsi,omitempty"`
+
+	// Managed NVMe subsystems
+	Nvme *NvmeStatus `json:"nvme,omitempty"`
+
+	// Managed S3 users and their key rotation
+	S3 *S3Status `json:"s3,omitempty"`
+
+	// Cluster and SVM peering state of each relationship
+	Peers []PeerStatus `json:"peers,omitempty"`
+
+	// Cluster-scoped ONTAP objects created by the operator - only these are deleted with the custom resource
+	Inventory *InventoryStatus `json:"inventory,omitempty"`
+
+	// ONTAP jobs started and not yet seen completing - resumed after an operator restart
+	Jobs []JobStatus `json:"jobs,omitempty"`
+
+	// Outcome of each reconcile step of the last reconcile - shows where the reconcile stopped
+	Steps []StepStatus `json:"steps,omitempty"`
 }
 
 // CHECK OUT THIS:  https://www.brendanp.com/pretty-printing-with-kubebuilder/
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
-// +kubebuilder:printcolumn:name="SVM UUID",type="string",JSONPath=`.spec.svmUuid`
+// +kubebuilder:printcolumn:name="SVM UUID",type="string",JSONPath=`.status.svmUuid`
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=storagevirtualmachines,shortName=svm
@@ -138,3 +184,13 @@ func (svm *StorageVirtualMachine) GetConditions() []metav1.Condition {
 func (svm *StorageVirtualMachine) SetConditions(conditions []metav1.Condition) {
 	svm.Status.Conditions = conditions
 }
+
+// GetSvmUuid returns the uuid of the managed SVM
+// falls back to the spec until the uuid is recorded in the status - the spec holds the uuid of an SVM
+// to adopt or, for custom resources created by earlier versions, the uuid the operator wrote there
+func (svm *StorageVirtualMachine) GetSvmUuid() string {
+	if svm.Status.SvmUuid != "" {
+		return svm.Status.SvmUuid
+	}
+	return svm.Spec.SvmUuid
+}
@@ -55,6 +55,158 @@ func (in *Certificate) DeepCopy() *Certificate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InventoryStatus) DeepCopyInto(out *InventoryStatus) {
+	*out = *in
+	if in.ClusterPeers != nil {
+		in, out := &in.ClusterPeers, &out.ClusterPeers
+		*out = make([]OwnedObject, len(*in))
+		copy(*out, *in)
+	}
+	if in.InterclusterLifs != nil {
+		in, out := &in.InterclusterLifs, &out.InterclusterLifs
+		*out = make([]OwnedObject, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryStatus.
+func (in *InventoryStatus) DeepCopy() *InventoryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InventoryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IscsiChap) DeepCopyInto(out *IscsiChap) {
+	*out = *in
+	if in.Inbound != nil {
+		in, out := &in.Inbound, &out.Inbound
+		*out = new(NamespacedName)
+		**out = **in
+	}
+	if in.Outbound != nil {
+		in, out := &in.Outbound, &out.Outbound
+		*out = new(NamespacedName)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IscsiChap.
+func (in *IscsiChap) DeepCopy() *IscsiChap {
+	if in == nil {
+		return nil
+	}
+	out := new(IscsiChap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IscsiChapStatus) DeepCopyInto(out *IscsiChapStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IscsiChapStatus.
+func (in *IscsiChapStatus) DeepCopy() *IscsiChapStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IscsiChapStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IscsiIgroup) DeepCopyInto(out *IscsiIgroup) {
+	*out = *in
+	if in.Initiators != nil {
+		in, out := &in.Initiators, &out.Initiators
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IscsiIgroup.
+func (in *IscsiIgroup) DeepCopy() *IscsiIgroup {
+	if in == nil {
+		return nil
+	}
+	out := new(IscsiIgroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IscsiIgroupStatus) DeepCopyInto(out *IscsiIgroupStatus) {
+	*out = *in
+	if in.Initiators != nil {
+		in, out := &in.Initiators, &out.Initiators
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IscsiIgroupStatus.
+func (in *IscsiIgroupStatus) DeepCopy() *IscsiIgroupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IscsiIgroupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IscsiNodeIgroup) DeepCopyInto(out *IscsiNodeIgroup) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IscsiNodeIgroup.
+func (in *IscsiNodeIgroup) DeepCopy() *IscsiNodeIgroup {
+	if in == nil {
+		return nil
+	}
+	out := new(IscsiNodeIgroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IscsiStatus) DeepCopyInto(out *IscsiStatus) {
+	*out = *in
+	if in.Igroups != nil {
+		in, out := &in.Igroups, &out.Igroups
+		*out = make([]IscsiIgroupStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Chap != nil {
+		in, out := &in.Chap, &out.Chap
+		*out = make([]IscsiChapStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IscsiStatus.
+func (in *IscsiStatus) DeepCopy() *IscsiStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IscsiStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IscsiSubSpec) DeepCopyInto(out *IscsiSubSpec) {
 	*out = *in
@@ -63,226 +215,1202 @@ func (in *IscsiSubSpec) DeepCopyInto(out *IscsiSubSpec) {
 		*out = make([]LIF, len(*in))
 		copy(*out, *in)
 	}
+	if in.Igroups != nil {
+		in, out := &in.Igroups, &out.Igroups
+		*out = make([]IscsiIgroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Chap != nil {
+		in, out := &in.Chap, &out.Chap
+		*out = make([]IscsiChap, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeIgroup != nil {
+		in, out := &in.NodeIgroup, &out.NodeIgroup
+		*out = new(IscsiNodeIgroup)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IscsiSubSpec.
+func (in *IscsiSubSpec) DeepCopy() *IscsiSubSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IscsiSubSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobStatus) DeepCopyInto(out *JobStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobStatus.
+func (in *JobStatus) DeepCopy() *JobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(JobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LIF) DeepCopyInto(out *LIF) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LIF.
+func (in *LIF) DeepCopy() *LIF {
+	if in == nil {
+		return nil
+	}
+	out := new(LIF)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedName) DeepCopyInto(out *NamespacedName) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedName.
+func (in *NamespacedName) DeepCopy() *NamespacedName {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedName)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NfsExport) DeepCopyInto(out *NfsExport) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]NfsRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsExport.
+func (in *NfsExport) DeepCopy() *NfsExport {
+	if in == nil {
+		return nil
+	}
+	out := new(NfsExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NfsExportPolicy) DeepCopyInto(out *NfsExportPolicy) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]NfsExportRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Qtrees != nil {
+		in, out := &in.Qtrees, &out.Qtrees
+		*out = make([]NfsQtree, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsExportPolicy.
+func (in *NfsExportPolicy) DeepCopy() *NfsExportPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NfsExportPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NfsExportRule) DeepCopyInto(out *NfsExportRule) {
+	*out = *in
+	if in.Clients != nil {
+		in, out := &in.Clients, &out.Clients
+		*out = make([]NfsRuleClient, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClientsFromNodes != nil {
+		in, out := &in.ClientsFromNodes, &out.ClientsFromNodes
+		*out = new(NfsNodeClients)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Protocols != nil {
+		in, out := &in.Protocols, &out.Protocols
+		*out = make([]NfsRuleProtocol, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rw != nil {
+		in, out := &in.Rw, &out.Rw
+		*out = make([]NfsRuleAuthFlavor, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ro != nil {
+		in, out := &in.Ro, &out.Ro
+		*out = make([]NfsRuleAuthFlavor, len(*in))
+		copy(*out, *in)
+	}
+	if in.Superuser != nil {
+		in, out := &in.Superuser, &out.Superuser
+		*out = make([]NfsRuleAuthFlavor, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowSuid != nil {
+		in, out := &in.AllowSuid, &out.AllowSuid
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsExportRule.
+func (in *NfsExportRule) DeepCopy() *NfsExportRule {
+	if in == nil {
+		return nil
+	}
+	out := new(NfsExportRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NfsKerberosInterface) DeepCopyInto(out *NfsKerberosInterface) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(NamespacedName)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsKerberosInterface.
+func (in *NfsKerberosInterface) DeepCopy() *NfsKerberosInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(NfsKerberosInterface)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NfsKerberosInterfaceStatus) DeepCopyInto(out *NfsKerberosInterfaceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsKerberosInterfaceStatus.
+func (in *NfsKerberosInterfaceStatus) DeepCopy() *NfsKerberosInterfaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NfsKerberosInterfaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NfsNodeClients) DeepCopyInto(out *NfsNodeClients) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PrefixLength != nil {
+		in, out := &in.PrefixLength, &out.PrefixLength
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsNodeClients.
+func (in *NfsNodeClients) DeepCopy() *NfsNodeClients {
+	if in == nil {
+		return nil
+	}
+	out := new(NfsNodeClients)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NfsQtree) DeepCopyInto(out *NfsQtree) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsQtree.
+func (in *NfsQtree) DeepCopy() *NfsQtree {
+	if in == nil {
+		return nil
+	}
+	out := new(NfsQtree)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NfsRule) DeepCopyInto(out *NfsRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsRule.
+func (in *NfsRule) DeepCopy() *NfsRule {
+	if in == nil {
+		return nil
+	}
+	out := new(NfsRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NfsRuleClient) DeepCopyInto(out *NfsRuleClient) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsRuleClient.
+func (in *NfsRuleClient) DeepCopy() *NfsRuleClient {
+	if in == nil {
+		return nil
+	}
+	out := new(NfsRuleClient)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NfsStatus) DeepCopyInto(out *NfsStatus) {
+	*out = *in
+	if in.Kerberos != nil {
+		in, out := &in.Kerberos, &out.Kerberos
+		*out = make([]NfsKerberosInterfaceStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsStatus.
+func (in *NfsStatus) DeepCopy() *NfsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NfsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NfsSubSpec) DeepCopyInto(out *NfsSubSpec) {
+	*out = *in
+	if in.Showmount != nil {
+		in, out := &in.Showmount, &out.Showmount
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Vstorage != nil {
+		in, out := &in.Vstorage, &out.Vstorage
+		*out = new(bool)
+		**out = **in
+	}
+	if in.FileId64bit != nil {
+		in, out := &in.FileId64bit, &out.FileId64bit
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TcpMaxTransferSize != nil {
+		in, out := &in.TcpMaxTransferSize, &out.TcpMaxTransferSize
+		*out = new(int)
+		**out = **in
+	}
+	if in.QtreeExports != nil {
+		in, out := &in.QtreeExports, &out.QtreeExports
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Kerberos != nil {
+		in, out := &in.Kerberos, &out.Kerberos
+		*out = make([]NfsKerberosInterface, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Lifs != nil {
+		in, out := &in.Lifs, &out.Lifs
+		*out = make([]LIF, len(*in))
+		copy(*out, *in)
+	}
+	if in.Export != nil {
+		in, out := &in.Export, &out.Export
+		*out = new(NfsExport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExportPolicies != nil {
+		in, out := &in.ExportPolicies, &out.ExportPolicies
+		*out = make([]NfsExportPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsSubSpec.
+func (in *NfsSubSpec) DeepCopy() *NfsSubSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NfsSubSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NvmeDhHmacChap) DeepCopyInto(out *NvmeDhHmacChap) {
+	*out = *in
+	out.Secret = in.Secret
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NvmeDhHmacChap.
+func (in *NvmeDhHmacChap) DeepCopy() *NvmeDhHmacChap {
+	if in == nil {
+		return nil
+	}
+	out := new(NvmeDhHmacChap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NvmeHost) DeepCopyInto(out *NvmeHost) {
+	*out = *in
+	if in.DhHmacChap != nil {
+		in, out := &in.DhHmacChap, &out.DhHmacChap
+		*out = new(NvmeDhHmacChap)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NvmeHost.
+func (in *NvmeHost) DeepCopy() *NvmeHost {
+	if in == nil {
+		return nil
+	}
+	out := new(NvmeHost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NvmeNodeSubsystem) DeepCopyInto(out *NvmeNodeSubsystem) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NvmeNodeSubsystem.
+func (in *NvmeNodeSubsystem) DeepCopy() *NvmeNodeSubsystem {
+	if in == nil {
+		return nil
+	}
+	out := new(NvmeNodeSubsystem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NvmeStatus) DeepCopyInto(out *NvmeStatus) {
+	*out = *in
+	if in.Subsystems != nil {
+		in, out := &in.Subsystems, &out.Subsystems
+		*out = make([]NvmeSubsystemStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NvmeStatus.
+func (in *NvmeStatus) DeepCopy() *NvmeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NvmeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NvmeSubSpec) DeepCopyInto(out *NvmeSubSpec) {
+	*out = *in
+	if in.Lifs != nil {
+		in, out := &in.Lifs, &out.Lifs
+		*out = make([]LIF, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeSubsystem != nil {
+		in, out := &in.NodeSubsystem, &out.NodeSubsystem
+		*out = new(NvmeNodeSubsystem)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Subsystems != nil {
+		in, out := &in.Subsystems, &out.Subsystems
+		*out = make([]NvmeSubsystem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NvmeSubSpec.
+func (in *NvmeSubSpec) DeepCopy() *NvmeSubSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NvmeSubSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NvmeSubsystem) DeepCopyInto(out *NvmeSubsystem) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]NvmeHost, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NvmeSubsystem.
+func (in *NvmeSubsystem) DeepCopy() *NvmeSubsystem {
+	if in == nil {
+		return nil
+	}
+	out := new(NvmeSubsystem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NvmeSubsystemStatus) DeepCopyInto(out *NvmeSubsystemStatus) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.KeyVersions != nil {
+		in, out := &in.KeyVersions, &out.KeyVersions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NvmeSubsystemStatus.
+func (in *NvmeSubsystemStatus) DeepCopy() *NvmeSubsystemStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NvmeSubsystemStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OntapCluster) DeepCopyInto(out *OntapCluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OntapCluster.
+func (in *OntapCluster) DeepCopy() *OntapCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(OntapCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OntapCluster) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OntapClusterAggregate) DeepCopyInto(out *OntapClusterAggregate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OntapClusterAggregate.
+func (in *OntapClusterAggregate) DeepCopy() *OntapClusterAggregate {
+	if in == nil {
+		return nil
+	}
+	out := new(OntapClusterAggregate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OntapClusterEndpoint) DeepCopyInto(out *OntapClusterEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OntapClusterEndpoint.
+func (in *OntapClusterEndpoint) DeepCopy() *OntapClusterEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(OntapClusterEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OntapClusterList) DeepCopyInto(out *OntapClusterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OntapCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OntapClusterList.
+func (in *OntapClusterList) DeepCopy() *OntapClusterList {
+	if in == nil {
+		return nil
+	}
+	out := new(OntapClusterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OntapClusterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OntapClusterNode) DeepCopyInto(out *OntapClusterNode) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OntapClusterNode.
+func (in *OntapClusterNode) DeepCopy() *OntapClusterNode {
+	if in == nil {
+		return nil
+	}
+	out := new(OntapClusterNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OntapClusterRateLimit) DeepCopyInto(out *OntapClusterRateLimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OntapClusterRateLimit.
+func (in *OntapClusterRateLimit) DeepCopy() *OntapClusterRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(OntapClusterRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OntapClusterSpec) DeepCopyInto(out *OntapClusterSpec) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Credentials = in.Credentials
+	if in.Tls != nil {
+		in, out := &in.Tls, &out.Tls
+		*out = new(OntapClusterTls)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(OntapClusterRateLimit)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OntapClusterSpec.
+func (in *OntapClusterSpec) DeepCopy() *OntapClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OntapClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OntapClusterStatus) DeepCopyInto(out *OntapClusterStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastChecked != nil {
+		in, out := &in.LastChecked, &out.LastChecked
+		*out = (*in).DeepCopy()
+	}
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]OntapClusterEndpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.Features != nil {
+		in, out := &in.Features, &out.Features
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]OntapClusterNode, len(*in))
+		copy(*out, *in)
+	}
+	if in.Aggregates != nil {
+		in, out := &in.Aggregates, &out.Aggregates
+		*out = make([]OntapClusterAggregate, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OntapClusterStatus.
+func (in *OntapClusterStatus) DeepCopy() *OntapClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OntapClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OntapClusterTls) DeepCopyInto(out *OntapClusterTls) {
+	*out = *in
+	if in.InsecureSkipVerify != nil {
+		in, out := &in.InsecureSkipVerify, &out.InsecureSkipVerify
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CaSecret != nil {
+		in, out := &in.CaSecret, &out.CaSecret
+		*out = new(NamespacedName)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OntapClusterTls.
+func (in *OntapClusterTls) DeepCopy() *OntapClusterTls {
+	if in == nil {
+		return nil
+	}
+	out := new(OntapClusterTls)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnedObject) DeepCopyInto(out *OwnedObject) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnedObject.
+func (in *OwnedObject) DeepCopy() *OwnedObject {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnedObject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeerApplication) DeepCopyInto(out *PeerApplication) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeerApplication.
+func (in *PeerApplication) DeepCopy() *PeerApplication {
+	if in == nil {
+		return nil
+	}
+	out := new(PeerApplication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeerRemote) DeepCopyInto(out *PeerRemote) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeerRemote.
+func (in *PeerRemote) DeepCopy() *PeerRemote {
+	if in == nil {
+		return nil
+	}
+	out := new(PeerRemote)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeerStatus) DeepCopyInto(out *PeerStatus) {
+	*out = *in
+	if in.PassphraseSecret != nil {
+		in, out := &in.PassphraseSecret, &out.PassphraseSecret
+		*out = new(NamespacedName)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeerStatus.
+func (in *PeerStatus) DeepCopy() *PeerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PeerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeerSubSpec) DeepCopyInto(out *PeerSubSpec) {
+	*out = *in
+	if in.PassphraseSecret != nil {
+		in, out := &in.PassphraseSecret, &out.PassphraseSecret
+		*out = new(NamespacedName)
+		**out = **in
+	}
+	if in.RemoteRef != nil {
+		in, out := &in.RemoteRef, &out.RemoteRef
+		*out = new(NamespacedName)
+		**out = **in
+	}
+	if in.Applications != nil {
+		in, out := &in.Applications, &out.Applications
+		*out = make([]PeerApplication, len(*in))
+		copy(*out, *in)
+	}
+	out.Remote = in.Remote
+	if in.Lifs != nil {
+		in, out := &in.Lifs, &out.Lifs
+		*out = make([]LIF, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IscsiSubSpec.
-func (in *IscsiSubSpec) DeepCopy() *IscsiSubSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeerSubSpec.
+func (in *PeerSubSpec) DeepCopy() *PeerSubSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(IscsiSubSpec)
+	out := new(PeerSubSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LIF) DeepCopyInto(out *LIF) {
+func (in *S3Bucket) DeepCopyInto(out *S3Bucket) {
 	*out = *in
+	if in.Nas != nil {
+		in, out := &in.Nas, &out.Nas
+		*out = new(S3NasBucket)
+		**out = **in
+	}
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(S3BucketPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LifecycleRules != nil {
+		in, out := &in.LifecycleRules, &out.LifecycleRules
+		*out = make([]S3LifecycleRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(S3BucketRetention)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LIF.
-func (in *LIF) DeepCopy() *LIF {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Bucket.
+func (in *S3Bucket) DeepCopy() *S3Bucket {
 	if in == nil {
 		return nil
 	}
-	out := new(LIF)
+	out := new(S3Bucket)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NamespacedName) DeepCopyInto(out *NamespacedName) {
+func (in *S3BucketPolicy) DeepCopyInto(out *S3BucketPolicy) {
 	*out = *in
+	if in.Statements != nil {
+		in, out := &in.Statements, &out.Statements
+		*out = make([]S3BucketPolicyStatement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedName.
-func (in *NamespacedName) DeepCopy() *NamespacedName {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3BucketPolicy.
+func (in *S3BucketPolicy) DeepCopy() *S3BucketPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(NamespacedName)
+	out := new(S3BucketPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NfsExport) DeepCopyInto(out *NfsExport) {
+func (in *S3BucketPolicyStatement) DeepCopyInto(out *S3BucketPolicyStatement) {
 	*out = *in
-	if in.Rules != nil {
-		in, out := &in.Rules, &out.Rules
-		*out = make([]NfsRule, len(*in))
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Principals != nil {
+		in, out := &in.Principals, &out.Principals
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsExport.
-func (in *NfsExport) DeepCopy() *NfsExport {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3BucketPolicyStatement.
+func (in *S3BucketPolicyStatement) DeepCopy() *S3BucketPolicyStatement {
 	if in == nil {
 		return nil
 	}
-	out := new(NfsExport)
+	out := new(S3BucketPolicyStatement)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NfsRule) DeepCopyInto(out *NfsRule) {
+func (in *S3BucketRetention) DeepCopyInto(out *S3BucketRetention) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsRule.
-func (in *NfsRule) DeepCopy() *NfsRule {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3BucketRetention.
+func (in *S3BucketRetention) DeepCopy() *S3BucketRetention {
 	if in == nil {
 		return nil
 	}
-	out := new(NfsRule)
+	out := new(S3BucketRetention)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NfsSubSpec) DeepCopyInto(out *NfsSubSpec) {
+func (in *S3CertificateStatus) DeepCopyInto(out *S3CertificateStatus) {
 	*out = *in
-	if in.Lifs != nil {
-		in, out := &in.Lifs, &out.Lifs
-		*out = make([]LIF, len(*in))
-		copy(*out, *in)
-	}
-	if in.Export != nil {
-		in, out := &in.Export, &out.Export
-		*out = new(NfsExport)
-		(*in).DeepCopyInto(*out)
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NfsSubSpec.
-func (in *NfsSubSpec) DeepCopy() *NfsSubSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3CertificateStatus.
+func (in *S3CertificateStatus) DeepCopy() *S3CertificateStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(NfsSubSpec)
+	out := new(S3CertificateStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NvmeSubSpec) DeepCopyInto(out *NvmeSubSpec) {
+func (in *S3Group) DeepCopyInto(out *S3Group) {
 	*out = *in
-	if in.Lifs != nil {
-		in, out := &in.Lifs, &out.Lifs
-		*out = make([]LIF, len(*in))
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NvmeSubSpec.
-func (in *NvmeSubSpec) DeepCopy() *NvmeSubSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Group.
+func (in *S3Group) DeepCopy() *S3Group {
 	if in == nil {
 		return nil
 	}
-	out := new(NvmeSubSpec)
+	out := new(S3Group)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PeerApplication) DeepCopyInto(out *PeerApplication) {
+func (in *S3Http) DeepCopyInto(out *S3Http) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeerApplication.
-func (in *PeerApplication) DeepCopy() *PeerApplication {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Http.
+func (in *S3Http) DeepCopy() *S3Http {
 	if in == nil {
 		return nil
 	}
-	out := new(PeerApplication)
+	out := new(S3Http)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PeerRemote) DeepCopyInto(out *PeerRemote) {
+func (in *S3Https) DeepCopyInto(out *S3Https) {
 	*out = *in
+	if in.Certificate != nil {
+		in, out := &in.Certificate, &out.Certificate
+		*out = new(Certificate)
+		**out = **in
+	}
+	if in.TlsSecret != nil {
+		in, out := &in.TlsSecret, &out.TlsSecret
+		*out = new(NamespacedName)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeerRemote.
-func (in *PeerRemote) DeepCopy() *PeerRemote {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Https.
+func (in *S3Https) DeepCopy() *S3Https {
 	if in == nil {
 		return nil
 	}
-	out := new(PeerRemote)
+	out := new(S3Https)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PeerSubSpec) DeepCopyInto(out *PeerSubSpec) {
+func (in *S3LifecycleRule) DeepCopyInto(out *S3LifecycleRule) {
 	*out = *in
-	if in.Applications != nil {
-		in, out := &in.Applications, &out.Applications
-		*out = make([]PeerApplication, len(*in))
-		copy(*out, *in)
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
 	}
-	out.Remote = in.Remote
-	if in.Lifs != nil {
-		in, out := &in.Lifs, &out.Lifs
-		*out = make([]LIF, len(*in))
-		copy(*out, *in)
+	if in.ExpirationDays != nil {
+		in, out := &in.ExpirationDays, &out.ExpirationDays
+		*out = new(int)
+		**out = **in
+	}
+	if in.NonCurrentExpirationDays != nil {
+		in, out := &in.NonCurrentExpirationDays, &out.NonCurrentExpirationDays
+		*out = new(int)
+		**out = **in
+	}
+	if in.AbortIncompleteMultipartUploadDays != nil {
+		in, out := &in.AbortIncompleteMultipartUploadDays, &out.AbortIncompleteMultipartUploadDays
+		*out = new(int)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeerSubSpec.
-func (in *PeerSubSpec) DeepCopy() *PeerSubSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3LifecycleRule.
+func (in *S3LifecycleRule) DeepCopy() *S3LifecycleRule {
 	if in == nil {
 		return nil
 	}
-	out := new(PeerSubSpec)
+	out := new(S3LifecycleRule)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *S3Bucket) DeepCopyInto(out *S3Bucket) {
+func (in *S3NameMapping) DeepCopyInto(out *S3NameMapping) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Bucket.
-func (in *S3Bucket) DeepCopy() *S3Bucket {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3NameMapping.
+func (in *S3NameMapping) DeepCopy() *S3NameMapping {
 	if in == nil {
 		return nil
 	}
-	out := new(S3Bucket)
+	out := new(S3NameMapping)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *S3Http) DeepCopyInto(out *S3Http) {
+func (in *S3NasBucket) DeepCopyInto(out *S3NasBucket) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Http.
-func (in *S3Http) DeepCopy() *S3Http {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3NasBucket.
+func (in *S3NasBucket) DeepCopy() *S3NasBucket {
 	if in == nil {
 		return nil
 	}
-	out := new(S3Http)
+	out := new(S3NasBucket)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *S3Https) DeepCopyInto(out *S3Https) {
+func (in *S3Policy) DeepCopyInto(out *S3Policy) {
 	*out = *in
-	out.Certificate = in.Certificate
+	if in.Statements != nil {
+		in, out := &in.Statements, &out.Statements
+		*out = make([]S3PolicyStatement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Https.
-func (in *S3Https) DeepCopy() *S3Https {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Policy.
+func (in *S3Policy) DeepCopy() *S3Policy {
 	if in == nil {
 		return nil
 	}
-	out := new(S3Https)
+	out := new(S3Policy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3PolicyStatement) DeepCopyInto(out *S3PolicyStatement) {
+	*out = *in
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3PolicyStatement.
+func (in *S3PolicyStatement) DeepCopy() *S3PolicyStatement {
+	if in == nil {
+		return nil
+	}
+	out := new(S3PolicyStatement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3Status) DeepCopyInto(out *S3Status) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]S3UserStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Certificate != nil {
+		in, out := &in.Certificate, &out.Certificate
+		*out = new(S3CertificateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NameMappings != nil {
+		in, out := &in.NameMappings, &out.NameMappings
+		*out = make([]S3NameMapping, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Status.
+func (in *S3Status) DeepCopy() *S3Status {
+	if in == nil {
+		return nil
+	}
+	out := new(S3Status)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -302,6 +1430,20 @@ func (in *S3SubSpec) DeepCopyInto(out *S3SubSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]S3Group, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]S3Policy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Http != nil {
 		in, out := &in.Http, &out.Http
 		*out = new(S3Http)
@@ -310,11 +1452,18 @@ func (in *S3SubSpec) DeepCopyInto(out *S3SubSpec) {
 	if in.Https != nil {
 		in, out := &in.Https, &out.Https
 		*out = new(S3Https)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Buckets != nil {
 		in, out := &in.Buckets, &out.Buckets
 		*out = make([]S3Bucket, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NameMappings != nil {
+		in, out := &in.NameMappings, &out.NameMappings
+		*out = make([]S3NameMapping, len(*in))
 		copy(*out, *in)
 	}
 }
@@ -337,6 +1486,16 @@ func (in *S3User) DeepCopyInto(out *S3User) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.RotationInterval != nil {
+		in, out := &in.RotationInterval, &out.RotationInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3User.
@@ -349,6 +1508,45 @@ func (in *S3User) DeepCopy() *S3User {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3UserStatus) DeepCopyInto(out *S3UserStatus) {
+	*out = *in
+	out.Secret = in.Secret
+	if in.KeysGenerated != nil {
+		in, out := &in.KeysGenerated, &out.KeysGenerated
+		*out = (*in).DeepCopy()
+	}
+	if in.PreviousKeysExpire != nil {
+		in, out := &in.PreviousKeysExpire, &out.PreviousKeysExpire
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3UserStatus.
+func (in *S3UserStatus) DeepCopy() *S3UserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(S3UserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepStatus) DeepCopyInto(out *StepStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepStatus.
+func (in *StepStatus) DeepCopy() *StepStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StepStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageVirtualMachine) DeepCopyInto(out *StorageVirtualMachine) {
 	*out = *in
@@ -411,6 +1609,11 @@ func (in *StorageVirtualMachineList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageVirtualMachineSpec) DeepCopyInto(out *StorageVirtualMachineSpec) {
 	*out = *in
+	if in.ClusterRef != nil {
+		in, out := &in.ClusterRef, &out.ClusterRef
+		*out = new(NamespacedName)
+		**out = **in
+	}
 	if in.Aggregates != nil {
 		in, out := &in.Aggregates, &out.Aggregates
 		*out = make([]Aggregate, len(*in))
@@ -448,6 +1651,13 @@ func (in *StorageVirtualMachineSpec) DeepCopyInto(out *StorageVirtualMachineSpec
 		*out = new(PeerSubSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]PeerSubSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageVirtualMachineSpec.
@@ -470,6 +1680,48 @@ func (in *StorageVirtualMachineStatus) DeepCopyInto(out *StorageVirtualMachineSt
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Nfs != nil {
+		in, out := &in.Nfs, &out.Nfs
+		*out = new(NfsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Iscsi != nil {
+		in, out := &in.Iscsi, &out.Iscsi
+		*out = new(IscsiStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Nvme != nil {
+		in, out := &in.Nvme, &out.Nvme
+		*out = new(NvmeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3Status)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]PeerStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Inventory != nil {
+		in, out := &in.Inventory, &out.Inventory
+		*out = new(InventoryStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = make([]JobStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]StepStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageVirtualMachineStatus.
@@ -17,9 +17,11 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -28,7 +30,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -41,6 +46,9 @@ import (
 	gatewayv1beta1 "gateway/api/v1beta1"
 	gatewayv1beta2 "gateway/api/v1beta2"
 	gatewayv1beta3 "gateway/api/v1beta3"
+	"gateway/internal/config"
+	cosicontroller "gateway/internal/controller/cosi"
+	ontapclustercontroller "gateway/internal/controller/ontapcluster"
 	svmcontroller "gateway/internal/controller/storagevirtualmachine"
 	//+kubebuilder:scaffold:imports
 )
@@ -68,6 +76,9 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var enableCosi bool
+	var configFile string
+	var requeueBaseDelay, requeueMaxDelay, driftCheckInterval time.Duration
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -79,6 +90,20 @@ func main() {
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.BoolVar(&enableCosi, "enable-cosi", false,
+		"If set, the COSI provisioner serves Buckets and BucketAccesses for the "+cosicontroller.DriverName+" driver. "+
+			"Requires the COSI CRDs and controller to be installed.")
+	flag.StringVar(&configFile, "config", "",
+		"The operator configuration file, usually mounted from a ConfigMap. If not set, the defaults are used.")
+	flag.DurationVar(&requeueBaseDelay, "requeue-base-delay", 0,
+		"The delay before the first retry of a failed reconcile. It doubles with every further failure. "+
+			"Overrides requeue.baseDelay of the configuration file.")
+	flag.DurationVar(&requeueMaxDelay, "requeue-max-delay", 0,
+		"The cap of the delay between retries of a failed reconcile. "+
+			"Overrides requeue.maxDelay of the configuration file.")
+	flag.DurationVar(&driftCheckInterval, "drift-check-interval", 0,
+		"The interval after a successful reconcile before the SVM is checked again for changes made in ONTAP. "+
+			"Overrides requeue.driftCheckInterval of the configuration file.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -87,6 +112,27 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	operatorConfig, err := config.Load(configFile)
+	if err != nil {
+		setupLog.Error(err, "unable to load operator configuration")
+		os.Exit(1)
+	}
+	// The requeue flags set on the command line override the configuration file
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "requeue-base-delay":
+			operatorConfig.Requeue.BaseDelay.Duration = requeueBaseDelay
+		case "requeue-max-delay":
+			operatorConfig.Requeue.MaxDelay.Duration = requeueMaxDelay
+		case "drift-check-interval":
+			operatorConfig.Requeue.DriftCheckInterval.Duration = driftCheckInterval
+		}
+	})
+	if err = operatorConfig.Validate(); err != nil {
+		setupLog.Error(err, "invalid operator configuration")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -137,6 +183,8 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "f2ac972d.gateway.netapp.com",
+		Cache:                  cacheOptions(operatorConfig),
+		NewClient:              newClient(operatorConfig),
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -154,15 +202,57 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The clients of OntapClusters are shared by all reconcilers
+	ontapClients := ontapclustercontroller.NewClients()
+	// The SVM locks serialize the StorageVirtualMachine and COSI bucket access reconciles of an SVM
+	svmLocks := &svmcontroller.KeyedLocks{}
+
+	if err = (&ontapclustercontroller.OntapClusterReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("ontapcluster-controller"),
+		Config:   operatorConfig,
+		Clients:  ontapClients,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OntapCluster")
+		os.Exit(1)
+	}
 	if err = (&svmcontroller.StorageVirtualMachineReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 		// Added to support events
 		Recorder: mgr.GetEventRecorderFor("storagevirtualmachine-controller"),
+		Config:   operatorConfig,
+		Clients:  ontapClients,
+		SvmLocks: svmLocks,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "StorageVirtualMachine")
 		os.Exit(1)
 	}
+	if enableCosi {
+		if err = (&cosicontroller.BucketReconciler{
+			Client:    mgr.GetClient(),
+			Recorder:  mgr.GetEventRecorderFor("cosi-provisioner"),
+			Config:    operatorConfig,
+			Clients:   ontapClients,
+			Namespace: os.Getenv("POD_NAMESPACE"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Bucket")
+			os.Exit(1)
+		}
+		if err = (&cosicontroller.BucketAccessReconciler{
+			Client:    mgr.GetClient(),
+			Scheme:    mgr.GetScheme(),
+			Recorder:  mgr.GetEventRecorderFor("cosi-provisioner"),
+			Config:    operatorConfig,
+			Clients:   ontapClients,
+			SvmLocks:  svmLocks,
+			Namespace: os.Getenv("POD_NAMESPACE"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "BucketAccess")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -180,3 +270,60 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// cacheOptions watches the configured namespaces, or all namespaces when none are configured,
+// and resyncs all watched objects after the configured sync period
+func cacheOptions(operatorConfig *config.OperatorConfiguration) cache.Options {
+	options := cache.Options{SyncPeriod: &operatorConfig.Manager.SyncPeriod.Duration}
+	if len(operatorConfig.Manager.WatchNamespaces) > 0 {
+		options.DefaultNamespaces = make(map[string]cache.Config)
+		for _, val := range operatorConfig.Manager.WatchNamespaces {
+			options.DefaultNamespaces[val] = cache.Config{}
+		}
+	}
+	return options
+}
+
+// newClient reads objects outside the watched namespaces, such as secrets and clusters referenced
+// across namespaces, directly from the API server since the cache doesn't hold them
+func newClient(operatorConfig *config.OperatorConfiguration) client.NewClientFunc {
+	return func(restConfig *rest.Config, options client.Options) (client.Client, error) {
+		cached, err := client.New(restConfig, options)
+		if err != nil || len(operatorConfig.Manager.WatchNamespaces) == 0 {
+			return cached, err
+		}
+		live, err := client.New(restConfig, client.Options{
+			HTTPClient: options.HTTPClient, Scheme: options.Scheme, Mapper: options.Mapper})
+		if err != nil {
+			return nil, err
+		}
+		watched := make(map[string]bool)
+		for _, val := range operatorConfig.Manager.WatchNamespaces {
+			watched[val] = true
+		}
+		return &namespacedClient{Client: cached, live: live, watched: watched}, nil
+	}
+}
+
+// namespacedClient reads from the cache in the watched namespaces and from the API server elsewhere
+type namespacedClient struct {
+	client.Client
+	live    client.Reader
+	watched map[string]bool
+}
+
+func (c *namespacedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if key.Namespace != "" && !c.watched[key.Namespace] {
+		return c.live.Get(ctx, key, obj, opts...)
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *namespacedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOptions := &client.ListOptions{}
+	listOptions.ApplyOptions(opts)
+	if listOptions.Namespace != "" && !c.watched[listOptions.Namespace] {
+		return c.live.List(ctx, list, opts...)
+	}
+	return c.Client.List(ctx, list, opts...)
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2025.
+Created by Curtis Burchett
+Version: v1beta3
+*/
+
+// Package config holds the operator configuration loaded by the manager at startup.
+// The configuration is a versioned YAML document, usually mounted from a ConfigMap,
+// and every setting that is left out keeps its default.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const APIVersion = "gateway.netapp.com/v1alpha1" //magic word
+const Kind = "OperatorConfiguration"             //magic word
+
+// ISO 8601 duration as accepted by ONTAP for certificate expiry, for example P725DT
+var isoDuration = regexp.MustCompile(`^P(\d+Y)?(\d+M)?(\d+D)?(T(\d+H)?(\d+M)?(\d+S)?)?$`)
+
+// OperatorConfiguration is the configuration of the operator
+type OperatorConfiguration struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	// Ontap configures the ONTAP REST clients
+	Ontap OntapConfig `json:"ontap"`
+
+	// Manager configures the controller manager
+	Manager ManagerConfig `json:"manager"`
+
+	// Requeue decides when custom resources are reconciled again
+	Requeue RequeueConfig `json:"requeue"`
+
+	// Deletion configures the SVM deletion
+	Deletion DeletionConfig `json:"deletion"`
+
+	// Certificates configures the certificates created in ONTAP
+	Certificates CertificateConfig `json:"certificates"`
+
+	// ServicePolicies names the ONTAP service policies of the LIFs the operator creates
+	ServicePolicies ServicePolicyConfig `json:"servicePolicies"`
+}
+
+type OntapConfig struct {
+	// TrustSSL skips the verification of the ONTAP server certificates
+	TrustSSL bool `json:"trustSSL"`
+
+	// Debug logs every ONTAP request - custom resources can also enable it with svmDebug
+	Debug bool `json:"debug"`
+
+	// Timeout of each ONTAP request
+	Timeout metav1.Duration `json:"timeout"`
+
+	// JobTimeout after which a reconcile stops waiting for an asynchronous ONTAP job
+	// the job stays recorded in the custom resource's status and the next reconcile waits for it again
+	JobTimeout metav1.Duration `json:"jobTimeout"`
+}
+
+type ManagerConfig struct {
+	// MaxConcurrentReconciles of each controller - the StorageVirtualMachine controller serializes
+	// the work per SVM and the mutations of cluster-scoped objects per cluster
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles"`
+
+	// WatchNamespaces restricts the watched namespaced objects to these namespaces - empty watches all namespaces
+	// objects referenced in other namespaces are read directly from the API server but changes to them aren't watched
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+
+	// SyncPeriod after which all watched objects are reconciled again
+	SyncPeriod metav1.Duration `json:"syncPeriod"`
+}
+
+type RequeueConfig struct {
+	// BaseDelay before the first retry of a failed reconcile - it doubles with every further failure
+	BaseDelay metav1.Duration `json:"baseDelay"`
+
+	// MaxDelay caps the delay between retries of a failed reconcile
+	MaxDelay metav1.Duration `json:"maxDelay"`
+
+	// DriftCheckInterval after a successful reconcile before the SVM is checked again for changes made in ONTAP
+	DriftCheckInterval metav1.Duration `json:"driftCheckInterval"`
+
+	// PollInterval of the COSI controllers while a bucket or its StorageVirtualMachine isn't ready
+	PollInterval metav1.Duration `json:"pollInterval"`
+}
+
+type DeletionConfig struct {
+	// CheckAttempts to confirm an SVM or a peer relationship is gone, CheckInterval apart
+	CheckAttempts int `json:"checkAttempts"`
+
+	// CheckInterval between the deletion check attempts
+	CheckInterval metav1.Duration `json:"checkInterval"`
+}
+
+type CertificateConfig struct {
+	// DefaultExpiry of created certificates that don't set expiryTime, as an ISO 8601 duration
+	DefaultExpiry string `json:"defaultExpiry"`
+}
+
+type ServicePolicyConfig struct {
+	Management   string `json:"management"`
+	Nfs          string `json:"nfs"`
+	Iscsi        string `json:"iscsi"`
+	IscsiLegacy  string `json:"iscsiLegacy"`
+	Nvme         string `json:"nvme"`
+	S3           string `json:"s3"`
+	Intercluster string `json:"intercluster"`
+}
+
+// Default returns the configuration used when no configuration file is given
+func Default() *OperatorConfiguration {
+	return &OperatorConfiguration{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Ontap: OntapConfig{
+			TrustSSL:   true,
+			Timeout:    metav1.Duration{Duration: 3 * time.Second},
+			JobTimeout: metav1.Duration{Duration: 5 * time.Minute},
+		},
+		Manager: ManagerConfig{
+			MaxConcurrentReconciles: 1,
+			SyncPeriod:              metav1.Duration{Duration: 10 * time.Hour},
+		},
+		Requeue: RequeueConfig{
+			BaseDelay:          metav1.Duration{Duration: 5 * time.Second},
+			MaxDelay:           metav1.Duration{Duration: 5 * time.Minute},
+			DriftCheckInterval: metav1.Duration{Duration: 10 * time.Minute},
+			PollInterval:       metav1.Duration{Duration: 30 * time.Second},
+		},
+		Deletion: DeletionConfig{
+			CheckAttempts: 5,
+			CheckInterval: metav1.Duration{Duration: 5 * time.Second},
+		},
+		Certificates: CertificateConfig{
+			DefaultExpiry: "P725DT",
+		},
+		ServicePolicies: ServicePolicyConfig{
+			Management:   "default-management",
+			Nfs:          "default-data-files",
+			Iscsi:        "default-data-iscsi",
+			IscsiLegacy:  "default-data-blocks",
+			Nvme:         "default-data-nvme-tcp",
+			S3:           "gateway-custom-service-policy-s3",
+			Intercluster: "default-intercluster",
+		},
+	}
+}
+
+// Load reads the configuration file over the defaults and validates it
+// an empty path returns the defaults
+func Load(path string) (*OperatorConfiguration, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading operator configuration: %w", err)
+	}
+	err = yaml.UnmarshalStrict(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parsing operator configuration %s: %w", path, err)
+	}
+	err = cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid operator configuration %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate reports every invalid setting of the configuration
+func (c *OperatorConfiguration) Validate() error {
+	var errs []error
+
+	if c.APIVersion != APIVersion {
+		errs = append(errs, fmt.Errorf("apiVersion must be %s, got %q", APIVersion, c.APIVersion))
+	}
+	if c.Kind != Kind {
+		errs = append(errs, fmt.Errorf("kind must be %s, got %q", Kind, c.Kind))
+	}
+
+	if c.Ontap.Timeout.Duration <= 0 {
+		errs = append(errs, errors.New("ontap.timeout must be positive"))
+	}
+	if c.Ontap.JobTimeout.Duration <= 0 {
+		errs = append(errs, errors.New("ontap.jobTimeout must be positive"))
+	}
+
+	if c.Manager.MaxConcurrentReconciles < 1 {
+		errs = append(errs, errors.New("manager.maxConcurrentReconciles must be at least 1"))
+	}
+	for _, val := range c.Manager.WatchNamespaces {
+		for _, msg := range validation.IsDNS1123Label(val) {
+			errs = append(errs, fmt.Errorf("manager.watchNamespaces %q: %s", val, msg))
+		}
+	}
+	if c.Manager.SyncPeriod.Duration <= 0 {
+		errs = append(errs, errors.New("manager.syncPeriod must be positive"))
+	}
+
+	if c.Requeue.BaseDelay.Duration <= 0 {
+		errs = append(errs, errors.New("requeue.baseDelay must be positive"))
+	}
+	if c.Requeue.MaxDelay.Duration < c.Requeue.BaseDelay.Duration {
+		errs = append(errs, errors.New("requeue.maxDelay must not be less than requeue.baseDelay"))
+	}
+	if c.Requeue.DriftCheckInterval.Duration <= 0 {
+		errs = append(errs, errors.New("requeue.driftCheckInterval must be positive"))
+	}
+	if c.Requeue.PollInterval.Duration <= 0 {
+		errs = append(errs, errors.New("requeue.pollInterval must be positive"))
+	}
+
+	if c.Deletion.CheckAttempts < 1 {
+		errs = append(errs, errors.New("deletion.checkAttempts must be at least 1"))
+	}
+
+	if c.Deletion.CheckInterval.Duration <= 0 {
+		errs = append(errs, errors.New("deletion.checkInterval must be positive"))
+	}
+
+	if c.Certificates.DefaultExpiry == "P" || !isoDuration.MatchString(c.Certificates.DefaultExpiry) {
+		errs = append(errs, fmt.Errorf("certificates.defaultExpiry %q is not an ISO 8601 duration", c.Certificates.DefaultExpiry))
+	}
+
+	for _, val := range []struct{ name, policy string }{
+		{"management", c.ServicePolicies.Management},
+		{"nfs", c.ServicePolicies.Nfs},
+		{"iscsi", c.ServicePolicies.Iscsi},
+		{"iscsiLegacy", c.ServicePolicies.IscsiLegacy},
+		{"nvme", c.ServicePolicies.Nvme},
+		{"s3", c.ServicePolicies.S3},
+		{"intercluster", c.ServicePolicies.Intercluster},
+	} {
+		if val.policy == "" {
+			errs = append(errs, fmt.Errorf("servicePolicies.%s must not be empty", val.name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,82 @@
+package config_test
+
+import (
+	"gateway/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("Expected no error writing the configuration, but found %v", err)
+	}
+	return path
+}
+
+func TestLoadKeepsDefaults(t *testing.T) {
+	path := writeConfig(t, `apiVersion: gateway.netapp.com/v1alpha1
+kind: OperatorConfiguration
+requeue:
+  maxDelay: 10m
+`)
+
+	cfg, err := config.Load(path)
+
+	if err != nil {
+		t.Fatalf("Expected no error, but found %v", err)
+	}
+	if cfg.Requeue.MaxDelay.Duration != 10*time.Minute {
+		t.Errorf("Expected maxDelay to be 10m, but found %v", cfg.Requeue.MaxDelay.Duration)
+	}
+	if cfg.Requeue.BaseDelay.Duration != 5*time.Second {
+		t.Errorf("Expected baseDelay to keep its default 5s, but found %v", cfg.Requeue.BaseDelay.Duration)
+	}
+	if cfg.Deletion.CheckInterval.Duration != 5*time.Second {
+		t.Errorf("Expected checkInterval to keep its default 5s, but found %v", cfg.Deletion.CheckInterval.Duration)
+	}
+	if !cfg.Ontap.TrustSSL {
+		t.Errorf("Expected trustSSL to keep its default true")
+	}
+}
+
+func TestLoadRejectsInvalidSettings(t *testing.T) {
+	path := writeConfig(t, `apiVersion: gateway.netapp.com/v1alpha1
+kind: OperatorConfiguration
+manager:
+  maxConcurrentReconciles: 0
+deletion:
+  checkInterval: 0s
+certificates:
+  defaultExpiry: 725 days
+`)
+
+	_, err := config.Load(path)
+
+	if err == nil {
+		t.Fatalf("Expected an error, but found none")
+	}
+	for _, val := range []string{"maxConcurrentReconciles", "checkInterval", "defaultExpiry"} {
+		if !strings.Contains(err.Error(), val) {
+			t.Errorf("Expected the error to report %s, but found %v", val, err)
+		}
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	path := writeConfig(t, `apiVersion: gateway.netapp.com/v1alpha1
+kind: OperatorConfiguration
+requeue:
+  maxDelays: 10m
+`)
+
+	_, err := config.Load(path)
+
+	if err == nil {
+		t.Errorf("Expected an error for the unknown field, but found none")
+	}
+}
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"gateway/internal/config"
+	"gateway/internal/controller/ontap"
+	ontapcluster "gateway/internal/controller/ontapcluster"
+)
+
+// BucketReconciler creates the S3 buckets for COSI Buckets of this driver
+type BucketReconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+	// Config is the operator configuration - nil uses the defaults
+	Config *config.OperatorConfiguration
+	// Clients holds the clients of OntapClusters - nil doesn't share them
+	Clients *ontapcluster.Clients
+	// Namespace is the operator's namespace, used when the class parameters don't name the svmNamespace
+	Namespace string
+}
+
+//+kubebuilder:rbac:groups=objectstorage.k8s.io,resources=buckets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=objectstorage.k8s.io,resources=buckets/status,verbs=get;update;patch
+
+// Reconcile creates the bucket on the StorageVirtualMachine referenced by the bucket class parameters
+// and deletes it when the Bucket is deleted with the Delete deletion policy
+func (r *BucketReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithValues("Bucket", req.Name)
+
+	bucket := newObject(BucketGVK)
+	err := r.Get(ctx, req.NamespacedName, bucket)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	driverName, _, _ := unstructured.NestedString(bucket.Object, "spec", "driverName")
+	if driverName != DriverName {
+		return ctrl.Result{}, nil
+	}
+
+	// Buckets for existing bucket ids are only marked ready
+	existingBucketID, _, _ := unstructured.NestedString(bucket.Object, "spec", "existingBucketID")
+	bucketName := bucket.GetName()
+	if existingBucketID != "" {
+		bucketName = existingBucketID
+	}
+
+	parameters := stringMap(bucket, "spec", "parameters")
+	svmCR, err := storageVirtualMachine(ctx, r.Client, parameters, r.Namespace)
+	if err != nil && errors.IsNotFound(err) && bucket.GetDeletionTimestamp() != nil {
+		// nothing left to clean up without the StorageVirtualMachine
+		controllerutil.RemoveFinalizer(bucket, bucketFinalizer)
+		return ctrl.Result{}, r.Update(ctx, bucket)
+	} else if err != nil && isS3Unavailable(err) && bucket.GetDeletionTimestamp() != nil {
+		// the ONTAP calls need the S3 server - the bucket is left on the SVM
+		if controllerutil.ContainsFinalizer(bucket, bucketFinalizer) {
+			log.Info("Releasing bucket without deleting it on ONTAP: " + err.Error())
+			r.Recorder.Event(bucket, "Warning", "BucketNotDeleted", "Bucket "+bucketName+" not deleted on ONTAP: "+err.Error())
+		}
+		controllerutil.RemoveFinalizer(bucket, bucketFinalizer)
+		return ctrl.Result{}, r.Update(ctx, bucket)
+	} else if err != nil {
+		log.Error(err, "Error resolving StorageVirtualMachine for bucket - requeuing")
+		return pollResult(r.Config), nil
+	}
+	oc, err := ontapClient(ctx, r.Client, svmCR, r.Clients, r.Config)
+	if err != nil {
+		log.Error(err, "Error creating ONTAP client - requeuing")
+		return pollResult(r.Config), nil
+	}
+	uuid := svmCR.GetSvmUuid()
+
+	if bucket.GetDeletionTimestamp() != nil {
+		if !controllerutil.ContainsFinalizer(bucket, bucketFinalizer) {
+			return ctrl.Result{}, nil
+		}
+		deletionPolicy, _, _ := unstructured.NestedString(bucket.Object, "spec", "deletionPolicy")
+		if deletionPolicy == "Delete" && existingBucketID == "" {
+			buckets, err := oc.GetS3BucketsBySvmUuid(uuid)
+			if err != nil {
+				log.Error(err, "Error getting S3 buckets - requeuing")
+				return pollResult(r.Config), nil
+			}
+			current := findBucket(buckets, bucketName)
+			if current != nil {
+				log.Info("COSI bucket delete attempt: " + bucketName)
+				err = oc.DeleteS3Bucket(uuid, current.Uuid)
+				if err != nil {
+					log.Error(err, "Error occurred when deleting COSI bucket: "+bucketName)
+					r.Recorder.Event(bucket, "Warning", "BucketDeleteFailed", "Error: "+err.Error())
+					return pollResult(r.Config), nil
+				}
+				log.Info("COSI bucket delete successful: " + bucketName)
+			}
+		}
+		controllerutil.RemoveFinalizer(bucket, bucketFinalizer)
+		return ctrl.Result{}, r.Update(ctx, bucket)
+	}
+
+	ready, _, _ := unstructured.NestedBool(bucket.Object, "status", "bucketReady")
+	if ready {
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(bucket, bucketFinalizer) {
+		controllerutil.AddFinalizer(bucket, bucketFinalizer)
+		err = r.Update(ctx, bucket)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	buckets, err := oc.GetS3BucketsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting S3 buckets - requeuing")
+		return pollResult(r.Config), nil
+	}
+
+	if findBucket(buckets, bucketName) == nil {
+		if existingBucketID != "" {
+			err = fmt.Errorf("existing bucket %s not found on SVM %s", existingBucketID, svmCR.Spec.SvmName)
+			log.Error(err, "Error resolving existing bucket - requeuing")
+			r.Recorder.Event(bucket, "Warning", "BucketNotFound", "Error: "+err.Error())
+			return pollResult(r.Config), nil
+		}
+
+		var newBucket ontap.S3Bucket
+		newBucket.Name = bucketName
+		newBucket.Type = "s3" //magic word
+		newBucket.Comment = cosiComment
+		newBucket.Size = minBucketSize
+		if size, err := strconv.Atoi(parameters[sizeParameter]); err == nil && size > minBucketSize {
+			newBucket.Size = size
+		}
+		// releases before NAS buckets don't know the bucket type
+		if _, err := oc.ClusterVersion(); err == nil && !oc.Capabilities().Supports(ontap.FeatureS3NasBuckets) {
+			newBucket.Type = ""
+		}
+
+		jsonPayload, err := json.Marshal(newBucket)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for COSI bucket creation")
+			return ctrl.Result{}, err
+		}
+
+		log.Info("COSI bucket creation attempt: " + bucketName)
+		err = oc.CreateS3Bucket(uuid, jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when creating COSI bucket: "+bucketName)
+			r.Recorder.Event(bucket, "Warning", "BucketCreateFailed", "Error: "+err.Error())
+			return pollResult(r.Config), nil
+		}
+		log.Info("COSI bucket creation successful: " + bucketName)
+	}
+
+	_ = unstructured.SetNestedField(bucket.Object, true, "status", "bucketReady")
+	_ = unstructured.SetNestedField(bucket.Object, bucketName, "status", "bucketID")
+	err = r.Status().Update(ctx, bucket)
+	if err != nil {
+		if errors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Event(bucket, "Normal", "BucketReady", "Bucket "+bucketName+" ready on SVM "+svmCR.Spec.SvmName)
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BucketReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("cosi-bucket").
+		WithOptions(controller.Options{MaxConcurrentReconciles: operatorConfig(r.Config).Manager.MaxConcurrentReconciles}).
+		For(newObject(BucketGVK)).
+		Complete(r)
+}
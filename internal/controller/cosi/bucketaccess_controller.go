@@ -0,0 +1,393 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"gateway/internal/config"
+	"gateway/internal/controller/ontap"
+	ontapcluster "gateway/internal/controller/ontapcluster"
+	svmcontroller "gateway/internal/controller/storagevirtualmachine"
+)
+
+// BucketAccessReconciler creates an S3 user per COSI BucketAccess of this driver,
+// grants it access to the bucket and writes the BucketInfo secret
+type BucketAccessReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Config is the operator configuration - nil uses the defaults
+	Config *config.OperatorConfiguration
+	// Clients holds the clients of OntapClusters - nil doesn't share them
+	Clients *ontapcluster.Clients
+	// SvmLocks serialize the bucket policy patches per SVM - shared with the StorageVirtualMachine reconciler
+	SvmLocks *svmcontroller.KeyedLocks
+	// Namespace is the operator's namespace, used when the class parameters don't name the svmNamespace
+	Namespace string
+}
+
+// BucketInfo is the COSI credentials format written to the BucketAccess's credentials secret
+type BucketInfo struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Metadata   BucketInfoMetadata `json:"metadata"`
+	Spec       BucketInfoSpec     `json:"spec"`
+}
+
+type BucketInfoMetadata struct {
+	Name string `json:"name"`
+}
+
+type BucketInfoSpec struct {
+	BucketName         string        `json:"bucketName"`
+	AuthenticationType string        `json:"authenticationType"`
+	S3                 *BucketInfoS3 `json:"secretS3"`
+	Azure              *struct{}     `json:"secretAzure"`
+	Protocols          []string      `json:"protocols"`
+}
+
+type BucketInfoS3 struct {
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region"`
+	AccessKeyID     string `json:"accessKeyID"`
+	AccessSecretKey string `json:"accessSecretKey"`
+}
+
+//+kubebuilder:rbac:groups=objectstorage.k8s.io,resources=bucketaccesses,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=objectstorage.k8s.io,resources=bucketaccesses/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=objectstorage.k8s.io,resources=bucketaccessclasses;bucketclaims,verbs=get;list;watch
+
+// Reconcile grants a new S3 user access to the claimed bucket and revokes it when the BucketAccess is deleted
+func (r *BucketAccessReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithValues("BucketAccess", req.NamespacedName)
+
+	access := newObject(BucketAccessGVK)
+	err := r.Get(ctx, req.NamespacedName, access)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	className, _, _ := unstructured.NestedString(access.Object, "spec", "bucketAccessClassName")
+	accessClass := newObject(BucketAccessClassGVK)
+	err = r.Get(ctx, types.NamespacedName{Name: className}, accessClass)
+	if err != nil {
+		if errors.IsNotFound(err) && access.GetDeletionTimestamp() == nil {
+			return pollResult(r.Config), nil
+		}
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+	} else {
+		driverName, _, _ := unstructured.NestedString(accessClass.Object, "driverName")
+		if driverName != DriverName {
+			return ctrl.Result{}, nil
+		}
+		authenticationType, _, _ := unstructured.NestedString(accessClass.Object, "authenticationType")
+		if authenticationType != "" && authenticationType != "Key" {
+			log.Info("Only Key authentication is supported - ignoring BucketAccess")
+			r.Recorder.Event(access, "Warning", "AccessUnsupported", "Only Key authentication is supported")
+			return ctrl.Result{}, nil
+		}
+	}
+
+	granted, _, _ := unstructured.NestedBool(access.Object, "status", "accessGranted")
+	if granted && access.GetDeletionTimestamp() == nil {
+		return ctrl.Result{}, nil
+	}
+	if !granted && !controllerutil.ContainsFinalizer(access, bucketAccessFinalizer) && access.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+
+	// Resolve the bucket through the claim
+	claimName, _, _ := unstructured.NestedString(access.Object, "spec", "bucketClaimName")
+	claim := newObject(BucketClaimGVK)
+	err = r.Get(ctx, types.NamespacedName{Name: claimName, Namespace: access.GetNamespace()}, claim)
+	if err != nil {
+		if errors.IsNotFound(err) && access.GetDeletionTimestamp() != nil {
+			return r.removeFinalizer(ctx, access)
+		}
+		log.Error(err, "Error getting BucketClaim: "+claimName+" - requeuing")
+		return pollResult(r.Config), nil
+	}
+	bucketObjectName, _, _ := unstructured.NestedString(claim.Object, "status", "bucketName")
+	if bucketObjectName == "" {
+		log.Info("BucketClaim " + claimName + " has no bucket yet - requeuing")
+		return pollResult(r.Config), nil
+	}
+	bucket := newObject(BucketGVK)
+	err = r.Get(ctx, types.NamespacedName{Name: bucketObjectName}, bucket)
+	if err != nil {
+		if errors.IsNotFound(err) && access.GetDeletionTimestamp() != nil {
+			return r.removeFinalizer(ctx, access)
+		}
+		log.Error(err, "Error getting Bucket: "+bucketObjectName+" - requeuing")
+		return pollResult(r.Config), nil
+	}
+	bucketReady, _, _ := unstructured.NestedBool(bucket.Object, "status", "bucketReady")
+	bucketName, _, _ := unstructured.NestedString(bucket.Object, "status", "bucketID")
+	if !bucketReady || bucketName == "" {
+		log.Info("Bucket " + bucketObjectName + " is not ready yet - requeuing")
+		return pollResult(r.Config), nil
+	}
+
+	svmCR, err := storageVirtualMachine(ctx, r.Client, stringMap(bucket, "spec", "parameters"), r.Namespace)
+	if err != nil && errors.IsNotFound(err) && access.GetDeletionTimestamp() != nil {
+		return r.removeFinalizer(ctx, access)
+	} else if err != nil && isS3Unavailable(err) && access.GetDeletionTimestamp() != nil {
+		// the ONTAP calls need the S3 server - the user and its bucket policy statement are left on the SVM
+		log.Info("Releasing bucket access without revoking it on ONTAP: " + err.Error())
+		r.Recorder.Event(access, "Warning", "AccessNotRevoked", "Access not revoked on ONTAP: "+err.Error())
+		return r.removeFinalizer(ctx, access)
+	} else if err != nil {
+		log.Error(err, "Error resolving StorageVirtualMachine for bucket access - requeuing")
+		return pollResult(r.Config), nil
+	}
+	oc, err := ontapClient(ctx, r.Client, svmCR, r.Clients, r.Config)
+	if err != nil {
+		log.Error(err, "Error creating ONTAP client - requeuing")
+		return pollResult(r.Config), nil
+	}
+	uuid := svmCR.GetSvmUuid()
+	userName := svmcontroller.S3CosiUserPrefix + string(access.GetUID())
+
+	// the bucket policy is read, changed and written back - bucket accesses of the SVM take turns
+	unlock := svmcontroller.LockSvm(r.SvmLocks, oc, svmCR.Spec.SvmName, log)
+	defer unlock()
+
+	if access.GetDeletionTimestamp() != nil {
+		err = revokeBucketAccess(userName, bucketName, uuid, oc, log)
+		if err != nil {
+			r.Recorder.Event(access, "Warning", "AccessRevokeFailed", "Error: "+err.Error())
+			return pollResult(r.Config), nil
+		}
+		return r.removeFinalizer(ctx, access)
+	}
+
+	if !controllerutil.ContainsFinalizer(access, bucketAccessFinalizer) {
+		controllerutil.AddFinalizer(access, bucketAccessFinalizer)
+		err = r.Update(ctx, access)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	keys, err := grantBucketAccess(userName, bucketName, uuid, oc, log)
+	if err != nil {
+		r.Recorder.Event(access, "Warning", "AccessGrantFailed", "Error: "+err.Error())
+		return pollResult(r.Config), nil
+	}
+
+	// Write the standard COSI BucketInfo secret
+	endpoint := ""
+	if endpoints := svmcontroller.S3Endpoints(svmCR.Spec.S3Config); len(endpoints) > 0 {
+		endpoint = endpoints[0]
+	}
+	info := BucketInfo{
+		APIVersion: cosiGroupVersion.String(),
+		Kind:       "BucketInfo", //magic word
+		Metadata:   BucketInfoMetadata{Name: bucketObjectName},
+		Spec: BucketInfoSpec{
+			BucketName:         bucketName,
+			AuthenticationType: "Key", //magic word
+			S3: &BucketInfoS3{
+				Endpoint:        endpoint,
+				Region:          cosiRegion,
+				AccessKeyID:     keys.AccessKey,
+				AccessSecretKey: keys.SecretKey,
+			},
+			Protocols: []string{"s3"},
+		},
+	}
+	infoJson, err := json.Marshal(info)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	secretName, _, _ := unstructured.NestedString(access.Object, "spec", "credentialsSecretName")
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: access.GetNamespace()},
+		Type:       corev1.SecretTypeOpaque,
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Data = map[string][]byte{bucketInfoKey: infoJson}
+		return controllerutil.SetOwnerReference(access, secret, r.Scheme)
+	})
+	if err != nil {
+		log.Error(err, "Error writing BucketInfo secret: "+secretName)
+		return ctrl.Result{}, err
+	}
+
+	_ = unstructured.SetNestedField(access.Object, true, "status", "accessGranted")
+	_ = unstructured.SetNestedField(access.Object, userName, "status", "accountID")
+	err = r.Status().Update(ctx, access)
+	if err != nil {
+		if errors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Event(access, "Normal", "AccessGranted", "Granted "+userName+" access to bucket "+bucketName)
+
+	return ctrl.Result{}, nil
+}
+
+func (r *BucketAccessReconciler) removeFinalizer(ctx context.Context, access *unstructured.Unstructured) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(access, bucketAccessFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	controllerutil.RemoveFinalizer(access, bucketAccessFinalizer)
+	return ctrl.Result{}, r.Update(ctx, access)
+}
+
+// grantBucketAccess creates the S3 user, or regenerates its keys when it already exists since keys
+// can't be read back, and adds a bucket policy statement for the user
+func grantBucketAccess(userName string, bucketName string, uuid string, oc *ontap.Client, log logr.Logger) (ontap.S3User, error) {
+	var keys ontap.S3User
+
+	existing, err := oc.GetS3UserByNameAndSvmUuid(userName, uuid)
+	if err != nil {
+		log.Error(err, "Error getting S3 user: "+userName)
+		return keys, err
+	}
+
+	var users ontap.S3UsersResponse
+	if existing.NumRecords == 0 {
+		jsonPayload, err := json.Marshal(ontap.S3User{Name: userName, Comment: cosiComment})
+		if err != nil {
+			return keys, err
+		}
+		log.Info("COSI S3 user creation attempt: " + userName)
+		users, err = oc.CreateS3User(uuid, jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when creating COSI S3 user: "+userName)
+			return keys, err
+		}
+	} else {
+		log.Info("COSI S3 user key regeneration attempt: " + userName)
+		users, err = oc.RegenerateS3UserKeys(uuid, userName)
+		if err != nil {
+			log.Error(err, "Error occurred when regenerating COSI S3 user keys: "+userName)
+			return keys, err
+		}
+	}
+	if len(users.Records) == 0 {
+		return keys, fmt.Errorf("no keys returned for S3 user %s", userName)
+	}
+	keys = users.Records[0]
+
+	buckets, err := oc.GetS3BucketsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting S3 buckets")
+		return keys, err
+	}
+	current := findBucket(buckets, bucketName)
+	if current == nil {
+		return keys, fmt.Errorf("bucket %s not found", bucketName)
+	}
+
+	policy := &ontap.S3BucketPolicy{Statements: []ontap.S3BucketPolicyStatements{}}
+	if current.Policy != nil {
+		policy.Statements = append(policy.Statements, current.Policy.Statements...)
+	}
+	for _, statement := range policy.Statements {
+		if statement.Sid == userName {
+			return keys, nil
+		}
+	}
+	policy.Statements = append(policy.Statements, ontap.S3BucketPolicyStatements{
+		Sid:        userName,
+		Effect:     "allow",                                                                                                                                            //magic word
+		Actions:    []string{"ListBucket", "GetObject", "PutObject", "DeleteObject", "ListBucketMultipartUploads", "ListMultipartUploadParts", "AbortMultipartUpload"}, //magic word
+		Principals: []string{userName},
+		Resources:  []string{bucketName, bucketName + "/*"},
+	})
+
+	err = patchBucketPolicy(current, policy, uuid, oc, log)
+	return keys, err
+}
+
+// revokeBucketAccess removes the user's bucket policy statement and deletes the S3 user
+func revokeBucketAccess(userName string, bucketName string, uuid string, oc *ontap.Client, log logr.Logger) error {
+	buckets, err := oc.GetS3BucketsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting S3 buckets")
+		return err
+	}
+	current := findBucket(buckets, bucketName)
+	if current != nil && current.Policy != nil {
+		policy := &ontap.S3BucketPolicy{Statements: []ontap.S3BucketPolicyStatements{}}
+		for _, statement := range current.Policy.Statements {
+			if statement.Sid != userName {
+				policy.Statements = append(policy.Statements, statement)
+			}
+		}
+		if len(policy.Statements) != len(current.Policy.Statements) {
+			err = patchBucketPolicy(current, policy, uuid, oc, log)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	existing, err := oc.GetS3UserByNameAndSvmUuid(userName, uuid)
+	if err != nil {
+		log.Error(err, "Error getting S3 user: "+userName)
+		return err
+	}
+	if existing.NumRecords == 0 {
+		return nil
+	}
+	log.Info("COSI S3 user delete attempt: " + userName)
+	err = oc.DeleteS3User(uuid, userName)
+	if err != nil {
+		log.Error(err, "Error occurred when deleting COSI S3 user: "+userName)
+		return err
+	}
+	log.Info("COSI S3 user delete successful: " + userName)
+	return nil
+}
+
+func patchBucketPolicy(current *ontap.S3Bucket, policy *ontap.S3BucketPolicy, uuid string, oc *ontap.Client, log logr.Logger) error {
+	var patchBucket ontap.S3Bucket
+	patchBucket.Policy = policy
+	jsonPayload, err := json.Marshal(patchBucket)
+	if err != nil {
+		return err
+	}
+	log.Info("COSI bucket policy update attempt: " + current.Name)
+	err = oc.PatchS3Bucket(uuid, current.Uuid, jsonPayload)
+	if err != nil {
+		log.Error(err, "Error occurred when updating COSI bucket policy: "+current.Name)
+		return err
+	}
+	log.Info("COSI bucket policy update successful: " + current.Name)
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BucketAccessReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.SvmLocks == nil {
+		r.SvmLocks = &svmcontroller.KeyedLocks{}
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("cosi-bucketaccess").
+		WithOptions(controller.Options{MaxConcurrentReconciles: operatorConfig(r.Config).Manager.MaxConcurrentReconciles}).
+		For(newObject(BucketAccessGVK)).
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2025.
+Created by Curtis Burchett
+Version: v1beta3
+*/
+
+// Package controller provisions COSI buckets and bucket accesses on a StorageVirtualMachine's S3 server.
+// The COSI types are handled as unstructured objects so the operator doesn't depend on the COSI API module
+// and only needs the COSI CRDs and controller installed in the cluster when the provisioner is enabled.
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/config"
+	"gateway/internal/controller/ontap"
+	ontapcluster "gateway/internal/controller/ontapcluster"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const DriverName = "gateway.netapp.com" //magic word
+
+const svmNameParameter = "svmName"           //magic word
+const svmNamespaceParameter = "svmNamespace" //magic word
+const sizeParameter = "size"                 //magic word
+
+const bucketFinalizer = "gateway.netapp.com/cosi-bucket"              //magic word
+const bucketAccessFinalizer = "gateway.netapp.com/cosi-bucket-access" //magic word
+
+const bucketInfoKey = "BucketInfo" //magic word
+const cosiComment = "Created by Astra Gateway COSI provisioner"
+const cosiRegion = "us-east-1"     //magic word - ONTAP ignores the region but SDKs require one
+const minBucketSize = 102005473280 //magic word - 95GiB
+
+var cosiGroupVersion = schema.GroupVersion{Group: "objectstorage.k8s.io", Version: "v1alpha1"}
+
+var BucketGVK = cosiGroupVersion.WithKind("Bucket")
+var BucketClaimGVK = cosiGroupVersion.WithKind("BucketClaim")
+var BucketAccessGVK = cosiGroupVersion.WithKind("BucketAccess")
+var BucketAccessClassGVK = cosiGroupVersion.WithKind("BucketAccessClass")
+
+func newObject(gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	return obj
+}
+
+// operatorConfig returns the operator configuration or the defaults
+func operatorConfig(cfg *config.OperatorConfiguration) *config.OperatorConfiguration {
+	if cfg == nil {
+		return config.Default()
+	}
+	return cfg
+}
+
+// pollResult comes back after the configured poll interval while a bucket or its StorageVirtualMachine isn't ready
+func pollResult(cfg *config.OperatorConfiguration) ctrl.Result {
+	return ctrl.Result{RequeueAfter: operatorConfig(cfg).Requeue.PollInterval.Duration}
+}
+
+// s3UnavailableError reports a StorageVirtualMachine without an S3 server on ONTAP
+type s3UnavailableError struct {
+	message string
+}
+
+func (e *s3UnavailableError) Error() string {
+	return e.message
+}
+
+// isS3Unavailable reports whether the StorageVirtualMachine exists but S3 is disabled or the SVM isn't created yet
+// deleted buckets and bucket accesses have nothing the provisioner can clean up on ONTAP then
+func isS3Unavailable(err error) bool {
+	var target *s3UnavailableError
+	return errors.As(err, &target)
+}
+
+// storageVirtualMachine returns the custom resource referenced by the class parameters
+// without an svmNamespace parameter the custom resource is looked up in the default namespace
+func storageVirtualMachine(ctx context.Context, c client.Client, parameters map[string]string,
+	defaultNamespace string) (*gateway.StorageVirtualMachine, error) {
+	name := parameters[svmNameParameter]
+	if name == "" {
+		return nil, fmt.Errorf("class parameter %s is required", svmNameParameter)
+	}
+	namespace := parameters[svmNamespaceParameter]
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("class parameter %s is required when the operator's namespace is unknown", svmNamespaceParameter)
+	}
+
+	svmCR := &gateway.StorageVirtualMachine{}
+	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, svmCR)
+	if err != nil {
+		return nil, err
+	}
+	if svmCR.Spec.S3Config == nil || !svmCR.Spec.S3Config.Enabled {
+		return nil, &s3UnavailableError{fmt.Sprintf("S3 is not enabled on StorageVirtualMachine %s/%s", namespace, name)}
+	}
+	if svmCR.GetSvmUuid() == "" {
+		return nil, &s3UnavailableError{fmt.Sprintf("StorageVirtualMachine %s/%s has not been created yet", namespace, name)}
+	}
+	return svmCR, nil
+}
+
+// ontapClient creates an ONTAP client of the custom resource's cluster
+// clients of an OntapCluster are shared with the StorageVirtualMachine reconciler
+func ontapClient(ctx context.Context, c client.Client, svmCR *gateway.StorageVirtualMachine,
+	clients *ontapcluster.Clients, cfg *config.OperatorConfiguration) (*ontap.Client, error) {
+	if clients == nil {
+		clients = ontapcluster.NewClients()
+	}
+	return clients.ClientFor(ctx, c, svmCR, operatorConfig(cfg))
+}
+
+// stringMap reads a map of strings such as the class parameters from an unstructured object
+func stringMap(obj *unstructured.Unstructured, fields ...string) map[string]string {
+	values, _, _ := unstructured.NestedStringMap(obj.Object, fields...)
+	if values == nil {
+		values = make(map[string]string)
+	}
+	return values
+}
+
+func findBucket(buckets ontap.S3BucketsResponse, name string) *ontap.S3Bucket {
+	for i := range buckets.Records {
+		if buckets.Records[i].Name == name {
+			return &buckets.Records[i]
+		}
+	}
+	return nil
+}
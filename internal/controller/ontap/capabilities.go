@@ -0,0 +1,206 @@
+package ontap
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Version is an ONTAP release, for example 9.14.1 - the zero value is an unknown version
+type Version struct {
+	Generation int
+	Major      int
+	Minor      int
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Generation, v.Major, v.Minor)
+}
+
+// Known reports whether the version was detected
+func (v Version) Known() bool {
+	return v.Generation > 0
+}
+
+// AtLeast reports whether the version is the other version or later
+func (v Version) AtLeast(other Version) bool {
+	if v.Generation != other.Generation {
+		return v.Generation > other.Generation
+	}
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	return v.Minor >= other.Minor
+}
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion reads a version such as 9.14.1 or the full release string NetApp Release 9.14.1P2: ...
+func ParseVersion(val string) (Version, error) {
+	match := versionPattern.FindStringSubmatch(val)
+	if match == nil {
+		return Version{}, fmt.Errorf("no ONTAP version in %q", val)
+	}
+	var v Version
+	v.Generation, _ = strconv.Atoi(match[1])
+	v.Major, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		v.Minor, _ = strconv.Atoi(match[3])
+	}
+	return v, nil
+}
+
+// OntapVersion returns the version reported by the cluster
+func (cluster Cluster) OntapVersion() Version {
+	return Version{
+		Generation: cluster.Version.Generation,
+		Major:      cluster.Version.Major,
+		Minor:      cluster.Version.Minor,
+	}
+}
+
+// Feature is an ONTAP capability the operator uses that depends on the ONTAP version
+type Feature string
+
+// Features defined here
+const (
+	FeaturePrivateCli         Feature = "REST private CLI"
+	FeatureS3                 Feature = "S3"
+	FeatureS3BucketPolicies   Feature = "S3 bucket policies"
+	FeatureNfs42              Feature = "NFS v4.2"
+	FeatureIscsiServicePolicy Feature = "iSCSI LIF service policy"
+	FeatureNvmeTcp            Feature = "NVMe/TCP"
+	FeatureSvmMigrate         Feature = "SVM migrate"
+	FeatureS3Versioning       Feature = "S3 bucket versioning"
+	FeatureS3NasBuckets       Feature = "S3 NAS buckets"
+	FeatureNvmeInBandAuth     Feature = "NVMe in-band authentication"
+	FeatureS3Lifecycle        Feature = "S3 bucket lifecycle rules"
+	FeatureS3ObjectLock       Feature = "S3 object locking"
+)
+
+// featureMatrix lists the first ONTAP release of each feature, in release order
+var featureMatrix = []struct {
+	feature Feature
+	since   Version
+}{
+	{FeaturePrivateCli, Version{9, 6, 0}},
+	{FeatureS3, Version{9, 8, 0}},
+	{FeatureS3BucketPolicies, Version{9, 8, 0}},
+	{FeatureNfs42, Version{9, 8, 0}},
+	{FeatureIscsiServicePolicy, Version{9, 10, 0}},
+	{FeatureNvmeTcp, Version{9, 10, 1}},
+	{FeatureSvmMigrate, Version{9, 10, 1}},
+	{FeatureS3Versioning, Version{9, 11, 1}},
+	{FeatureS3NasBuckets, Version{9, 12, 1}},
+	{FeatureNvmeInBandAuth, Version{9, 12, 1}},
+	{FeatureS3Lifecycle, Version{9, 13, 1}},
+	{FeatureS3ObjectLock, Version{9, 14, 1}},
+}
+
+// Since returns the first ONTAP release supporting the feature
+func Since(feature Feature) Version {
+	for _, val := range featureMatrix {
+		if val.feature == feature {
+			return val.since
+		}
+	}
+	return Version{}
+}
+
+// Capabilities reports the features of a cluster's ONTAP version
+// with an unknown version every feature is assumed supported and left to ONTAP to reject
+type Capabilities struct {
+	Version Version
+}
+
+// Supports reports whether the cluster supports the feature
+func (c Capabilities) Supports(feature Feature) bool {
+	return !c.Version.Known() || c.Version.AtLeast(Since(feature))
+}
+
+// Features returns the features of the matrix the cluster supports
+func (c Capabilities) Features() []Feature {
+	var features []Feature
+	for _, val := range featureMatrix {
+		if c.Supports(val.feature) {
+			features = append(features, val.feature)
+		}
+	}
+	return features
+}
+
+// Unsupported returns the features the cluster doesn't support, without duplicates
+func (c Capabilities) Unsupported(features []Feature) []Feature {
+	var unsupported []Feature
+	for _, val := range features {
+		if !c.Supports(val) && !containsFeature(unsupported, val) {
+			unsupported = append(unsupported, val)
+		}
+	}
+	return unsupported
+}
+
+// Describe lists the features with the release they need, for example NVMe/TCP (9.10.1)
+func Describe(features []Feature) string {
+	var names []string
+	for _, val := range features {
+		names = append(names, string(val)+" ("+Since(val).String()+")")
+	}
+	return strings.Join(names, ", ")
+}
+
+func containsFeature(features []Feature, feature Feature) bool {
+	for _, val := range features {
+		if val == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// versionCache holds the detected version - shared by the copies of a client
+type versionCache struct {
+	mu      sync.Mutex
+	version Version
+}
+
+// ClusterVersion returns the cluster's ONTAP version, asking the cluster only once per client
+func (c *Client) ClusterVersion() (Version, error) {
+	if c.versions != nil {
+		c.versions.mu.Lock()
+		version := c.versions.version
+		c.versions.mu.Unlock()
+		if version.Known() {
+			return version, nil
+		}
+	}
+
+	cluster, err := c.GetCluster()
+	if err != nil {
+		return Version{}, err
+	}
+	c.SetClusterVersion(cluster.OntapVersion())
+	return cluster.OntapVersion(), nil
+}
+
+// SetClusterVersion records a version detected elsewhere, for example by the periodic check of an OntapCluster
+func (c *Client) SetClusterVersion(version Version) {
+	if c.versions == nil {
+		return
+	}
+	c.versions.mu.Lock()
+	defer c.versions.mu.Unlock()
+	c.versions.version = version
+}
+
+// Capabilities returns the capabilities of the detected version - all features while it is unknown
+func (c *Client) Capabilities() Capabilities {
+	if c.versions == nil {
+		return Capabilities{}
+	}
+	c.versions.mu.Lock()
+	defer c.versions.mu.Unlock()
+	return Capabilities{Version: c.versions.version}
+}
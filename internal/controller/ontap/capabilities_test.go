@@ -0,0 +1,40 @@
+package ontap_test
+
+import (
+	"gateway/internal/controller/ontap"
+	"testing"
+)
+
+func TestParseVersionFromReleaseString(t *testing.T) {
+	input := "NetApp Release 9.12.1P2: Wed Mar 08 10:02:31 UTC 2023"
+	expectedOutput := ontap.Version{Generation: 9, Major: 12, Minor: 1}
+
+	actualOutput, actualError := ontap.ParseVersion(input)
+
+	if actualError != nil {
+		t.Errorf("Expected no error, but found %v", actualError)
+	}
+
+	if actualOutput != expectedOutput {
+		t.Errorf("Expected out to be %s, but found %s", expectedOutput, actualOutput)
+	}
+}
+
+func TestCapabilitiesUnsupported(t *testing.T) {
+	capabilities := ontap.Capabilities{Version: ontap.Version{Generation: 9, Major: 10, Minor: 0}}
+	input := []ontap.Feature{ontap.FeatureS3, ontap.FeatureNvmeTcp, ontap.FeatureS3Lifecycle, ontap.FeatureNvmeTcp}
+
+	actualOutput := capabilities.Unsupported(input)
+
+	if len(actualOutput) != 2 || actualOutput[0] != ontap.FeatureNvmeTcp || actualOutput[1] != ontap.FeatureS3Lifecycle {
+		t.Errorf("Expected NVMe/TCP and S3 lifecycle rules to be unsupported, but found %v", actualOutput)
+	}
+}
+
+func TestCapabilitiesUnknownVersion(t *testing.T) {
+	capabilities := ontap.Capabilities{}
+
+	if !capabilities.Supports(ontap.FeatureS3ObjectLock) {
+		t.Errorf("Expected an unknown version to leave the features to ONTAP")
+	}
+}
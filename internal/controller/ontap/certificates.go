@@ -2,22 +2,24 @@ package ontap
 
 import (
 	"encoding/json"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 type Certificate struct {
-	Svm               SvmRef `json:"svm,omitempty"`
-	Type              string `json:"type,omitempty"`
-	PublicCertificate string `json:"public_certificate,omitempty"`
-	PrivateKey        string `json:"private_key,omitempty"`
-	KeySize           int    `json:"key_size,omitempty"`
-	ExpiryTime        string `json:"expiry_time,omitempty"`
-	Name              string `json:"name,omitempty"`
-	CommonName        string `json:"common_name,omitempty"`
-	SerialNumber      string `json:"serial_number,omitempty"`
-	Uuid              string `json:"uuid,omitempty"`
+	Svm               SvmRef   `json:"svm,omitempty"`
+	Type              string   `json:"type,omitempty"`
+	PublicCertificate string   `json:"public_certificate,omitempty"`
+	PrivateKey        string   `json:"private_key,omitempty"`
+	Intermediates     []string `json:"intermediate_certificates,omitempty"`
+	KeySize           int      `json:"key_size,omitempty"`
+	ExpiryTime        string   `json:"expiry_time,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	CommonName        string   `json:"common_name,omitempty"`
+	SerialNumber      string   `json:"serial_number,omitempty"`
+	Uuid              string   `json:"uuid,omitempty"`
 }
 
 type CertificateSigningRequest struct {
@@ -46,7 +48,8 @@ type CertificateResponse struct {
 //const returnCertificateQs string = "?return_timeout=120&max_records=40&fields="
 
 func (c *Client) GetCertificatesBySvmUuid(uuid string, commonName string, caType string) (certs CertificateResponse, err error) {
-	uri := "/api/security/certificates?common_name=" + commonName + "&svm.uuid=" + uuid + "&type=" + caType
+	uri := "/api/security/certificates?common_name=" + commonName + "&svm.uuid=" + uuid + "&type=" + caType +
+		"&fields=uuid,name,common_name,type,expiry_time,public_certificate"
 
 	data, err := c.clientGet(uri)
 	if err != nil {
@@ -117,3 +120,34 @@ func (c *Client) CreateSignedCertificate(jsonPayload []byte, ca_uuid string) (ce
 
 	return resp, nil
 }
+
+func (c *Client) GetCertificateByUuid(uuid string) (cert Certificate, err error) {
+	uri := "/api/security/certificates/" + uuid + "?fields=uuid,name,common_name,type,expiry_time,public_certificate"
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return cert, errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"}, "no certificate")
+		}
+		return cert, &apiError{1, err.Error()}
+	}
+
+	var resp Certificate
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) DeleteCertificate(uuid string) (err error) {
+	uri := "/api/security/certificates/" + uuid
+
+	_, err = c.clientDelete(uri)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
@@ -36,3 +36,66 @@ func (c *Client) GetCluster() (cluster Cluster, err error) {
 
 	return resp, nil
 }
+
+type Node struct {
+	Name  string `json:"name"`
+	Uuid  string `json:"uuid"`
+	Model string `json:"model,omitempty"`
+	State string `json:"state,omitempty"`
+}
+
+type NodesResponse struct {
+	BaseResponse
+	Records []Node `json:"records,omitempty"`
+}
+
+func (c *Client) GetNodes() (nodes NodesResponse, err error) {
+	uri := "/api/cluster/nodes?fields=name,uuid,model,state"
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return nodes, &apiError{1, err.Error()}
+	}
+
+	var resp NodesResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
+
+type ClusterAggregate struct {
+	Name  string   `json:"name"`
+	Uuid  string   `json:"uuid"`
+	State string   `json:"state,omitempty"`
+	Node  Resource `json:"node,omitempty"`
+	Space struct {
+		BlockStorage struct {
+			Available int64 `json:"available"`
+		} `json:"block_storage"`
+	} `json:"space,omitempty"`
+}
+
+type ClusterAggregatesResponse struct {
+	BaseResponse
+	Records []ClusterAggregate `json:"records,omitempty"`
+}
+
+func (c *Client) GetAggregates() (aggregates ClusterAggregatesResponse, err error) {
+	uri := "/api/storage/aggregates?fields=name,uuid,state,node.name,space.block_storage.available"
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return aggregates, &apiError{1, err.Error()}
+	}
+
+	var resp ClusterAggregatesResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,193 @@
+package ontap
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+)
+
+const healthCheckUri = "/api/cluster?fields=name" //special key
+
+// Endpoints are the management addresses of a cluster, such as the cluster management LIF
+// and the node management LIFs - shared by the copies of a client
+// requests go to the active endpoint, which only changes when it can't be reached
+type Endpoints struct {
+	mu     sync.Mutex
+	hosts  []string
+	active int
+}
+
+// EndpointHealth is the result of checking one endpoint
+type EndpointHealth struct {
+	Host    string
+	Healthy bool
+	Error   string
+}
+
+// NewEndpoints returns the endpoints in failover order - the first one is active
+func NewEndpoints(hosts ...string) *Endpoints {
+	e := &Endpoints{}
+	for _, val := range hosts {
+		if val != "" && !containsHost(e.hosts, val) {
+			e.hosts = append(e.hosts, val)
+		}
+	}
+	return e
+}
+
+// Active returns the endpoint requests are sent to
+func (e *Endpoints) Active() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.hosts) == 0 {
+		return ""
+	}
+	return e.hosts[e.active]
+}
+
+// Hosts returns the endpoints in failover order
+func (e *Endpoints) Hosts() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string{}, e.hosts...)
+}
+
+// after returns the other endpoints in the order they are tried when the host can't be reached
+func (e *Endpoints) after(host string) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	start := 0
+	for i, val := range e.hosts {
+		if val == host {
+			start = i + 1
+		}
+	}
+	var others []string
+	for i := range e.hosts {
+		val := e.hosts[(start+i)%len(e.hosts)]
+		if val != host {
+			others = append(others, val)
+		}
+	}
+	return others
+}
+
+// use makes the host the active endpoint
+func (e *Endpoints) use(host string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, val := range e.hosts {
+		if val == host {
+			e.active = i
+		}
+	}
+}
+
+// activeHost returns the endpoint of the next request
+func (c *Client) activeHost() string {
+	if c.Endpoints != nil {
+		if host := c.Endpoints.Active(); host != "" {
+			return host
+		}
+	}
+	return c.Host
+}
+
+// ActiveHost returns the endpoint the client sends its requests to
+func (c *Client) ActiveHost() string {
+	return c.activeHost()
+}
+
+// failover sends the request to the other endpoints after a connection error
+// the first endpoint that answers becomes the active one
+func (c *Client) failover(req *http.Request, cause error) ([]byte, error) {
+	if c.Endpoints == nil || !connectionFailed(req, cause) {
+		return nil, cause
+	}
+
+	failed := req.URL.Host
+	for _, host := range c.Endpoints.after(failed) {
+		log.Printf("%s", "[WARN] ONTAP endpoint "+failed+" unreachable - failing over to "+host+": "+cause.Error())
+		retry := req.Clone(req.Context())
+		retry.URL.Host = host
+		retry.Host = host
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, cause
+			}
+			retry.Body = body
+		}
+
+		data, err := c.send(retry)
+		if err != nil && connectionFailed(retry, err) {
+			failed = host
+			continue
+		}
+		// the endpoint answered - stick to it even if ONTAP rejected the request
+		c.Endpoints.use(host)
+		return data, err
+	}
+	return nil, cause
+}
+
+// connectionFailed reports whether the request failed before ONTAP received it,
+// or timed out while reading, which is only safe to send again for GET requests
+func connectionFailed(req *http.Request, err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var netErr net.Error
+	return req.Method == http.MethodGet && errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// CheckEndpoints sends a request to each endpoint and makes the first healthy one active
+// when the active endpoint doesn't answer - a healthy active endpoint is kept
+func (c *Client) CheckEndpoints() []EndpointHealth {
+	hosts := []string{c.Host}
+	if c.Endpoints != nil {
+		hosts = c.Endpoints.Hosts()
+	}
+
+	var results []EndpointHealth
+	activeHealthy := false
+	firstHealthy := ""
+	for _, host := range hosts {
+		health := EndpointHealth{Host: host, Healthy: true}
+		req, err := http.NewRequest("GET", "https://"+host+healthCheckUri, nil)
+		if err == nil {
+			c.setHeaders(req)
+			_, err = c.send(req)
+		}
+		if err != nil {
+			health.Healthy = false
+			health.Error = err.Error()
+		} else {
+			if host == c.activeHost() {
+				activeHealthy = true
+			}
+			if firstHealthy == "" {
+				firstHealthy = host
+			}
+		}
+		results = append(results, health)
+	}
+
+	if c.Endpoints != nil && !activeHealthy && firstHealthy != "" {
+		log.Printf("%s", "[WARN] ONTAP endpoint "+c.activeHost()+" unhealthy - switching to "+firstHealthy)
+		c.Endpoints.use(firstHealthy)
+	}
+	return results
+}
+
+func containsHost(hosts []string, host string) bool {
+	for _, val := range hosts {
+		if val == host {
+			return true
+		}
+	}
+	return false
+}
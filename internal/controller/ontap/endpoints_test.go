@@ -0,0 +1,42 @@
+package ontap_test
+
+import (
+	"gateway/internal/controller/ontap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFailoverToReachableEndpoint(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"cluster1","version":{"full":"NetApp Release 9.14.1","generation":9,"major":14,"minor":1}}`))
+	}))
+	defer server.Close()
+	reachable := strings.TrimPrefix(server.URL, "https://")
+	unreachable := "127.0.0.1:1"
+
+	oc, _ := ontap.NewClient("admin", "password", unreachable, false, true)
+	oc.Endpoints = ontap.NewEndpoints(unreachable, reachable)
+
+	cluster, err := oc.GetCluster()
+
+	if err != nil {
+		t.Fatalf("Expected no error, but found %v", err)
+	}
+	if cluster.Name != "cluster1" {
+		t.Errorf("Expected cluster1, but found %s", cluster.Name)
+	}
+	if oc.ActiveHost() != reachable {
+		t.Errorf("Expected the active endpoint to be %s, but found %s", reachable, oc.ActiveHost())
+	}
+
+	health := oc.CheckEndpoints()
+
+	if len(health) != 2 || health[0].Healthy || !health[1].Healthy {
+		t.Errorf("Expected only %s to be healthy, but found %v", reachable, health)
+	}
+	if oc.ActiveHost() != reachable {
+		t.Errorf("Expected the client to stick to %s, but found %s", reachable, oc.ActiveHost())
+	}
+}
@@ -0,0 +1,108 @@
+package ontap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type Igroup struct {
+	Uuid       string            `json:"uuid,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	Svm        SvmRef            `json:"svm,omitempty"`
+	OsType     string            `json:"os_type,omitempty"`
+	Protocol   string            `json:"protocol,omitempty"`
+	Comment    string            `json:"comment,omitempty"`
+	Initiators []IgroupInitiator `json:"initiators,omitempty"`
+	Portset    *Ref              `json:"portset,omitempty"`
+}
+
+type IgroupInitiator struct {
+	Name string `json:"name,omitempty"`
+}
+
+type IgroupInitiatorsUpsert struct {
+	Records []IgroupInitiator `json:"records,omitempty"`
+}
+
+type IgroupsResponse struct {
+	BaseResponse
+	Records []Igroup `json:"records,omitempty"`
+}
+
+const returnIgroupRecords string = "?return_timeout=120&max_records=100&fields=uuid,name,svm,os_type,protocol,comment,initiators.name,portset"
+
+func (c *Client) GetIgroupsBySvmUuid(uuid string) (igroups IgroupsResponse, err error) {
+	uri := "/api/protocols/san/igroups" + returnIgroupRecords + "&svm.uuid=" + uuid
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return igroups, &apiError{1, err.Error()}
+	}
+
+	var resp IgroupsResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) CreateIgroup(jsonPayload []byte) (err error) {
+	uri := "/api/protocols/san/igroups"
+	_, err = c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) PatchIgroup(uuid string, jsonPayload []byte) (err error) {
+	uri := "/api/protocols/san/igroups/" + uuid
+
+	_, err = c.clientPatch(uri, jsonPayload)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return &apiError{404, fmt.Sprintf("Igroup with UUID \"%s\" not found", uuid)}
+		}
+		//miscellaneous errror
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteIgroup(uuid string) (err error) {
+	uri := "/api/protocols/san/igroups/" + uuid
+
+	_, err = c.clientDelete(uri)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) AddIgroupInitiators(uuid string, jsonPayload []byte) (err error) {
+	uri := "/api/protocols/san/igroups/" + uuid + "/initiators"
+	_, err = c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteIgroupInitiator(uuid string, name string) (err error) {
+	uri := "/api/protocols/san/igroups/" + uuid + "/initiators/" + url.PathEscape(name)
+
+	_, err = c.clientDelete(uri)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package ontap
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -118,3 +119,78 @@ func (c *Client) CreateIscsiServicePolicy(jsonPayload []byte) (err error) {
 	}
 	return nil
 }
+
+type IscsiCredentials struct {
+	Initiator          string          `json:"initiator,omitempty"`
+	Svm                SvmRef          `json:"svm,omitempty"`
+	AuthenticationType string          `json:"authentication_type,omitempty"`
+	Chap               *IscsiChapUsers `json:"chap,omitempty"`
+}
+
+type IscsiChapUsers struct {
+	Inbound  *IscsiChapUser `json:"inbound,omitempty"`
+	Outbound *IscsiChapUser `json:"outbound,omitempty"`
+}
+
+type IscsiChapUser struct {
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+type IscsiCredentialsResponse struct {
+	BaseResponse
+	Records []IscsiCredentials `json:"records,omitempty"`
+}
+
+func (c *Client) GetIscsiCredentialsBySvmUuid(uuid string) (credentials IscsiCredentialsResponse, err error) {
+	uri := "/api/protocols/san/iscsi/credentials?fields=initiator,svm,authentication_type,chap.inbound.user,chap.outbound.user&svm.uuid=" + uuid
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return credentials, &apiError{1, err.Error()}
+	}
+
+	var resp IscsiCredentialsResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) CreateIscsiCredentials(jsonPayload []byte) (err error) {
+	uri := "/api/protocols/san/iscsi/credentials"
+	_, err = c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) PatchIscsiCredentials(uuid string, initiator string, jsonPayload []byte) (err error) {
+	uri := "/api/protocols/san/iscsi/credentials/" + uuid + "/" + url.PathEscape(initiator)
+
+	_, err = c.clientPatch(uri, jsonPayload)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return &apiError{404, fmt.Sprintf("iSCSI credentials for initiator \"%s\" not found", initiator)}
+		}
+		//miscellaneous errror
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteIscsiCredentials(uuid string, initiator string) (err error) {
+	uri := "/api/protocols/san/iscsi/credentials/" + uuid + "/" + url.PathEscape(initiator)
+
+	_, err = c.clientDelete(uri)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
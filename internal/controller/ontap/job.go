@@ -2,6 +2,7 @@ package ontap
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -37,3 +38,63 @@ func (c *Client) GetJob(url string) (job Job, err error) {
 	return job, nil
 
 }
+
+const jobPollInterval = 2 * time.Second //magic number
+
+// jobUuid returns the job uuid of an asynchronous response, empty when the request completed synchronously
+func jobUuid(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+	var result JobResponse
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		return "", &apiError{2, err.Error()}
+	}
+	return result.Job.Uuid, nil
+}
+
+// JobRunningError reports a job still queued, running or paused when WaitForJob stopped polling
+type JobRunningError struct {
+	Uuid  string
+	State string
+}
+
+func (e *JobRunningError) Error() string {
+	return "waiting for job " + e.Uuid + " - still " + e.State
+}
+
+// IsJobRunning reports whether the error is a job that didn't complete within the job timeout
+func IsJobRunning(err error) bool {
+	var running *JobRunningError
+	return errors.As(err, &running)
+}
+
+// WaitForJob polls the job until it is no longer queued or running, at most for the client's job timeout
+// also resumes polling of a job started before the operator restarted
+func (c *Client) WaitForJob(uuid string) (job Job, err error) {
+	url := "/api/cluster/jobs/" + uuid
+	timeout := c.JobTimeout
+	if timeout <= 0 {
+		timeout = defaultJobTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	job, err = c.GetJob(url)
+	for err == nil && (job.State == "running" || job.State == "queued" || job.State == "paused") {
+		if !time.Now().Add(jobPollInterval).Before(deadline) {
+			return job, &JobRunningError{Uuid: uuid, State: job.State}
+		}
+		time.Sleep(jobPollInterval)
+		job, err = c.GetJob(url)
+	}
+	if err != nil {
+		return job, &apiError{1, err.Error()}
+	}
+
+	if job.State == "failure" {
+		return job, &apiError{int64(job.Code), job.Message}
+	}
+
+	return job, nil
+}
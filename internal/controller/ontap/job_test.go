@@ -0,0 +1,47 @@
+package ontap_test
+
+import (
+	"gateway/internal/controller/ontap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitForJobStopsAfterJobTimeout(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"uuid":"job1","state":"running"}`))
+	}))
+	defer server.Close()
+
+	oc, _ := ontap.NewClient("admin", "password", strings.TrimPrefix(server.URL, "https://"), false, true)
+	oc.JobTimeout = time.Second
+
+	job, err := oc.WaitForJob("job1")
+
+	if !ontap.IsJobRunning(err) {
+		t.Fatalf("Expected a running job error, but found %v", err)
+	}
+	if job.State != "running" {
+		t.Errorf("Expected the job to be running, but found %s", job.State)
+	}
+}
+
+func TestWaitForJobReturnsCompletedJob(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"uuid":"job1","state":"success"}`))
+	}))
+	defer server.Close()
+
+	oc, _ := ontap.NewClient("admin", "password", strings.TrimPrefix(server.URL, "https://"), false, true)
+
+	job, err := oc.WaitForJob("job1")
+
+	if err != nil {
+		t.Fatalf("Expected no error, but found %v", err)
+	}
+	if job.State != "success" {
+		t.Errorf("Expected the job to succeed, but found %s", job.State)
+	}
+}
@@ -0,0 +1,68 @@
+package ontap
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+type NameMapping struct {
+	Svm         SvmRef `json:"svm,omitempty"`
+	Direction   string `json:"direction,omitempty"`
+	Index       int    `json:"index,omitempty"`
+	Pattern     string `json:"pattern,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+type NameMappingsResponse struct {
+	BaseResponse
+	Records []NameMapping `json:"records,omitempty"`
+}
+
+func (c *Client) GetNameMappingsBySvmUuid(uuid string, direction string) (mappings NameMappingsResponse, err error) {
+	uri := "/api/name-services/name-mappings?fields=svm,direction,index,pattern,replacement&order_by=index" +
+		"&svm.uuid=" + uuid + "&direction=" + direction
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return mappings, &apiError{1, err.Error()}
+	}
+
+	var resp NameMappingsResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) CreateNameMapping(jsonPayload []byte) (err error) {
+	uri := "/api/name-services/name-mappings"
+	_, err = c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) PatchNameMapping(uuid string, direction string, index int, jsonPayload []byte) (err error) {
+	uri := "/api/name-services/name-mappings/" + uuid + "/" + url.PathEscape(direction) + "/" + strconv.Itoa(index)
+	_, err = c.clientPatch(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteNameMapping(uuid string, direction string, index int) (err error) {
+	uri := "/api/name-services/name-mappings/" + uuid + "/" + url.PathEscape(direction) + "/" + strconv.Itoa(index)
+	_, err = c.clientDelete(uri)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
@@ -11,15 +11,45 @@ import (
 )
 
 type NFSService struct {
-	Enabled  *bool       `json:"enabled,omitempty"`
-	Protocol NFSProtocol `json:"protocol,omitempty"`
-	Svm      SvmRef      `json:"svm,omitempty"`
+	Enabled          *bool         `json:"enabled,omitempty"`
+	Protocol         NFSProtocol   `json:"protocol,omitempty"`
+	Svm              SvmRef        `json:"svm,omitempty"`
+	ShowmountEnabled *bool         `json:"showmount_enabled,omitempty"`
+	VstorageEnabled  *bool         `json:"vstorage_enabled,omitempty"`
+	Transport        *NFSTransport `json:"transport,omitempty"`
+	Qtree            *NFSQtree     `json:"qtree,omitempty"`
 }
 
 type NFSProtocol struct {
-	V3Enable  *bool `json:"v3_enabled,omitempty"`
-	V4Enable  *bool `json:"v40_enabled,omitempty"`
-	V41Enable *bool `json:"v41_enabled,omitempty"`
+	V3Enable      *bool  `json:"v3_enabled,omitempty"`
+	V4Enable      *bool  `json:"v40_enabled,omitempty"`
+	V41Enable     *bool  `json:"v41_enabled,omitempty"`
+	V42Enable     *bool  `json:"v42_enabled,omitempty"`
+	V4IdDomain    string `json:"v4_id_domain,omitempty"`
+	V3FileId64bit *bool  `json:"v3_64bit_identifiers_enabled,omitempty"`
+	V4FileId64bit *bool  `json:"v4_64bit_identifiers_enabled,omitempty"`
+}
+
+type NFSTransport struct {
+	TcpMaxTransferSize *int `json:"tcp_max_transfer_size,omitempty"`
+}
+
+type NFSQtree struct {
+	ExportEnabled *bool `json:"export_enabled,omitempty"`
+}
+
+type KerberosInterface struct {
+	Interface Ref    `json:"interface,omitempty"`
+	Svm       SvmRef `json:"svm,omitempty"`
+	Enabled   *bool  `json:"enabled,omitempty"`
+	Spn       string `json:"spn,omitempty"`
+	User      string `json:"user,omitempty"`
+	Password  string `json:"password,omitempty"`
+}
+
+type KerberosInterfacesResponse struct {
+	BaseResponse
+	Records []KerberosInterface `json:"records,omitempty"`
 }
 
 type ExportPolicyUpsert struct {
@@ -37,12 +67,16 @@ type ExportPolicy struct {
 
 // Requires ONTAP 9.10?
 type ExportRule struct {
-	Protocols []string      `json:"protocols,omitempty"`
-	RwRule    []string      `json:"rw_rule,omitempty"`
-	RoRule    []string      `json:"ro_rule,omitempty"`
-	Superuser []string      `json:"superuser,omitempty"`
-	Anonuser  string        `json:"anonymous_user,omitempty"`
-	Clients   []ExportMatch `json:"clients,omitempty"`
+	Index            int           `json:"index,omitempty"`
+	Protocols        []string      `json:"protocols,omitempty"`
+	RwRule           []string      `json:"rw_rule,omitempty"`
+	RoRule           []string      `json:"ro_rule,omitempty"`
+	Superuser        []string      `json:"superuser,omitempty"`
+	Anonuser         string        `json:"anonymous_user,omitempty"`
+	Clients          []ExportMatch `json:"clients,omitempty"`
+	ChownMode        string        `json:"chown_mode,omitempty"`
+	NtfsUnixSecurity string        `json:"ntfs_unix_security,omitempty"`
+	AllowSuid        *bool         `json:"allow_suid,omitempty"`
 }
 
 type ExportMatch struct {
@@ -54,10 +88,15 @@ type ExportResponse struct {
 	Records []ExportPolicy `json:"records,omitempty"`
 }
 
+type ExportRulesResponse struct {
+	BaseResponse
+	Records []ExportRule `json:"records,omitempty"`
+}
+
 const returnNFSRecords string = "?return_timeout=120&max_records=40&fields=*"
 
 func (c *Client) GetNfsServiceBySvmUuid(uuid string) (nfsService NFSService, err error) {
-	uri := "/api/protocols/nfs/services/" + uuid
+	uri := "/api/protocols/nfs/services/" + uuid + "?fields=*"
 
 	data, err := c.clientGet(uri)
 	if err != nil {
@@ -175,6 +214,59 @@ func (c *Client) DeleteNfsExport(id int) (err error) {
 	return nil
 }
 
+func (c *Client) GetNfsExportRules(id int) (rules ExportRulesResponse, err error) {
+	uri := "/api/protocols/nfs/export-policies/" + strconv.Itoa(id) + "/rules" + returnNFSRecords + "&order_by=index"
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return rules, &apiError{1, err.Error()}
+	}
+
+	var resp ExportRulesResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) CreateNfsExportRule(id int, jsonPayload []byte) (err error) {
+	uri := "/api/protocols/nfs/export-policies/" + strconv.Itoa(id) + "/rules"
+	_, err = c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) PatchNfsExportRule(id int, index int, jsonPayload []byte) (err error) {
+	uri := "/api/protocols/nfs/export-policies/" + strconv.Itoa(id) + "/rules/" + strconv.Itoa(index)
+
+	_, err = c.clientPatch(uri, jsonPayload)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return &apiError{404, fmt.Sprintf("Export rule with index \"%s\" not found", strconv.Itoa(index))}
+		}
+		//miscellaneous errror
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteNfsExportRule(id int, index int) (err error) {
+	uri := "/api/protocols/nfs/export-policies/" + strconv.Itoa(id) + "/rules/" + strconv.Itoa(index)
+
+	_, err = c.clientDelete(uri)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
 func (c *Client) GetNfsInterfacesBySvmUuid(uuid string) (lifs IpInterfacesResponse, err error) {
 	uri := "/api/network/ip/interfaces" + returnNFSRecords + "&service_policy.name=default-data-files&svm.uuid=" + uuid
 
@@ -191,3 +283,35 @@ func (c *Client) GetNfsInterfacesBySvmUuid(uuid string) (lifs IpInterfacesRespon
 
 	return resp, nil
 }
+
+func (c *Client) GetKerberosInterfacesBySvmUuid(uuid string) (interfaces KerberosInterfacesResponse, err error) {
+	uri := "/api/protocols/nfs/kerberos/interfaces" + returnNFSRecords + "&svm.uuid=" + uuid
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return interfaces, &apiError{1, err.Error()}
+	}
+
+	var resp KerberosInterfacesResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) PatchKerberosInterface(lifUuid string, jsonPayload []byte) (err error) {
+	uri := "/api/protocols/nfs/kerberos/interfaces/" + lifUuid
+
+	_, err = c.clientPatch(uri, jsonPayload)
+	if err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return &apiError{404, fmt.Sprintf("Kerberos interface with UUID \"%s\" not found", lifUuid)}
+		}
+		//miscellaneous errror
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
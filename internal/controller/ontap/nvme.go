@@ -3,6 +3,7 @@ package ontap
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -113,3 +114,145 @@ func (c *Client) CreateNvmeServicePolicy(jsonPayload []byte) (err error) {
 	}
 	return nil
 }
+
+type NvmeSubsystem struct {
+	Uuid      string              `json:"uuid,omitempty"`
+	Name      string              `json:"name,omitempty"`
+	Svm       SvmRef              `json:"svm,omitempty"`
+	OsType    string              `json:"os_type,omitempty"`
+	Comment   string              `json:"comment,omitempty"`
+	TargetNqn string              `json:"target_nqn,omitempty"`
+	Hosts     []NvmeSubsystemHost `json:"hosts,omitempty"`
+}
+
+type NvmeSubsystemHost struct {
+	Nqn        string          `json:"nqn,omitempty"`
+	DhHmacChap *NvmeDhHmacChap `json:"dh_hmac_chap,omitempty"`
+}
+
+type NvmeDhHmacChap struct {
+	HostSecretKey       string `json:"host_secret_key,omitempty"`
+	ControllerSecretKey string `json:"controller_secret_key,omitempty"`
+	HashFunction        string `json:"hash_function,omitempty"`
+	GroupSize           string `json:"group_size,omitempty"`
+}
+
+type NvmeSubsystemMap struct {
+	Svm       SvmRef `json:"svm,omitempty"`
+	Subsystem Ref    `json:"subsystem,omitempty"`
+	Namespace Ref    `json:"namespace,omitempty"`
+}
+
+type NvmeSubsystemMapsResponse struct {
+	BaseResponse
+	Records []NvmeSubsystemMap `json:"records,omitempty"`
+}
+
+type NvmeSubsystemHostsUpsert struct {
+	Records []NvmeSubsystemHost `json:"records,omitempty"`
+}
+
+type NvmeSubsystemsResponse struct {
+	BaseResponse
+	Records []NvmeSubsystem `json:"records,omitempty"`
+}
+
+const returnNvmeSubsystemRecords string = "?return_timeout=120&max_records=100&fields=uuid,name,svm,os_type,comment,target_nqn,hosts.nqn"
+
+func (c *Client) GetNvmeSubsystemsBySvmUuid(uuid string) (subsystems NvmeSubsystemsResponse, err error) {
+	uri := "/api/protocols/nvme/subsystems" + returnNvmeSubsystemRecords + "&svm.uuid=" + uuid
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return subsystems, &apiError{1, err.Error()}
+	}
+
+	var resp NvmeSubsystemsResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) CreateNvmeSubsystem(jsonPayload []byte) (err error) {
+	uri := "/api/protocols/nvme/subsystems"
+	_, err = c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+// DeleteNvmeSubsystem deletes the subsystem with its hosts and namespace maps - the namespaces are kept
+func (c *Client) DeleteNvmeSubsystem(uuid string) (err error) {
+	uri := "/api/protocols/nvme/subsystems/" + uuid + "?allow_delete_with_hosts=true&allow_delete_while_mapped=true"
+
+	_, err = c.clientDelete(uri)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) AddNvmeSubsystemHosts(uuid string, jsonPayload []byte) (err error) {
+	uri := "/api/protocols/nvme/subsystems/" + uuid + "/hosts"
+	_, err = c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteNvmeSubsystemHost(uuid string, nqn string) (err error) {
+	uri := "/api/protocols/nvme/subsystems/" + uuid + "/hosts/" + url.PathEscape(nqn)
+
+	_, err = c.clientDelete(uri)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) GetNvmeSubsystemMapsBySvmUuid(uuid string) (maps NvmeSubsystemMapsResponse, err error) {
+	uri := "/api/protocols/nvme/subsystem-maps?return_timeout=120&max_records=1000&fields=svm,subsystem,namespace&svm.uuid=" + uuid
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return maps, &apiError{1, err.Error()}
+	}
+
+	var resp NvmeSubsystemMapsResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) CreateNvmeSubsystemMap(jsonPayload []byte) (err error) {
+	uri := "/api/protocols/nvme/subsystem-maps"
+	_, err = c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteNvmeSubsystemMap(subsystemUuid string, namespaceUuid string) (err error) {
+	uri := "/api/protocols/nvme/subsystem-maps/" + subsystemUuid + "/" + namespaceUuid
+
+	_, err = c.clientDelete(uri)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
@@ -6,18 +6,23 @@ package ontap
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"strings"
 	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 const libraryVersion = "0.1"                        //special key
 const userAgent = "astra.gateway/" + libraryVersion //special key
-const defaultTimeout = 3                            // special key
+const defaultTimeout = 3 * time.Second              // special key
+const defaultJobTimeout = 5 * time.Minute           // special key
 const contentType = "application/hal+json"          //special key
 
 type Client struct {
@@ -29,6 +34,16 @@ type Client struct {
 	TimeOut     time.Duration
 	UserAgent   string
 	ContentType string
+	// JobTimeout bounds the polling of an asynchronous job - a job still running after it is reported as waiting
+	JobTimeout time.Duration
+	// RootCAs verifies the cluster certificate when TrustSSL is off - nil uses the system roots
+	RootCAs *x509.CertPool
+	// RateLimiter is shared by the clients of a cluster - nil doesn't limit requests
+	RateLimiter flowcontrol.RateLimiter
+	// Endpoints are the management addresses to fail over to - nil only uses Host
+	Endpoints *Endpoints
+	// versions caches the cluster's ONTAP version
+	versions *versionCache
 }
 
 type apiError struct {
@@ -52,8 +67,10 @@ func NewClient(user, password, host string, debug, trustSsl bool) (client *Clien
 		Debug:       debug,
 		TrustSSL:    trustSsl,
 		TimeOut:     defaultTimeout,
+		JobTimeout:  defaultJobTimeout,
 		UserAgent:   userAgent,
 		ContentType: contentType,
+		versions:    &versionCache{},
 	}, error
 }
 
@@ -61,7 +78,7 @@ func NewClient(user, password, host string, debug, trustSsl bool) (client *Clien
 
 func (c *Client) clientGet(uri string) (data []byte, err error) {
 
-	url := "https://" + c.Host + uri
+	url := "https://" + c.activeHost() + uri
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -89,7 +106,7 @@ func (c *Client) clientGet(uri string) (data []byte, err error) {
 
 func (c *Client) clientPost(uri string, json []byte) (data []byte, err error) {
 
-	url := "https://" + c.Host + uri
+	url := "https://" + c.activeHost() + uri
 
 	payload := bytes.NewReader(json)
 
@@ -120,7 +137,7 @@ func (c *Client) clientPost(uri string, json []byte) (data []byte, err error) {
 
 func (c *Client) clientPatch(uri string, json []byte) (data []byte, err error) {
 
-	url := "https://" + c.Host + uri
+	url := "https://" + c.activeHost() + uri
 
 	payload := bytes.NewReader(json)
 
@@ -151,7 +168,7 @@ func (c *Client) clientPatch(uri string, json []byte) (data []byte, err error) {
 
 func (c *Client) clientDelete(uri string) (data []byte, err error) {
 
-	url := "https://" + c.Host + uri
+	url := "https://" + c.activeHost() + uri
 
 	req, err := http.NewRequest("DELETE", url, nil)
 	if err != nil {
@@ -180,18 +197,42 @@ func (c *Client) clientDelete(uri string) (data []byte, err error) {
 // Unified Do func
 
 func (c *Client) doRequest(req *http.Request) ([]byte, error) {
+	c.setHeaders(req)
+
+	if c.RateLimiter != nil {
+		c.RateLimiter.Accept()
+	}
+
+	body, err := c.send(req)
+	if err != nil {
+		return c.failover(req, err)
+	}
+	return body, nil
+}
+
+func (c *Client) setHeaders(req *http.Request) {
 	req.SetBasicAuth(c.UserName, c.Password)
 	req.Header.Set("Content-Type", c.ContentType)
 	req.Header.Set("UserAgent", c.UserAgent)
+}
 
-	httpClient := &http.Client{
-		Timeout: time.Second * c.TimeOut,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: c.TrustSSL,
-			},
+// send sends the request to the endpoint in its url
+func (c *Client) send(req *http.Request) ([]byte, error) {
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: c.TrustSSL,
+			RootCAs:            c.RootCAs,
 		},
 	}
+	if c.Endpoints != nil {
+		// give up connecting early enough to try the other endpoints
+		transport.DialContext = (&net.Dialer{Timeout: c.TimeOut / 2}).DialContext
+	}
+
+	httpClient := &http.Client{
+		Timeout:   c.TimeOut,
+		Transport: transport,
+	}
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -0,0 +1,62 @@
+package ontap
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type Qtree struct {
+	Id           int             `json:"id,omitempty"`
+	Name         string          `json:"name,omitempty"`
+	Svm          SvmRef          `json:"svm,omitempty"`
+	Volume       Ref             `json:"volume,omitempty"`
+	ExportPolicy ExportPolicyRef `json:"export_policy,omitempty"`
+}
+
+type QtreesResponse struct {
+	BaseResponse
+	Records []Qtree `json:"records,omitempty"`
+}
+
+func (c *Client) GetQtreeByName(svmUuid string, volumeName string, name string) (qtree Qtree, err error) {
+	uri := "/api/storage/qtrees?fields=id,name,svm,volume,export_policy&svm.uuid=" + svmUuid +
+		"&volume.name=" + volumeName + "&name=" + name
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return qtree, &apiError{1, err.Error()}
+	}
+
+	var resp QtreesResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return qtree, &apiError{2, err.Error()}
+	}
+
+	if resp.NumRecords == 0 {
+		return qtree, errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"}, "no qtree")
+	}
+
+	return resp.Records[0], nil
+}
+
+func (c *Client) PatchQtree(volumeUuid string, id int, jsonPayload []byte) (err error) {
+	uri := "/api/storage/qtrees/" + volumeUuid + "/" + strconv.Itoa(id)
+
+	data, err := c.clientPatch(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	job, err := jobUuid(data)
+	if err != nil || job == "" {
+		// an empty job completed synchronously
+		return err
+	}
+
+	_, err = c.WaitForJob(job)
+	return err
+}
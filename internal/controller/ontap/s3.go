@@ -3,26 +3,29 @@ package ontap
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
-	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 type S3Service struct {
-	Svm            SvmRef   `json:"svm,omitempty"`
-	Certificate    Resource `json:"certificate,omitempty"`
-	IsHttpEnabled  bool     `json:"is_http_enabled"`
-	IsHttpsEnabled bool     `json:"is_https_enabled"`
-	Port           int      `json:"port,omitempty"`
-	SecurePort     int      `json:"secure_port,omitempty"`
-	Enabled        bool     `json:"enabled"`
-	Name           string   `json:"name"`
+	Svm             SvmRef   `json:"svm,omitempty"`
+	Certificate     Resource `json:"certificate,omitempty"`
+	IsHttpEnabled   bool     `json:"is_http_enabled"`
+	IsHttpsEnabled  bool     `json:"is_https_enabled"`
+	Port            int      `json:"port,omitempty"`
+	SecurePort      int      `json:"secure_port,omitempty"`
+	Enabled         bool     `json:"enabled"`
+	Name            string   `json:"name"`
+	DefaultUnixUser string   `json:"default_unix_user,omitempty"`
 }
 
 type S3User struct {
 	Name      string `json:"name,omitempty"`
+	Comment   string `json:"comment,omitempty"`
 	Svm       SvmRef `json:"svm,omitempty"`
 	AccessKey string `json:"access_key,omitempty"`
 	SecretKey string `json:"secret_key,omitempty"`
@@ -34,26 +37,65 @@ type S3UsersResponse struct {
 }
 
 type S3Bucket struct {
-	Name    string         `json:"name,omitempty"`
-	Svm     SvmRef         `json:"svm,omitempty"`
-	Size    int            `json:"size,omitempty"`
-	Type    string         `json:"type,omitempty"`
-	Comment string         `json:"comment,omitempty"`
-	Uuid    string         `json:"uuid,omitempty"`
-	Policy  S3BucketPolicy `json:"policy,omitempty"`
+	Name                string                       `json:"name,omitempty"`
+	Svm                 SvmRef                       `json:"svm,omitempty"`
+	Size                int                          `json:"size,omitempty"`
+	Type                string                       `json:"type,omitempty"`
+	NasPath             string                       `json:"nas_path,omitempty"`
+	Comment             string                       `json:"comment,omitempty"`
+	Uuid                string                       `json:"uuid,omitempty"`
+	Policy              *S3BucketPolicy              `json:"policy,omitempty"`
+	VersioningState     string                       `json:"versioning_state,omitempty"`
+	LifecycleManagement *S3BucketLifecycleManagement `json:"lifecycle_management,omitempty"`
+	Retention           *S3BucketRetention           `json:"retention,omitempty"`
 }
 
 type S3BucketPolicy struct {
-	Statements []S3BucketPolicyStatements `json:"statements,omitempty"`
+	Statements []S3BucketPolicyStatements `json:"statements"`
 }
 
 type S3BucketPolicyStatements struct {
+	Sid        string   `json:"sid,omitempty"`
 	Actions    []string `json:"actions,omitempty"`
 	Principals []string `json:"principals,omitempty"`
 	Effect     string   `json:"effect,omitempty"`
 	Resources  []string `json:"resources,omitempty"`
 }
 
+type S3BucketLifecycleManagement struct {
+	Rules []S3BucketLifecycleRule `json:"rules"`
+}
+
+type S3BucketLifecycleRule struct {
+	Name                           string                           `json:"name,omitempty"`
+	Enabled                        *bool                            `json:"enabled,omitempty"`
+	ObjectFilter                   *S3BucketLifecycleObjectFilter   `json:"object_filter,omitempty"`
+	Expiration                     *S3BucketLifecycleExpiration     `json:"expiration,omitempty"`
+	NonCurrentVersionExpiration    *S3BucketLifecycleNonCurrent     `json:"non_current_version_expiration,omitempty"`
+	AbortIncompleteMultipartUpload *S3BucketLifecycleAbortMultipart `json:"abort_incomplete_multipart_upload,omitempty"`
+}
+
+type S3BucketLifecycleObjectFilter struct {
+	Prefix string `json:"prefix,omitempty"`
+}
+
+type S3BucketLifecycleExpiration struct {
+	ObjectAgeDays *int `json:"object_age_days,omitempty"`
+}
+
+type S3BucketLifecycleNonCurrent struct {
+	NonCurrentDays *int `json:"non_current_days,omitempty"`
+}
+
+type S3BucketLifecycleAbortMultipart struct {
+	AfterInitiationDays *int `json:"after_initiation_days,omitempty"`
+}
+
+type S3BucketRetention struct {
+	Mode          string `json:"mode,omitempty"`
+	DefaultPeriod string `json:"default_period,omitempty"`
+}
+
 type S3BucketsResponse struct {
 	BaseResponse
 	Records []S3Bucket `json:"records,omitempty"`
@@ -64,6 +106,42 @@ type S3BucketJobResponse struct {
 	Uuid string `json:"uuid,omitempty"`
 }
 
+type S3Group struct {
+	Id       int         `json:"id,omitempty"`
+	Name     string      `json:"name,omitempty"`
+	Comment  string      `json:"comment,omitempty"`
+	Users    []S3NameRef `json:"users"`
+	Policies []S3NameRef `json:"policies"`
+}
+
+type S3NameRef struct {
+	Name string `json:"name"`
+}
+
+type S3GroupsResponse struct {
+	BaseResponse
+	Records []S3Group `json:"records,omitempty"`
+}
+
+type S3Policy struct {
+	Name       string              `json:"name,omitempty"`
+	Comment    string              `json:"comment,omitempty"`
+	ReadOnly   *bool               `json:"read-only,omitempty"`
+	Statements []S3PolicyStatement `json:"statements"`
+}
+
+type S3PolicyStatement struct {
+	Sid       string   `json:"sid,omitempty"`
+	Effect    string   `json:"effect,omitempty"`
+	Actions   []string `json:"actions,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+}
+
+type S3PoliciesResponse struct {
+	BaseResponse
+	Records []S3Policy `json:"records,omitempty"`
+}
+
 const returnS3Records string = "?return_records=true"
 
 func (c *Client) GetS3ServiceBySvmUuid(uuid string) (s3Service S3Service, err error) {
@@ -152,7 +230,7 @@ func (c *Client) CreateS3ServicePolicy(jsonPayload []byte) (err error) {
 }
 
 func (c *Client) GetS3UsersBySvmUuid(uuid string) (users S3UsersResponse, err error) {
-	uri := "/api/protocols/s3/services/" + uuid + "/users"
+	uri := "/api/protocols/s3/services/" + uuid + "/users?fields=name,comment"
 
 	data, err := c.clientGet(uri)
 	if err != nil {
@@ -202,6 +280,25 @@ func (c *Client) CreateS3User(uuid string, jsonPayload []byte) (users S3UsersRes
 	return resp, nil
 }
 
+// RegenerateS3UserKeys replaces the user's access and secret keys
+// ONTAP keeps a single key pair per user so the previous keys stop working immediately
+func (c *Client) RegenerateS3UserKeys(uuid string, name string) (users S3UsersResponse, err error) {
+	uri := "/api/protocols/s3/services/" + uuid + "/users/" + url.PathEscape(name) + "?regenerate_keys=true"
+
+	data, err := c.clientPatch(uri, []byte("{}"))
+	if err != nil {
+		return users, &apiError{1, err.Error()}
+	}
+
+	var resp S3UsersResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
+
 func (c *Client) DeleteS3User(uuid string, name string) (err error) {
 	uri := "/api/protocols/s3/services/" + uuid + "/users/" + name
 
@@ -213,8 +310,28 @@ func (c *Client) DeleteS3User(uuid string, name string) (err error) {
 	return nil
 }
 
+// s3BucketFields returns the bucket fields the cluster's ONTAP version knows
+// ONTAP rejects requests for fields of later releases
+func s3BucketFields(capabilities Capabilities) string {
+	fields := "name,uuid,size,comment,policy"
+	if capabilities.Supports(FeatureS3Versioning) {
+		fields += ",versioning_state"
+	}
+	if capabilities.Supports(FeatureS3NasBuckets) {
+		fields += ",type,nas_path"
+	}
+	if capabilities.Supports(FeatureS3Lifecycle) {
+		fields += ",lifecycle_management"
+	}
+	if capabilities.Supports(FeatureS3ObjectLock) {
+		fields += ",retention"
+	}
+	return fields
+}
+
 func (c *Client) GetS3BucketsBySvmUuid(uuid string) (users S3BucketsResponse, err error) {
-	uri := "/api/protocols/s3/services/" + uuid + "/buckets"
+	uri := "/api/protocols/s3/services/" + uuid + "/buckets" +
+		"?fields=" + s3BucketFields(c.Capabilities())
 
 	data, err := c.clientGet(uri)
 	if err != nil {
@@ -231,39 +348,153 @@ func (c *Client) GetS3BucketsBySvmUuid(uuid string) (users S3BucketsResponse, er
 }
 
 func (c *Client) CreateS3Bucket(uuid string, jsonPayload []byte) (err error) {
+	job, err := c.StartS3BucketCreation(uuid, jsonPayload)
+	if err != nil || job == "" {
+		return err
+	}
+
+	_, err = c.WaitForJob(job)
+	return err
+}
+
+// StartS3BucketCreation requests the bucket creation and returns its job uuid without waiting for it
+func (c *Client) StartS3BucketCreation(uuid string, jsonPayload []byte) (job string, err error) {
 	uri := "/api/protocols/s3/services/" + uuid + "/buckets"
 
 	data, err := c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return "", &apiError{1, err.Error()}
+	}
+
+	return jobUuid(data)
+}
+
+func (c *Client) PatchS3Bucket(uuid string, bucketUuid string, jsonPayload []byte) (err error) {
+	job, err := c.StartS3BucketPatch(uuid, bucketUuid, jsonPayload)
+	if err != nil || job == "" {
+		// an empty job completed synchronously
+		return err
+	}
+
+	_, err = c.WaitForJob(job)
+	return err
+}
+
+// StartS3BucketPatch requests the bucket update and returns its job uuid without waiting for it
+// the job uuid is empty when the update completed synchronously
+func (c *Client) StartS3BucketPatch(uuid string, bucketUuid string, jsonPayload []byte) (job string, err error) {
+	uri := "/api/protocols/s3/services/" + uuid + "/buckets/" + bucketUuid
+
+	data, err := c.clientPatch(uri, jsonPayload)
+	if err != nil {
+		return "", &apiError{1, err.Error()}
+	}
+
+	return jobUuid(data)
+}
+
+func (c *Client) DeleteS3Bucket(uuid string, bucketUuid string) (err error) {
+	uri := "/api/protocols/s3/services/" + uuid + "/buckets/" + bucketUuid
+
+	_, err = c.clientDelete(uri)
 	if err != nil {
 		return &apiError{1, err.Error()}
 	}
 
-	var result JobResponse
-	json.Unmarshal(data, &result)
+	return nil
+}
 
-	url := result.Job.Selflink.Self.Href
+func (c *Client) GetS3GroupsBySvmUuid(uuid string) (groups S3GroupsResponse, err error) {
+	uri := "/api/protocols/s3/services/" + uuid + "/groups?fields=id,name,comment,users.name,policies.name"
 
-	createJob, _ := c.GetJob(url)
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return groups, &apiError{1, err.Error()}
+	}
 
-	for createJob.State == "running" {
-		time.Sleep(time.Second * 2)
-		createJob, _ = c.GetJob(url)
+	var resp S3GroupsResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
 	}
 
-	if createJob.State == "failure" {
-		return &apiError{int64(createJob.Code), createJob.Message}
+	return resp, nil
+}
+
+func (c *Client) CreateS3Group(uuid string, jsonPayload []byte) (err error) {
+	uri := "/api/protocols/s3/services/" + uuid + "/groups"
+
+	_, err = c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
 	}
 
-	if createJob.State == "success" {
-		//_, err = ParseUUID(createJob.Description, "/")
-		return err
+	return nil
+}
+
+func (c *Client) PatchS3Group(uuid string, id int, jsonPayload []byte) (err error) {
+	uri := "/api/protocols/s3/services/" + uuid + "/groups/" + strconv.Itoa(id)
+
+	_, err = c.clientPatch(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
 	}
 
 	return nil
 }
 
-func (c *Client) DeleteS3Bucket(uuid string, bucketUuid string) (err error) {
-	uri := "/api/protocols/s3/services/" + uuid + "/buckets/" + bucketUuid
+func (c *Client) DeleteS3Group(uuid string, id int) (err error) {
+	uri := "/api/protocols/s3/services/" + uuid + "/groups/" + strconv.Itoa(id)
+
+	_, err = c.clientDelete(uri)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) GetS3PoliciesBySvmUuid(uuid string) (policies S3PoliciesResponse, err error) {
+	uri := "/api/protocols/s3/services/" + uuid + "/policies?fields=name,comment,read-only,statements"
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return policies, &apiError{1, err.Error()}
+	}
+
+	var resp S3PoliciesResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return resp, &apiError{2, err.Error()}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) CreateS3Policy(uuid string, jsonPayload []byte) (err error) {
+	uri := "/api/protocols/s3/services/" + uuid + "/policies"
+
+	_, err = c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) PatchS3Policy(uuid string, name string, jsonPayload []byte) (err error) {
+	uri := "/api/protocols/s3/services/" + uuid + "/policies/" + url.PathEscape(name)
+
+	_, err = c.clientPatch(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	return nil
+}
+
+func (c *Client) DeleteS3Policy(uuid string, name string) (err error) {
+	uri := "/api/protocols/s3/services/" + uuid + "/policies/" + url.PathEscape(name)
 
 	_, err = c.clientDelete(uri)
 	if err != nil {
@@ -9,6 +9,9 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 type AdDomain struct {
@@ -315,81 +318,84 @@ func (c *Client) GetStorageVMByUUID(uuid string) (svm SvmByUUID, err error) {
 	return resp, nil
 }
 
-// Create SVM
-func (c *Client) CreateStorageVM(jsonPayload []byte) (uuid string, err error) {
-	uri := "/api/svm/svms"
-	r := ""
-	data, err := c.clientPost(uri, jsonPayload)
+// Return a SVM by name
+func (c *Client) GetStorageVMByName(name string) (svm SvmByUUID, err error) {
+	uri := "/api/svm/svms?fields=uuid,name,state,comment&name=" + name
+
+	data, err := c.clientGet(uri)
 	if err != nil {
-		//fmt.Println("Error: " + err.Error())
-		return r, &apiError{1, err.Error()}
+		return svm, &apiError{1, err.Error()}
 	}
 
-	var result JobResponse
-	json.Unmarshal(data, &result)
-
-	url := result.Job.Selflink.Self.Href
-
-	createJob, _ := c.GetJob(url)
-
-	for createJob.State == "running" {
-		time.Sleep(time.Second * 2)
-		createJob, _ = c.GetJob(url)
+	var resp struct {
+		BaseResponse
+		Records []SvmByUUID `json:"records,omitempty"`
 	}
-
-	if createJob.State == "failure" {
-		return r, &apiError{int64(createJob.Code), createJob.Message}
-		//return fmt.Errorf("%d - %s", createJob.Code, createJob.Message)
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return svm, &apiError{2, err.Error()}
 	}
 
-	if createJob.State == "success" {
-		uuid, err = ParseUUID(createJob.Description, "/")
-		return uuid, err
+	for _, val := range resp.Records {
+		if val.Name == name {
+			return val, nil
+		}
 	}
 
-	return r, nil
+	return svm, errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"}, "no SVM named "+name)
 }
 
-func (c *Client) PatchStorageVM(uuid string, jsonPayload []byte) (err error) {
-	uri := "/api/svm/svms/" + uuid
-
-	data, err := c.clientPatch(uri, jsonPayload)
+// Create SVM
+func (c *Client) CreateStorageVM(jsonPayload []byte) (uuid string, err error) {
+	job, err := c.StartStorageVMCreation(jsonPayload)
 	if err != nil {
-		if strings.Contains(err.Error(), "Error-4") {
-			return &apiError{4, fmt.Sprintf("SVM with UUID \"%s\" not found", uuid)}
-		}
-		return &apiError{1, err.Error()}
+		return "", err
 	}
 
-	var result map[string]interface{}
-	err = json.Unmarshal(data, &result)
+	createJob, err := c.WaitForJob(job)
 	if err != nil {
-		return &apiError{2, err.Error()}
+		return "", err
 	}
 
-	job := result["job"].(map[string]interface{})
-	link := job["_links"].(map[string]interface{})
-	href := link["self"].(map[string]interface{})
-	url := href["href"].(string)
-
-	patchJob, _ := c.GetJob(url)
+	return ParseUUID(createJob.Description, "/")
+}
 
-	for patchJob.State == "running" {
-		time.Sleep(time.Second)
-		patchJob, _ = c.GetJob(url)
+// StartStorageVMCreation requests the SVM creation and returns its job uuid without waiting for it
+func (c *Client) StartStorageVMCreation(jsonPayload []byte) (job string, err error) {
+	uri := "/api/svm/svms"
+	data, err := c.clientPost(uri, jsonPayload)
+	if err != nil {
+		//fmt.Println("Error: " + err.Error())
+		return "", &apiError{1, err.Error()}
 	}
 
-	if patchJob.State == "failure" {
-		return &apiError{int64(patchJob.Code), patchJob.Message}
+	return jobUuid(data)
+}
+
+func (c *Client) PatchStorageVM(uuid string, jsonPayload []byte) (err error) {
+	job, err := c.StartStorageVMPatch(uuid, jsonPayload)
+	if err != nil || job == "" {
+		return err
 	}
 
-	if patchJob.State == "success" {
-		//uuid, err = ParseUUID(patchJob.Description, " ")
-		//return uuid, err
-		return nil
+	_, err = c.WaitForJob(job)
+	return err
+}
+
+// StartStorageVMPatch requests the SVM update and returns its job uuid without waiting for it
+// the job uuid is empty when the update completed synchronously
+func (c *Client) StartStorageVMPatch(uuid string, jsonPayload []byte) (job string, err error) {
+	uri := "/api/svm/svms/" + uuid
+
+	data, err := c.clientPatch(uri, jsonPayload)
+	if err != nil {
+		if strings.Contains(err.Error(), "Error-4") {
+			return "", &apiError{4, fmt.Sprintf("SVM with UUID \"%s\" not found", uuid)}
+		}
+		return "", &apiError{1, err.Error()}
 	}
 
-	return nil
+	return jobUuid(data)
 }
 
 func (c *Client) DeleteStorageVM(uuid string) (err error) {
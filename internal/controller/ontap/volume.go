@@ -0,0 +1,98 @@
+package ontap
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type Volume struct {
+	Uuid       string     `json:"uuid,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Svm        SvmRef     `json:"svm,omitempty"`
+	Size       int        `json:"size,omitempty"`
+	Comment    string     `json:"comment,omitempty"`
+	Aggregates []Resource `json:"aggregates,omitempty"`
+	Nas        VolumeNas  `json:"nas,omitempty"`
+}
+
+type VolumeNas struct {
+	Path          string          `json:"path,omitempty"`
+	SecurityStyle string          `json:"security_style,omitempty"`
+	ExportPolicy  ExportPolicyRef `json:"export_policy,omitempty"`
+}
+
+type ExportPolicyRef struct {
+	Name string `json:"name,omitempty"`
+	Id   int    `json:"id,omitempty"`
+}
+
+type VolumesResponse struct {
+	BaseResponse
+	Records []Volume `json:"records,omitempty"`
+}
+
+const returnVolumeRecords string = "?return_timeout=120&max_records=40&fields=uuid,name,svm,nas.path,nas.export_policy"
+
+func (c *Client) GetVolumeByName(svmUuid string, name string) (volume Volume, err error) {
+	uri := "/api/storage/volumes" + returnVolumeRecords + "&svm.uuid=" + svmUuid + "&name=" + name
+
+	data, err := c.clientGet(uri)
+	if err != nil {
+		return volume, &apiError{1, err.Error()}
+	}
+
+	var resp VolumesResponse
+	err = json.Unmarshal(data, &resp)
+	if err != nil {
+		return volume, &apiError{2, err.Error()}
+	}
+
+	if resp.NumRecords == 0 {
+		return volume, errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"}, "no volume")
+	}
+
+	return resp.Records[0], nil
+}
+
+func (c *Client) CreateVolume(jsonPayload []byte) (err error) {
+	job, err := c.StartVolumeCreation(jsonPayload)
+	if err != nil || job == "" {
+		// an empty job completed synchronously
+		return err
+	}
+
+	_, err = c.WaitForJob(job)
+	return err
+}
+
+// StartVolumeCreation requests the volume creation and returns its job uuid without waiting for it
+func (c *Client) StartVolumeCreation(jsonPayload []byte) (job string, err error) {
+	uri := "/api/storage/volumes"
+
+	data, err := c.clientPost(uri, jsonPayload)
+	if err != nil {
+		return "", &apiError{1, err.Error()}
+	}
+
+	return jobUuid(data)
+}
+
+func (c *Client) PatchVolume(uuid string, jsonPayload []byte) (err error) {
+	uri := "/api/storage/volumes/" + uuid
+
+	data, err := c.clientPatch(uri, jsonPayload)
+	if err != nil {
+		return &apiError{1, err.Error()}
+	}
+
+	job, err := jobUuid(data)
+	if err != nil || job == "" {
+		// an empty job completed synchronously
+		return err
+	}
+
+	_, err = c.WaitForJob(job)
+	return err
+}
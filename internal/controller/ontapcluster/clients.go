@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/config"
+	"gateway/internal/controller/ontap"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const caCertificateKey = "ca.crt" //magic word
+
+// Clients keeps one ONTAP client per OntapCluster so the StorageVirtualMachines on the cluster
+// share its connection settings, rate limiter and active endpoint instead of building a client on every reconcile
+type Clients struct {
+	mu      sync.Mutex
+	entries map[types.NamespacedName]*clientEntry
+}
+
+type clientEntry struct {
+	// fingerprint changes with the OntapCluster's spec and secrets
+	fingerprint string
+	client      ontap.Client
+}
+
+func NewClients() *Clients {
+	return &Clients{entries: make(map[types.NamespacedName]*clientEntry)}
+}
+
+// Client returns a client of the cluster authenticated with the credentials secret
+// the client is built again when the OntapCluster's spec or its secrets changed
+// debug enables the request logging of the returned copy only
+func (cs *Clients) Client(ctx context.Context, c client.Reader, cluster *gateway.OntapCluster,
+	credentials *corev1.Secret, cfg *config.OperatorConfiguration, debug bool) (*ontap.Client, error) {
+
+	var caSecret *corev1.Secret
+	if cluster.Spec.Tls != nil && cluster.Spec.Tls.CaSecret != nil {
+		caSecret = &corev1.Secret{}
+		err := c.Get(ctx, secretKey(cluster, *cluster.Spec.Tls.CaSecret), caSecret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fingerprint := fmt.Sprintf("%d/%s", cluster.Generation, credentials.ResourceVersion)
+	if caSecret != nil {
+		fingerprint += "/" + caSecret.ResourceVersion
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	key := types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name}
+	entry := cs.entries[key]
+	if entry == nil || entry.fingerprint != fingerprint {
+		oc, err := newClient(cluster, credentials, caSecret, cfg)
+		if err != nil {
+			return nil, err
+		}
+		entry = &clientEntry{fingerprint: fingerprint, client: *oc}
+		cs.entries[key] = entry
+	}
+
+	oc := entry.client
+	oc.Debug = oc.Debug || debug
+	return &oc, nil
+}
+
+// Forget drops the client of a deleted OntapCluster
+func (cs *Clients) Forget(key types.NamespacedName) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	delete(cs.entries, key)
+}
+
+// ClientFor returns a client of the StorageVirtualMachine's cluster, from the referenced OntapCluster
+// or the deprecated clusterHost and clusterCredentials
+func (cs *Clients) ClientFor(ctx context.Context, c client.Reader, svmCR *gateway.StorageVirtualMachine,
+	cfg *config.OperatorConfiguration) (*ontap.Client, error) {
+
+	cluster, err := Lookup(ctx, c, svmCR)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := &corev1.Secret{}
+	if cluster != nil {
+		err = c.Get(ctx, CredentialsKey(cluster), credentials)
+		if err != nil {
+			return nil, err
+		}
+		return cs.Client(ctx, c, cluster, credentials, cfg, svmCR.Spec.SvmDebug)
+	}
+
+	namespace := svmCR.Spec.ClusterCredentialSecret.Namespace
+	if namespace == "" {
+		namespace = svmCR.Namespace
+	}
+	err = c.Get(ctx, types.NamespacedName{Name: svmCR.Spec.ClusterCredentialSecret.Name, Namespace: namespace}, credentials)
+	if err != nil {
+		return nil, err
+	}
+	oc, err := ontap.NewClient(
+		string(credentials.Data["username"]),
+		string(credentials.Data["password"]),
+		ManagementHost(svmCR.Spec.ClusterManagementHost), svmCR.Spec.SvmDebug || cfg.Ontap.Debug, cfg.Ontap.TrustSSL)
+	if err != nil {
+		return nil, err
+	}
+	oc.TimeOut = cfg.Ontap.Timeout.Duration
+	oc.JobTimeout = cfg.Ontap.JobTimeout.Duration
+	return oc, nil
+}
+
+// Lookup returns the OntapCluster referenced by the StorageVirtualMachine
+// or nil when it uses the deprecated clusterHost
+func Lookup(ctx context.Context, c client.Reader, svmCR *gateway.StorageVirtualMachine) (*gateway.OntapCluster, error) {
+	if svmCR.Spec.ClusterRef == nil {
+		return nil, nil
+	}
+	namespace := svmCR.Spec.ClusterRef.Namespace
+	if namespace == "" {
+		namespace = svmCR.Namespace
+	}
+	cluster := &gateway.OntapCluster{}
+	err := c.Get(ctx, types.NamespacedName{Name: svmCR.Spec.ClusterRef.Name, Namespace: namespace}, cluster)
+	if err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// References reports whether the StorageVirtualMachine references the OntapCluster
+func References(svmCR *gateway.StorageVirtualMachine, cluster client.Object) bool {
+	if svmCR.Spec.ClusterRef == nil || svmCR.Spec.ClusterRef.Name != cluster.GetName() {
+		return false
+	}
+	namespace := svmCR.Spec.ClusterRef.Namespace
+	if namespace == "" {
+		namespace = svmCR.Namespace
+	}
+	return namespace == cluster.GetNamespace()
+}
+
+// CredentialsKey returns the OntapCluster's credentials secret
+func CredentialsKey(cluster *gateway.OntapCluster) types.NamespacedName {
+	return secretKey(cluster, cluster.Spec.Credentials)
+}
+
+// ReferencesSecret reports whether the OntapCluster uses the secret as its credentials or CA certificate
+func ReferencesSecret(cluster *gateway.OntapCluster, secret client.Object) bool {
+	key := types.NamespacedName{Namespace: secret.GetNamespace(), Name: secret.GetName()}
+	if CredentialsKey(cluster) == key {
+		return true
+	}
+	return cluster.Spec.Tls != nil && cluster.Spec.Tls.CaSecret != nil && secretKey(cluster, *cluster.Spec.Tls.CaSecret) == key
+}
+
+// secretKey resolves a secret reference - the namespace defaults to the OntapCluster's
+func secretKey(cluster *gateway.OntapCluster, ref gateway.NamespacedName) types.NamespacedName {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = cluster.Namespace
+	}
+	return types.NamespacedName{Name: ref.Name, Namespace: namespace}
+}
+
+// ManagementHost returns the host of a management address given as an IP address, host name or URL
+func ManagementHost(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	clusterUrl, err := url.Parse(host)
+	if err == nil && clusterUrl.Host != "" {
+		return clusterUrl.Host
+	}
+	return host
+}
+
+// newClient builds a client of the cluster
+func newClient(cluster *gateway.OntapCluster, credentials *corev1.Secret, caSecret *corev1.Secret,
+	cfg *config.OperatorConfiguration) (*ontap.Client, error) {
+
+	username := strings.TrimSpace(string(credentials.Data["username"]))
+	password := strings.TrimSpace(string(credentials.Data["password"]))
+	if username == "" || password == "" {
+		return nil, errors.NewBadRequest("credentials secret " + credentials.Name + " has no username or password")
+	}
+
+	trustSSL := cfg.Ontap.TrustSSL
+	if cluster.Spec.Tls != nil && cluster.Spec.Tls.InsecureSkipVerify != nil {
+		trustSSL = *cluster.Spec.Tls.InsecureSkipVerify
+	}
+
+	oc, err := ontap.NewClient(username, password, ManagementHost(cluster.Spec.ManagementHost), cfg.Ontap.Debug, trustSSL)
+	if err != nil {
+		return nil, err
+	}
+	oc.TimeOut = cfg.Ontap.Timeout.Duration
+	oc.JobTimeout = cfg.Ontap.JobTimeout.Duration
+
+	// the management host comes first - requests fail over to the other endpoints
+	hosts := []string{oc.Host}
+	for _, val := range cluster.Spec.Endpoints {
+		hosts = append(hosts, ManagementHost(val))
+	}
+	oc.Endpoints = ontap.NewEndpoints(hosts...)
+
+	if caSecret != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caSecret.Data[caCertificateKey]) {
+			return nil, errors.NewBadRequest("CA secret " + caSecret.Name + " has no PEM certificate in " + caCertificateKey)
+		}
+		oc.RootCAs = pool
+	}
+
+	if cluster.Spec.RateLimit != nil {
+		burst := cluster.Spec.RateLimit.Burst
+		if burst < 1 {
+			burst = cluster.Spec.RateLimit.RequestsPerSecond
+		}
+		oc.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(float32(cluster.Spec.RateLimit.RequestsPerSecond), burst)
+	}
+	return oc, nil
+}
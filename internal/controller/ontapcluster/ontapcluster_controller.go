@@ -0,0 +1,288 @@
+package controller
+
+import (
+	"context"
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/config"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Connected condition of an OntapCluster
+const CONDITION_TYPE_CONNECTED = "Connected"
+const CONDITION_REASON_CONNECTED = "ClusterReachable"
+const CONDITION_REASON_CREDENTIALS = "CredentialsNotAvailable"
+const CONDITION_REASON_UNREACHABLE = "ClusterUnreachable"
+const CONDITION_REASON_IN_USE = "ClusterInUse"
+
+// finalizerName keeps an OntapCluster while StorageVirtualMachines reference it
+// so they can still reach the cluster to finish their own deletion
+const finalizerName = "gateway.netapp.com/ontapcluster-finalizer" //magic word
+
+// OntapClusterReconciler checks the connection to an ONTAP cluster and reports its nodes and aggregates
+type OntapClusterReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Config is the operator configuration - nil uses the defaults
+	Config *config.OperatorConfiguration
+	// Clients is shared with the StorageVirtualMachine and COSI reconcilers
+	Clients *Clients
+}
+
+//+kubebuilder:rbac:groups=gateway.netapp.com,resources=ontapclusters,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=gateway.netapp.com,resources=ontapclusters/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=gateway.netapp.com,resources=ontapclusters/finalizers,verbs=update
+//+kubebuilder:rbac:groups=gateway.netapp.com,resources=storagevirtualmachines,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+// Reconcile connects to the cluster and records what it reports in the OntapCluster's status
+// the check is repeated after the drift check interval while connected and the poll interval while not
+func (r *OntapClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithValues("Request.Namespace", req.Namespace, "Request.Name", req.Name)
+
+	cluster := &gateway.OntapCluster{}
+	err := r.Get(ctx, req.NamespacedName, cluster)
+	if err != nil && errors.IsNotFound(err) {
+		log.Info("OntapCluster deleted - forgetting its client")
+		r.Clients.Forget(req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if cluster.GetDeletionTimestamp() != nil {
+		return r.reconcileDeletion(ctx, cluster, log)
+	}
+	if !controllerutil.ContainsFinalizer(cluster, finalizerName) {
+		controllerutil.AddFinalizer(cluster, finalizerName)
+		err = r.Update(ctx, cluster)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	credentials := &corev1.Secret{}
+	err = r.Get(ctx, CredentialsKey(cluster), credentials)
+	if err != nil {
+		log.Error(err, "Error getting the credentials secret of the cluster")
+		return r.disconnected(ctx, cluster, CONDITION_REASON_CREDENTIALS, err)
+	}
+
+	oc, err := r.Clients.Client(ctx, r.Client, cluster, credentials, r.Config, false)
+	if err != nil {
+		log.Error(err, "Error creating ONTAP client")
+		return r.disconnected(ctx, cluster, CONDITION_REASON_CREDENTIALS, err)
+	}
+
+	// check every endpoint and move off an unhealthy active one before asking the cluster
+	cluster.Status.Endpoints = nil
+	for _, val := range oc.CheckEndpoints() {
+		cluster.Status.Endpoints = append(cluster.Status.Endpoints, gateway.OntapClusterEndpoint{
+			Host: val.Host, Healthy: val.Healthy, Error: val.Error})
+		if !val.Healthy {
+			log.Info("Management endpoint " + val.Host + " unhealthy: " + val.Error)
+		}
+	}
+	cluster.Status.ActiveEndpoint = oc.ActiveHost()
+
+	ontapCluster, err := oc.GetCluster()
+	if err != nil {
+		log.Error(err, "Error retrieving cluster: "+oc.Host)
+		return r.disconnected(ctx, cluster, CONDITION_REASON_UNREACHABLE, err)
+	}
+	nodes, err := oc.GetNodes()
+	if err != nil {
+		log.Error(err, "Error retrieving nodes of cluster: "+oc.Host)
+		return r.disconnected(ctx, cluster, CONDITION_REASON_UNREACHABLE, err)
+	}
+	aggregates, err := oc.GetAggregates()
+	if err != nil {
+		log.Error(err, "Error retrieving aggregates of cluster: "+oc.Host)
+		return r.disconnected(ctx, cluster, CONDITION_REASON_UNREACHABLE, err)
+	}
+
+	now := metav1.Now()
+	cluster.Status.Connected = true
+	cluster.Status.LastChecked = &now
+	cluster.Status.ClusterName = ontapCluster.Name
+	cluster.Status.ClusterUuid = ontapCluster.UUID
+	version := ontapCluster.OntapVersion()
+	oc.SetClusterVersion(version)
+	cluster.Status.Version = version.String()
+	cluster.Status.Features = nil
+	for _, val := range oc.Capabilities().Features() {
+		cluster.Status.Features = append(cluster.Status.Features, string(val))
+	}
+	cluster.Status.Nodes = nil
+	for _, val := range nodes.Records {
+		cluster.Status.Nodes = append(cluster.Status.Nodes, gateway.OntapClusterNode{
+			Name: val.Name, Uuid: val.Uuid, Model: val.Model, State: val.State})
+	}
+	cluster.Status.Aggregates = nil
+	for _, val := range aggregates.Records {
+		cluster.Status.Aggregates = append(cluster.Status.Aggregates, gateway.OntapClusterAggregate{
+			Name: val.Name, Uuid: val.Uuid, Node: val.Node.Name, State: val.State,
+			Available: val.Space.BlockStorage.Available})
+	}
+	cluster.Status.ActiveEndpoint = oc.ActiveHost()
+	meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+		Type:               CONDITION_TYPE_CONNECTED,
+		Status:             metav1.ConditionTrue,
+		Reason:             CONDITION_REASON_CONNECTED,
+		Message:            "Connected to cluster " + ontapCluster.Name + " running ONTAP " + version.String(),
+		ObservedGeneration: cluster.Generation,
+	})
+	err = r.Status().Update(ctx, cluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	log.Info("Connected to cluster: " + oc.Host + " through " + oc.ActiveHost() + " reporting ONTAP version: " + ontapCluster.Version.Full)
+	return ctrl.Result{RequeueAfter: r.operatorConfig().Requeue.DriftCheckInterval.Duration}, nil
+}
+
+// reconcileDeletion removes the finalizer once no StorageVirtualMachine references the OntapCluster
+// until then the deletion waits and is checked again when a referencing custom resource is deleted
+func (r *OntapClusterReconciler) reconcileDeletion(ctx context.Context, cluster *gateway.OntapCluster,
+	log logr.Logger) (ctrl.Result, error) {
+
+	if !controllerutil.ContainsFinalizer(cluster, finalizerName) {
+		return ctrl.Result{}, nil
+	}
+
+	var svmList gateway.StorageVirtualMachineList
+	err := r.List(ctx, &svmList)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	var referencing []string
+	for _, svm := range svmList.Items {
+		if References(&svm, cluster) {
+			referencing = append(referencing, svm.Namespace+"/"+svm.Name)
+		}
+	}
+	if len(referencing) > 0 {
+		message := "Deletion waits for the StorageVirtualMachines referencing the cluster: " + strings.Join(referencing, ", ")
+		log.Info(message)
+		r.Recorder.Event(cluster, "Warning", CONDITION_REASON_IN_USE, message)
+		return ctrl.Result{RequeueAfter: r.operatorConfig().Requeue.PollInterval.Duration}, nil
+	}
+
+	log.Info("OntapCluster no longer referenced - removing finalizer and forgetting its client")
+	r.Clients.Forget(types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name})
+	controllerutil.RemoveFinalizer(cluster, finalizerName)
+	return ctrl.Result{}, r.Update(ctx, cluster)
+}
+
+// disconnected records the failed check and comes back after the poll interval
+func (r *OntapClusterReconciler) disconnected(ctx context.Context, cluster *gateway.OntapCluster,
+	reason string, cause error) (ctrl.Result, error) {
+
+	r.Recorder.Event(cluster, "Warning", reason, cause.Error())
+
+	now := metav1.Now()
+	cluster.Status.Connected = false
+	cluster.Status.LastChecked = &now
+	meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+		Type:               CONDITION_TYPE_CONNECTED,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            cause.Error(),
+		ObservedGeneration: cluster.Generation,
+	})
+	err := r.Status().Update(ctx, cluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.operatorConfig().Requeue.PollInterval.Duration}, nil
+}
+
+// operatorConfig returns the operator configuration or the defaults
+func (r *OntapClusterReconciler) operatorConfig() *config.OperatorConfiguration {
+	if r.Config == nil {
+		return config.Default()
+	}
+	return r.Config
+}
+
+// secretToOntapClusters enqueues the OntapClusters using the secret as their credentials or CA certificate
+func (r *OntapClusterReconciler) secretToOntapClusters(ctx context.Context, obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+
+	var clusterList gateway.OntapClusterList
+	err := r.List(ctx, &clusterList)
+	if err != nil {
+		return requests
+	}
+	for _, cluster := range clusterList.Items {
+		if ReferencesSecret(&cluster, obj) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: cluster.Namespace,
+				Name:      cluster.Name,
+			}})
+		}
+	}
+	return requests
+}
+
+// storageVirtualMachineToOntapCluster enqueues the OntapCluster referenced by a deleted StorageVirtualMachine
+// so a deletion waiting for it completes
+func (r *OntapClusterReconciler) storageVirtualMachineToOntapCluster(ctx context.Context, obj client.Object) []reconcile.Request {
+	svm, ok := obj.(*gateway.StorageVirtualMachine)
+	if !ok || svm.Spec.ClusterRef == nil {
+		return nil
+	}
+	namespace := svm.Spec.ClusterRef.Namespace
+	if namespace == "" {
+		namespace = svm.Namespace
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: svm.Spec.ClusterRef.Name}}}
+}
+
+// storageVirtualMachineDeletedPredicate only lets through deleted StorageVirtualMachines
+var storageVirtualMachineDeletedPredicate = predicate.Funcs{
+	CreateFunc:  func(e event.CreateEvent) bool { return false },
+	UpdateFunc:  func(e event.UpdateEvent) bool { return false },
+	DeleteFunc:  func(e event.DeleteEvent) bool { return true },
+	GenericFunc: func(e event.GenericEvent) bool { return false },
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *OntapClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Config == nil {
+		r.Config = config.Default()
+	}
+	if r.Clients == nil {
+		r.Clients = NewClients()
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&gateway.OntapCluster{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.secretToOntapClusters),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(&gateway.StorageVirtualMachine{}, handler.EnqueueRequestsFromMapFunc(r.storageVirtualMachineToOntapCluster),
+			builder.WithPredicates(storageVirtualMachineDeletedPredicate)).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.operatorConfig().Manager.MaxConcurrentReconciles,
+		}).
+		Complete(r)
+}
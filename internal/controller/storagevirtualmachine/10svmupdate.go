@@ -59,7 +59,9 @@ func (r *StorageVirtualMachineReconciler) reconcileSvmUpdate(ctx context.Context
 
 	// After building update string execute it and check for errors
 	log.Info("SVM update attempt for SVM: " + svmRetrieved.Uuid)
-	err = oc.PatchStorageVM(svmRetrieved.Uuid, jsonPayload)
+	_, err = r.runJob(ctx, svmCR, JobOperationSvmPatch, svmCR.Spec.SvmName, func() (string, error) {
+		return oc.StartStorageVMPatch(svmRetrieved.Uuid, jsonPayload)
+	}, oc, log)
 	if err != nil {
 		log.Error(err, "Error occurred when updating SVM ")
 		_ = r.setConditionSVMUpdate(ctx, svmCR, CONDITION_STATUS_FALSE)
@@ -100,7 +100,7 @@ func (r *StorageVirtualMachineReconciler) reconcileManagementLifUpdate(ctx conte
 		upsertManagementLif.Ip.Netmask = svmCR.Spec.ManagementLIF.Netmask
 		upsertManagementLif.Location.BroadcastDomain.Name = svmCR.Spec.ManagementLIF.BroadcastDomain
 		upsertManagementLif.Location.HomeNode.Name = svmCR.Spec.ManagementLIF.HomeNode
-		upsertManagementLif.ServicePolicy.Name = managementLIFServicePolicy
+		upsertManagementLif.ServicePolicy.Name = r.operatorConfig().ServicePolicies.Management
 		upsertManagementLif.Scope = svmScope
 		upsertManagementLif.Svm.Uuid = uuid
 	}
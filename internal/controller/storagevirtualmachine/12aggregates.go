@@ -53,7 +53,9 @@ func (r *StorageVirtualMachineReconciler) reconcileAggregates(ctx context.Contex
 
 			// After building update string execute it and check for errors
 			log.Info("SVM aggregates update attempt for SVM: " + svmRetrieved.Uuid)
-			err = oc.PatchStorageVM(svmRetrieved.Uuid, jsonPayload)
+			_, err = r.runJob(ctx, svmCR, JobOperationSvmPatch, svmCR.Spec.SvmName, func() (string, error) {
+				return oc.StartStorageVMPatch(svmRetrieved.Uuid, jsonPayload)
+			}, oc, log)
 			if err != nil {
 				log.Error(err, "Error occurred when updating SVM aggregates - requeuing")
 				_ = r.setConditionAggregateAssigned(ctx, svmCR, CONDITION_STATUS_FALSE)
@@ -7,14 +7,17 @@ import (
 	gateway "gateway/api/v1beta3"
 	"gateway/internal/controller/ontap"
 	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
-const NfsLifServicePolicy = "default-data-files" //magic word
-const NfsLifServicePolicyScope = "svm"           //magic word
+const NfsLifServicePolicyScope = "svm"   //magic word
+const NfsDefaultExportPolicy = "default" //magic word
 
 func (r *StorageVirtualMachineReconciler) reconcileNfsUpdate(ctx context.Context,
 	svmCR *gateway.StorageVirtualMachine, uuid string, oc *ontap.Client, log logr.Logger) error {
@@ -51,7 +54,8 @@ func (r *StorageVirtualMachineReconciler) reconcileNfsUpdate(ctx context.Context
 		upsertNfsService.Protocol.V3Enable = &svmCR.Spec.NfsConfig.Nfsv3
 		upsertNfsService.Protocol.V4Enable = &svmCR.Spec.NfsConfig.Nfsv4
 		upsertNfsService.Protocol.V41Enable = &svmCR.Spec.NfsConfig.Nfsv41
-		upsertNfsService.Svm.Uuid = svmCR.Spec.SvmUuid
+		upsertNfsService.Svm.Uuid = svmCR.GetSvmUuid()
+		_ = nfsServiceTuning(svmCR.Spec.NfsConfig, ontap.NFSService{}, &upsertNfsService)
 
 		jsonPayload, err := json.Marshal(upsertNfsService)
 		if err != nil {
@@ -98,6 +102,10 @@ func (r *StorageVirtualMachineReconciler) reconcileNfsUpdate(ctx context.Context
 			upsertNfsService.Protocol.V41Enable = &svmCR.Spec.NfsConfig.Nfsv41
 		}
 
+		if nfsServiceTuning(svmCR.Spec.NfsConfig, nfsService, &upsertNfsService) {
+			updateNfsService = true
+		}
+
 		if oc.Debug && updateNfsService {
 			log.Info("[DEBUG] NFS service update payload: " + fmt.Sprintf("%#v\n", upsertNfsService))
 		}
@@ -159,7 +167,7 @@ func (r *StorageVirtualMachineReconciler) reconcileNfsUpdate(ctx context.Context
 		if createNfsLifs {
 			//creating lifs
 			for _, val := range svmCR.Spec.NfsConfig.Lifs {
-				err = CreateLif(val, NfsLifServicePolicy, NfsLifServicePolicyScope, uuid, oc, log)
+				err = CreateLif(val, r.operatorConfig().ServicePolicies.Nfs, NfsLifServicePolicyScope, uuid, oc, log)
 				if err != nil {
 					_ = r.setConditionNfsLif(ctx, svmCR, CONDITION_STATUS_FALSE)
 					return err
@@ -173,7 +181,7 @@ func (r *StorageVirtualMachineReconciler) reconcileNfsUpdate(ctx context.Context
 				// Check to see if lifs.Records[index] is out of index - if so, need to create LIF
 				if index > lifs.NumRecords-1 {
 					// Need to create LIF for val
-					err = CreateLif(val, NfsLifServicePolicy, NfsLifServicePolicyScope, uuid, oc, log)
+					err = CreateLif(val, r.operatorConfig().ServicePolicies.Nfs, NfsLifServicePolicyScope, uuid, oc, log)
 					if err != nil {
 						_ = r.setConditionNfsLif(ctx, svmCR, CONDITION_STATUS_FALSE)
 						r.Recorder.Event(svmCR, "Warning", "NfsCreationLifFailed", "Error: "+err.Error())
@@ -186,7 +194,7 @@ func (r *StorageVirtualMachineReconciler) reconcileNfsUpdate(ctx context.Context
 						break
 					}
 
-					err = UpdateLif(val, lifs.Records[index], NfsLifServicePolicy, oc, log)
+					err = UpdateLif(val, lifs.Records[index], r.operatorConfig().ServicePolicies.Nfs, oc, log)
 					if err != nil {
 						_ = r.setConditionNfsLif(ctx, svmCR, CONDITION_STATUS_FALSE)
 						r.Recorder.Event(svmCR, "Warning", "NfsUpdateLifFailed", "Error: "+err.Error())
@@ -227,168 +235,460 @@ func (r *StorageVirtualMachineReconciler) reconcileNfsUpdate(ctx context.Context
 
 	// END NFS LIFS
 
+	// NFS KERBEROS
+
+	if svmCR.Spec.NfsConfig.Kerberos == nil {
+		log.Info("No NFS Kerberos interfaces defined - skipping updates")
+	} else {
+		err = r.reconcileNfsKerberos(ctx, svmCR, uuid, oc, log)
+		if err != nil {
+			_ = r.setConditionNfsKerberos(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "NfsUpdateKerberosFailed", "Error: "+err.Error())
+			return err
+		}
+		_ = r.setConditionNfsKerberos(ctx, svmCR, CONDITION_STATUS_TRUE)
+	}
+
+	// END NFS KERBEROS
+
 	// NFS EXPORTS
 
-	// Check to see if NFS rules are defined in custom resources
-	if svmCR.Spec.NfsConfig.Export == nil {
+	// Check to see if NFS export policies are defined in custom resources
+	exportPolicies := nfsExportPolicies(svmCR.Spec.NfsConfig)
+	if len(exportPolicies) == 0 {
 		// If none, exit with no error
 		log.Info("No NFS export rules defined - skipping")
 	} else {
-		createNfsExports := false
 
-		// Check to see if NFS interfaces defined and compare to custom resource's definitions
+		// Fill in rule clients derived from Kubernetes nodes
+		exportPolicies, err = r.resolveNodeClients(ctx, exportPolicies, log)
+		if err != nil {
+			log.Error(err, "Error resolving NFS export clients from nodes - requeuing")
+			_ = r.setConditionNfsExport(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "NfsNodeClientsFailed", "Error: "+err.Error())
+			return err
+		}
+
+		// Check to see if NFS export policies defined and compare to custom resource's definitions
 		exportRetrieved, err := oc.GetNfsExportBySvmUuid(uuid)
 		if err != nil {
-			//error creating the json body
 			log.Error(err, "Error getting NFS export rules for SVM: "+uuid+" - requeuing")
 			_ = r.setConditionNfsExport(ctx, svmCR, CONDITION_STATUS_FALSE)
 			return err
 		}
 
-		if exportRetrieved.NumRecords == 0 {
-			// No exports for the SVM provided in UUID
-			// create new export(s)
-			log.Info("No exports defined for SVM: " + uuid + " - creating NFS export(s)")
-			createNfsExports = true
-		}
+		for _, policy := range exportPolicies {
+			var existing *ontap.ExportPolicy
+			for i := range exportRetrieved.Records {
+				if exportRetrieved.Records[i].Name == policy.Name {
+					existing = &exportRetrieved.Records[i]
+					break
+				}
+			}
 
-		if createNfsExports {
-			// this will probably never happen because there is always a default export
-			// creating export
-			err = CreateNfsExport(*svmCR.Spec.NfsConfig.Export, uuid, oc, log)
+			if existing == nil {
+				log.Info("No export policy " + policy.Name + " defined for SVM: " + uuid + " - creating NFS export")
+				err = CreateNfsExport(policy, uuid, oc, log)
+				if err != nil {
+					_ = r.setConditionNfsExport(ctx, svmCR, CONDITION_STATUS_FALSE)
+					r.Recorder.Event(svmCR, "Warning", "NfsCreationExportFailed", "Error: "+err.Error())
+					return err
+				}
+				r.Recorder.Event(svmCR, "Normal", "NfsCreationExportSucceeded", "Created NFS export "+policy.Name+" successfully")
+			} else {
+				err = UpdateNfsExportRules(policy, existing.Id, oc, log)
+				if err != nil {
+					_ = r.setConditionNfsExport(ctx, svmCR, CONDITION_STATUS_FALSE)
+					r.Recorder.Event(svmCR, "Warning", "NfsUpdateExportFailed", "Error: "+err.Error())
+					return err
+				}
+			}
+
+			err = AssignNfsExport(policy, uuid, oc, log)
 			if err != nil {
 				_ = r.setConditionNfsExport(ctx, svmCR, CONDITION_STATUS_FALSE)
+				r.Recorder.Event(svmCR, "Warning", "NfsAssignExportFailed", "Error: "+err.Error())
 				return err
 			}
+		}
 
-		} else {
-
-			// if more than one export, delete anything after the first one
-			// never delete the first export
-			for i := 1; i < exportRetrieved.NumRecords; i++ {
-				log.Info("NFS export delete attempt: " + exportRetrieved.Records[i].Name)
-				err = oc.DeleteNfsExport(exportRetrieved.Records[i].Id)
-				if err != nil {
-					log.Error(err, "Error occurred when deleting NFS export: "+exportRetrieved.Records[i].Name+" - requeuing")
-					// no condition error
-					// don't requeue on failed delete request
-					// return err
-				} else {
-					log.Info("NFS export delete successful: " + exportRetrieved.Records[i].Name)
+		// Delete all export policies that are not defined in the custom resource
+		// never delete the default export policy
+		for _, val := range exportRetrieved.Records {
+			if val.Name == NfsDefaultExportPolicy {
+				continue
+			}
+			defined := false
+			for _, policy := range exportPolicies {
+				if policy.Name == val.Name {
+					defined = true
+					break
 				}
 			}
+			if defined {
+				continue
+			}
+			log.Info("NFS export delete attempt: " + val.Name)
+			err = oc.DeleteNfsExport(val.Id)
+			if err != nil {
+				log.Error(err, "Error occurred when deleting NFS export: "+val.Name)
+				// no condition error
+				// don't requeue on failed delete request
+			} else {
+				log.Info("NFS export delete successful: " + val.Name)
+			}
+		}
 
-			// check for updating export
-			updateNfsExports := false
+		_ = r.setConditionNfsExport(ctx, svmCR, CONDITION_STATUS_TRUE)
+		r.Recorder.Event(svmCR, "Normal", "NfsUpsertExportSucceeded", "Upserted NFS export(s) successfully")
+
+	} // NFS exports rules defined in custom resource
+
+	// END NFS EXPORTS
 
-			if exportRetrieved.Records[0].Name != svmCR.Spec.NfsConfig.Export.Name {
-				//need to update it
-				updateNfsExports = true
+	r.reportNfsStatus(ctx, svmCR, uuid, oc, log)
+
+	return nil
+}
+
+// nfsServiceTuning sets the optional service settings requested in the custom resource
+// that differ from the current service and reports whether any were set
+func nfsServiceTuning(nfsConfig *gateway.NfsSubSpec, current ontap.NFSService, upsert *ontap.NFSService) bool {
+	changed := false
+
+	if boolDiffers(current.Protocol.V42Enable, nfsConfig.Nfsv42) {
+		changed = true
+		upsert.Protocol.V42Enable = &nfsConfig.Nfsv42
+	}
+
+	if nfsConfig.V4IdDomain != "" && nfsConfig.V4IdDomain != current.Protocol.V4IdDomain {
+		changed = true
+		upsert.Protocol.V4IdDomain = nfsConfig.V4IdDomain
+	}
+
+	if nfsConfig.Showmount != nil && boolDiffers(current.ShowmountEnabled, *nfsConfig.Showmount) {
+		changed = true
+		upsert.ShowmountEnabled = nfsConfig.Showmount
+	}
+
+	if nfsConfig.Vstorage != nil && boolDiffers(current.VstorageEnabled, *nfsConfig.Vstorage) {
+		changed = true
+		upsert.VstorageEnabled = nfsConfig.Vstorage
+	}
+
+	if nfsConfig.FileId64bit != nil && (boolDiffers(current.Protocol.V3FileId64bit, *nfsConfig.FileId64bit) ||
+		boolDiffers(current.Protocol.V4FileId64bit, *nfsConfig.FileId64bit)) {
+		changed = true
+		upsert.Protocol.V3FileId64bit = nfsConfig.FileId64bit
+		upsert.Protocol.V4FileId64bit = nfsConfig.FileId64bit
+	}
+
+	if nfsConfig.TcpMaxTransferSize != nil && (current.Transport == nil || current.Transport.TcpMaxTransferSize == nil ||
+		*current.Transport.TcpMaxTransferSize != *nfsConfig.TcpMaxTransferSize) {
+		changed = true
+		upsert.Transport = &ontap.NFSTransport{TcpMaxTransferSize: nfsConfig.TcpMaxTransferSize}
+	}
+
+	if nfsConfig.QtreeExports != nil && (current.Qtree == nil || boolDiffers(current.Qtree.ExportEnabled, *nfsConfig.QtreeExports)) {
+		changed = true
+		upsert.Qtree = &ontap.NFSQtree{ExportEnabled: nfsConfig.QtreeExports}
+	}
+
+	return changed
+}
+
+// boolDiffers treats a setting ONTAP doesn't return as disabled
+func boolDiffers(current *bool, desired bool) bool {
+	if current == nil {
+		return desired
+	}
+	return *current != desired
+}
+
+func boolValue(val *bool) bool {
+	return val != nil && *val
+}
+
+func (r *StorageVirtualMachineReconciler) reconcileNfsKerberos(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, uuid string, oc *ontap.Client, log logr.Logger) error {
+
+	interfaces, err := oc.GetKerberosInterfacesBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting NFS Kerberos interfaces for SVM: "+uuid+" - requeuing")
+		return err
+	}
+
+	for _, val := range svmCR.Spec.NfsConfig.Kerberos {
+		var current *ontap.KerberosInterface
+		for i := range interfaces.Records {
+			if interfaces.Records[i].Interface.Name == val.Interface {
+				current = &interfaces.Records[i]
+				break
 			}
+		}
+		if current == nil {
+			return errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"},
+				"no NFS interface "+val.Interface+" for Kerberos")
+		}
 
-			for indx, val := range svmCR.Spec.NfsConfig.Export.Rules {
+		if !boolDiffers(current.Enabled, val.Enabled) && (!val.Enabled || current.Spn == val.Spn) {
+			continue
+		}
 
-				if len(exportRetrieved.Records[0].Rules) == 0 {
-					//nothing defined
-					updateNfsExports = true
-				} else {
-					if val.Anon != exportRetrieved.Records[0].Rules[indx].Anonuser {
-						updateNfsExports = true
-					}
+		var patch ontap.KerberosInterface
+		patch.Enabled = &val.Enabled
+		if val.Enabled {
+			patch.Spn = val.Spn
+		}
+		if val.Credentials != nil {
+			secret, err := r.getSecret(ctx, *val.Credentials, svmCR)
+			if err != nil {
+				log.Error(err, "Error getting NFS Kerberos credentials for interface: "+val.Interface)
+				return err
+			}
+			patch.User = string(secret.Data["username"])
+			patch.Password = string(secret.Data["password"])
+		}
 
-					if val.Clients != exportRetrieved.Records[0].Rules[indx].Clients[0].Match {
-						updateNfsExports = true
-					}
-					if val.Protocols != exportRetrieved.Records[0].Rules[indx].Protocols[0] {
-						updateNfsExports = true
-					}
+		jsonPayload, err := json.Marshal(patch)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for NFS Kerberos interface update - requeuing")
+			return err
+		}
 
-					if val.Ro != exportRetrieved.Records[0].Rules[indx].RoRule[0] {
-						updateNfsExports = true
-					}
-					if val.Rw != exportRetrieved.Records[0].Rules[indx].RwRule[0] {
-						updateNfsExports = true
-					}
-					if val.Superuser != exportRetrieved.Records[0].Rules[indx].Superuser[0] {
-						updateNfsExports = true
-					}
-				}
+		if oc.Debug {
+			log.Info("[DEBUG] NFS Kerberos interface update for: " + val.Interface + " enabled: " + strconv.FormatBool(val.Enabled))
+		}
+
+		log.Info("NFS Kerberos interface update attempt: " + val.Interface)
+		err = oc.PatchKerberosInterface(current.Interface.Uuid, jsonPayload)
+		if err != nil {
+			log.Error(err, "Error updating NFS Kerberos interface: "+val.Interface+" - requeuing")
+			return err
+		}
+		log.Info("NFS Kerberos interface update successful: " + val.Interface)
+	}
+
+	return nil
+}
+
+// reportNfsStatus reads back the effective NFS settings into the custom resource status
+// failures are logged only - the configuration itself has already succeeded
+func (r *StorageVirtualMachineReconciler) reportNfsStatus(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, uuid string, oc *ontap.Client, log logr.Logger) {
+
+	nfsService, err := oc.GetNfsServiceBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error retrieving NFS service for status")
+		return
+	}
 
+	var status gateway.NfsStatus
+	status.Enabled = boolValue(nfsService.Enabled)
+	status.Nfsv3 = boolValue(nfsService.Protocol.V3Enable)
+	status.Nfsv4 = boolValue(nfsService.Protocol.V4Enable)
+	status.Nfsv41 = boolValue(nfsService.Protocol.V41Enable)
+	status.Nfsv42 = boolValue(nfsService.Protocol.V42Enable)
+	status.V4IdDomain = nfsService.Protocol.V4IdDomain
+	status.Showmount = boolValue(nfsService.ShowmountEnabled)
+	status.Vstorage = boolValue(nfsService.VstorageEnabled)
+	status.FileId64bit = boolValue(nfsService.Protocol.V3FileId64bit) && boolValue(nfsService.Protocol.V4FileId64bit)
+	if nfsService.Transport != nil && nfsService.Transport.TcpMaxTransferSize != nil {
+		status.TcpMaxTransferSize = *nfsService.Transport.TcpMaxTransferSize
+	}
+	if nfsService.Qtree != nil {
+		status.QtreeExports = boolValue(nfsService.Qtree.ExportEnabled)
+	}
+
+	if svmCR.Spec.NfsConfig.Kerberos != nil {
+		interfaces, err := oc.GetKerberosInterfacesBySvmUuid(uuid)
+		if err != nil {
+			log.Error(err, "Error retrieving NFS Kerberos interfaces for status")
+		} else {
+			for _, val := range interfaces.Records {
+				status.Kerberos = append(status.Kerberos, gateway.NfsKerberosInterfaceStatus{
+					Interface: val.Interface.Name,
+					Enabled:   boolValue(val.Enabled),
+					Spn:       val.Spn,
+				})
 			}
+		}
+	}
 
-			if updateNfsExports {
-				// Build out complete export if there is any change
-				idToReplace := exportRetrieved.Records[0].Id // get the id
-				var newExport ontap.ExportPolicy
-
-				//NOT CHANGING NAME DURING UPDATE
-				//newExport.Name = svmCR.Spec.NfsConfig.Export.Name
-
-				for _, val := range svmCR.Spec.NfsConfig.Export.Rules {
-					var newRule ontap.ExportRule
-					newRule.Anonuser = val.Anon
-					newRule.Protocols = append(newRule.Protocols, val.Protocols)
-					newRule.RwRule = append(newRule.RwRule, val.Rw)
-					newRule.RoRule = append(newRule.RoRule, val.Ro)
-					newRule.Superuser = append(newRule.Superuser, val.Superuser)
-					var match ontap.ExportMatch
-					match.Match = val.Clients
-					newRule.Clients = append(newRule.Clients, match)
-					newExport.Rules = append(newExport.Rules, newRule)
-				}
+	if reflect.DeepEqual(svmCR.Status.Nfs, &status) {
+		return
+	}
+	svmCR.Status.Nfs = &status
+	_ = r.updateStatus(ctx, svmCR)
+}
 
-				// otherwise changes need to be implemented
-				if oc.Debug {
-					log.Info("[DEBUG] NFS export update payload: " + fmt.Sprintf("%#v\n", newExport))
-				}
+// nfsExportPolicies returns the export policies requested in the custom resource
+// the deprecated single export is applied to the SVM's default export policy
+func nfsExportPolicies(nfsConfig *gateway.NfsSubSpec) []gateway.NfsExportPolicy {
+	var policies []gateway.NfsExportPolicy
+
+	if nfsConfig.Export != nil {
+		legacy := gateway.NfsExportPolicy{Name: NfsDefaultExportPolicy}
+		for _, val := range nfsConfig.Export.Rules {
+			var rule gateway.NfsExportRule
+			rule.Clients = append(rule.Clients, gateway.NfsRuleClient{Match: val.Clients})
+			if val.Protocols != "" {
+				rule.Protocols = []gateway.NfsRuleProtocol{gateway.NfsRuleProtocol(val.Protocols)}
+			}
+			rule.Rw = authFlavors(val.Rw)
+			rule.Ro = authFlavors(val.Ro)
+			rule.Superuser = authFlavors(val.Superuser)
+			rule.Anon = val.Anon
+			legacy.Rules = append(legacy.Rules, rule)
+		}
+		policies = append(policies, legacy)
+	}
 
-				jsonPayload, err := json.Marshal(newExport)
-				if err != nil {
-					//error creating the json body
-					log.Error(err, "Error creating the json payload for NFS export update - requeuing")
-					_ = r.setConditionNfsExport(ctx, svmCR, CONDITION_STATUS_FALSE)
-					r.Recorder.Event(svmCR, "Warning", "NfsUpdateExportFailed", "Error: "+err.Error())
-					return err
-				}
+	for _, val := range nfsConfig.ExportPolicies {
+		if val.Name == NfsDefaultExportPolicy && nfsConfig.Export != nil {
+			// exportPolicies takes precedence over the deprecated export
+			policies[0] = val
+			continue
+		}
+		policies = append(policies, val)
+	}
 
-				err = oc.PatchNfsExport(idToReplace, jsonPayload)
-				if err != nil {
-					log.Error(err, "Error occurred when updating NFS export - requeuing")
-					_ = r.setConditionNfsExport(ctx, svmCR, CONDITION_STATUS_FALSE)
-					r.Recorder.Event(svmCR, "Warning", "NfsUpdateExportFailed", "Error: "+err.Error())
-					return err
-				}
-				log.Info("NFS export updated successful")
-				_ = r.setConditionNfsExport(ctx, svmCR, CONDITION_STATUS_TRUE)
-				r.Recorder.Event(svmCR, "Normal", "NfsUpdateExportSucceeded", "Updated NFS export(s) successfully")
-			} else {
-				log.Info("No NFS export rules changed detected - skipping")
+	return policies
+}
+
+// resolveNodeClients returns a copy of the export policies with the node addresses
+// added to the client matches of every rule using clientsFromNodes
+func (r *StorageVirtualMachineReconciler) resolveNodeClients(ctx context.Context,
+	policies []gateway.NfsExportPolicy, log logr.Logger) ([]gateway.NfsExportPolicy, error) {
+
+	var resolved []gateway.NfsExportPolicy
+	for _, policy := range policies {
+		policy = *policy.DeepCopy()
+		for indx, rule := range policy.Rules {
+			if rule.ClientsFromNodes == nil {
+				continue
+			}
+			nodes, err := r.listNodes(ctx, rule.ClientsFromNodes.NodeSelector)
+			if err != nil {
+				return nil, err
+			}
+			matches := NodeClientMatches(nodes, rule.ClientsFromNodes.PrefixLength)
+			if len(matches) == 0 && len(rule.Clients) == 0 {
+				return nil, errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"},
+					"no nodes match the clientsFromNodes selector of export "+policy.Name)
+			}
+			for _, match := range matches {
+				policy.Rules[indx].Clients = append(policy.Rules[indx].Clients, gateway.NfsRuleClient{Match: match})
 			}
+			log.Info("NFS export " + policy.Name + " rule " + strconv.Itoa(indx+1) + " node clients: " + strings.Join(matches, ","))
+		}
+		resolved = append(resolved, policy)
+	}
+	return resolved, nil
+}
 
-		} //Check for NFS export updates or deletion of none conforming exports
+func authFlavors(val string) []gateway.NfsRuleAuthFlavor {
+	if val == "" {
+		return nil
+	}
+	return []gateway.NfsRuleAuthFlavor{gateway.NfsRuleAuthFlavor(val)}
+}
 
-	} // NFS exports rules defined in custom resource
+func stringValues[T ~string](vals []T) []string {
+	var result []string
+	for _, val := range vals {
+		result = append(result, string(val))
+	}
+	return result
+}
 
-	// END NFS EXPORTS
+// ExportRuleFromSpec converts a custom resource rule to its ONTAP representation
+func ExportRuleFromSpec(rule gateway.NfsExportRule) ontap.ExportRule {
+	var newRule ontap.ExportRule
+	for _, val := range rule.Clients {
+		newRule.Clients = append(newRule.Clients, ontap.ExportMatch{Match: val.Match})
+	}
+	newRule.Protocols = stringValues(rule.Protocols)
+	newRule.RoRule = stringValues(rule.Ro)
+	if len(newRule.RoRule) == 0 {
+		newRule.RoRule = []string{"any"}
+	}
+	newRule.RwRule = stringValues(rule.Rw)
+	if len(newRule.RwRule) == 0 {
+		newRule.RwRule = []string{"never"}
+	}
+	newRule.Superuser = stringValues(rule.Superuser)
+	newRule.Anonuser = rule.Anon
+	newRule.ChownMode = rule.ChownMode
+	newRule.NtfsUnixSecurity = rule.NtfsUnixSecurity
+	newRule.AllowSuid = rule.AllowSuid
+	return newRule
+}
 
-	return nil
+// exportRuleChanged compares only the fields requested in the custom resource
+// ONTAP fills in defaults for everything else
+func exportRuleChanged(desired ontap.ExportRule, current ontap.ExportRule) bool {
+	var desiredClients, currentClients []string
+	for _, val := range desired.Clients {
+		desiredClients = append(desiredClients, val.Match)
+	}
+	for _, val := range current.Clients {
+		currentClients = append(currentClients, val.Match)
+	}
+	if !sameValues(desiredClients, currentClients) {
+		return true
+	}
+	if len(desired.Protocols) > 0 && !sameValues(desired.Protocols, current.Protocols) {
+		return true
+	}
+	if !sameValues(desired.RoRule, current.RoRule) {
+		return true
+	}
+	if !sameValues(desired.RwRule, current.RwRule) {
+		return true
+	}
+	if len(desired.Superuser) > 0 && !sameValues(desired.Superuser, current.Superuser) {
+		return true
+	}
+	if desired.Anonuser != "" && desired.Anonuser != current.Anonuser {
+		return true
+	}
+	if desired.ChownMode != "" && desired.ChownMode != current.ChownMode {
+		return true
+	}
+	if desired.NtfsUnixSecurity != "" && desired.NtfsUnixSecurity != current.NtfsUnixSecurity {
+		return true
+	}
+	if desired.AllowSuid != nil && (current.AllowSuid == nil || *desired.AllowSuid != *current.AllowSuid) {
+		return true
+	}
+	return false
 }
 
-func CreateNfsExport(exportToCreate gateway.NfsExport, uuid string, oc *ontap.Client, log logr.Logger) (err error) {
+// sameValues compares two lists ignoring order
+func sameValues(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int)
+	for _, val := range a {
+		counts[val]++
+	}
+	for _, val := range b {
+		counts[val]--
+		if counts[val] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func CreateNfsExport(exportToCreate gateway.NfsExportPolicy, uuid string, oc *ontap.Client, log logr.Logger) (err error) {
 	var newExport ontap.ExportPolicy
 	newExport.Name = exportToCreate.Name
 
 	for _, val := range exportToCreate.Rules {
-		var newRule ontap.ExportRule
-		newRule.Anonuser = val.Anon
-		newRule.Protocols = append(newRule.Protocols, val.Protocols)
-		newRule.RwRule = append(newRule.RwRule, val.Rw)
-		newRule.RoRule = append(newRule.RoRule, val.Ro)
-		newRule.Superuser = append(newRule.Superuser, val.Superuser)
-		var match ontap.ExportMatch
-		match.Match = val.Clients
-		newRule.Clients = append(newRule.Clients, match)
-		newExport.Rules = append(newExport.Rules, newRule)
+		newExport.Rules = append(newExport.Rules, ExportRuleFromSpec(val))
 	}
 
 	newExport.Svm.Uuid = uuid
@@ -399,8 +699,13 @@ func CreateNfsExport(exportToCreate gateway.NfsExport, uuid string, oc *ontap.Cl
 		log.Error(err, "Error creating the json payload for NFS export creation: "+exportToCreate.Name)
 		return err
 	}
+
+	if oc.Debug {
+		log.Info("[DEBUG] NFS export creation payload: " + fmt.Sprintf("%#v\n", newExport))
+	}
+
 	log.Info("NFS export creation attempt: " + exportToCreate.Name)
-	err = oc.CreateIpInterface(jsonPayload)
+	err = oc.CreateNfsExport(jsonPayload)
 	if err != nil {
 		log.Error(err, "Error occurred when creating NFS export: "+exportToCreate.Name)
 		return err
@@ -410,6 +715,135 @@ func CreateNfsExport(exportToCreate gateway.NfsExport, uuid string, oc *ontap.Cl
 	return nil
 }
 
+// UpdateNfsExportRules patches, appends or removes individual rules so unchanged
+// rules keep their index
+func UpdateNfsExportRules(exportToUpdate gateway.NfsExportPolicy, id int, oc *ontap.Client, log logr.Logger) (err error) {
+	rulesRetrieved, err := oc.GetNfsExportRules(id)
+	if err != nil {
+		log.Error(err, "Error getting NFS export rules for export: "+exportToUpdate.Name)
+		return err
+	}
+
+	for indx, val := range exportToUpdate.Rules {
+		desired := ExportRuleFromSpec(val)
+
+		if indx >= rulesRetrieved.NumRecords {
+			// rules are appended so the new rule lands at the next index
+			jsonPayload, err := json.Marshal(desired)
+			if err != nil {
+				log.Error(err, "Error creating the json payload for NFS export rule creation - requeuing")
+				return err
+			}
+			if oc.Debug {
+				log.Info("[DEBUG] NFS export rule creation payload: " + fmt.Sprintf("%#v\n", desired))
+			}
+			log.Info("NFS export rule creation attempt: " + exportToUpdate.Name + " rule " + strconv.Itoa(indx+1))
+			err = oc.CreateNfsExportRule(id, jsonPayload)
+			if err != nil {
+				log.Error(err, "Error occurred when creating NFS export rule - requeuing")
+				return err
+			}
+			log.Info("NFS export rule creation successful")
+			continue
+		}
+
+		current := rulesRetrieved.Records[indx]
+		if !exportRuleChanged(desired, current) {
+			continue
+		}
+
+		jsonPayload, err := json.Marshal(desired)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for NFS export rule update - requeuing")
+			return err
+		}
+		if oc.Debug {
+			log.Info("[DEBUG] NFS export rule update payload: " + fmt.Sprintf("%#v\n", desired))
+		}
+		log.Info("NFS export rule update attempt: " + exportToUpdate.Name + " rule " + strconv.Itoa(current.Index))
+		err = oc.PatchNfsExportRule(id, current.Index, jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when updating NFS export rule - requeuing")
+			return err
+		}
+		log.Info("NFS export rule updated successful")
+	}
+
+	// Delete trailing rules not defined in the custom resource
+	// delete from the end so the remaining indexes don't shift
+	for i := rulesRetrieved.NumRecords - 1; i >= len(exportToUpdate.Rules); i-- {
+		current := rulesRetrieved.Records[i]
+		log.Info("NFS export rule delete attempt: " + exportToUpdate.Name + " rule " + strconv.Itoa(current.Index))
+		err = oc.DeleteNfsExportRule(id, current.Index)
+		if err != nil {
+			log.Error(err, "Error occurred when deleting NFS export rule - requeuing")
+			return err
+		}
+		log.Info("NFS export rule delete successful")
+	}
+
+	return nil
+}
+
+// AssignNfsExport points the requested volumes and qtrees at the export policy
+func AssignNfsExport(exportToAssign gateway.NfsExportPolicy, uuid string, oc *ontap.Client, log logr.Logger) (err error) {
+	var patch ontap.Volume
+	patch.Nas.ExportPolicy.Name = exportToAssign.Name
+
+	for _, val := range exportToAssign.Volumes {
+		volume, err := oc.GetVolumeByName(uuid, val)
+		if err != nil {
+			log.Error(err, "Error getting volume "+val+" for NFS export: "+exportToAssign.Name)
+			return err
+		}
+		if volume.Nas.ExportPolicy.Name == exportToAssign.Name {
+			continue
+		}
+
+		jsonPayload, err := json.Marshal(patch)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for volume export policy update")
+			return err
+		}
+		log.Info("Volume " + val + " export policy update attempt: " + exportToAssign.Name)
+		err = oc.PatchVolume(volume.Uuid, jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when assigning NFS export to volume: "+val)
+			return err
+		}
+		log.Info("Volume " + val + " export policy update successful")
+	}
+
+	var qtreePatch ontap.Qtree
+	qtreePatch.ExportPolicy.Name = exportToAssign.Name
+
+	for _, val := range exportToAssign.Qtrees {
+		qtree, err := oc.GetQtreeByName(uuid, val.Volume, val.Name)
+		if err != nil {
+			log.Error(err, "Error getting qtree "+val.Volume+"/"+val.Name+" for NFS export: "+exportToAssign.Name)
+			return err
+		}
+		if qtree.ExportPolicy.Name == exportToAssign.Name {
+			continue
+		}
+
+		jsonPayload, err := json.Marshal(qtreePatch)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for qtree export policy update")
+			return err
+		}
+		log.Info("Qtree " + val.Volume + "/" + val.Name + " export policy update attempt: " + exportToAssign.Name)
+		err = oc.PatchQtree(qtree.Volume.Uuid, qtree.Id, jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when assigning NFS export to qtree: "+val.Volume+"/"+val.Name)
+			return err
+		}
+		log.Info("Qtree " + val.Volume + "/" + val.Name + " export policy update successful")
+	}
+
+	return nil
+}
+
 // STEP 13
 // NFS update
 // Note: Status of NFS_SERVICE can only be true or false
@@ -485,3 +919,27 @@ func (reconciler *StorageVirtualMachineReconciler) setConditionNfsExport(ctx con
 	}
 	return nil
 }
+
+const CONDITION_REASON_NFS_KERBEROS = "NFSkerberos"
+const CONDITION_MESSAGE_NFS_KERBEROS_TRUE = "NFS Kerberos configuration succeeded"
+const CONDITION_MESSAGE_NFS_KERBEROS_FALSE = "NFS Kerberos configuration failed"
+
+func (reconciler *StorageVirtualMachineReconciler) setConditionNfsKerberos(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus) error {
+
+	// I don't want to delete old references to updates to make a history
+	// if reconciler.containsCondition(ctx, svmCR, CONDITION_REASON_NFS_KERBEROS) {
+	// 	reconciler.deleteCondition(ctx, svmCR, CONDITION_TYPE_NFS_SERVICE, CONDITION_REASON_NFS_KERBEROS)
+	// }
+
+	if status == CONDITION_STATUS_TRUE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_NFS_SERVICE, status,
+			CONDITION_REASON_NFS_KERBEROS, CONDITION_MESSAGE_NFS_KERBEROS_TRUE)
+	}
+
+	if status == CONDITION_STATUS_FALSE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_NFS_SERVICE, status,
+			CONDITION_REASON_NFS_KERBEROS, CONDITION_MESSAGE_NFS_KERBEROS_FALSE)
+	}
+	return nil
+}
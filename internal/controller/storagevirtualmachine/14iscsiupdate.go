@@ -7,20 +7,20 @@ import (
 	gateway "gateway/api/v1beta3"
 	"gateway/internal/controller/ontap"
 	"reflect"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-const Iscsi909ServicePolicy = "default-data-blocks" //magic word
-const Iscsi910ServicePolicy = "default-data-iscsi"  //magic word
 /*
 todo: check on this
 if 9.9.1 - use default-data-blocks
 if 9.10.1+ - use default-data-iscsi
 */
 const IscsiLifServicePolicyScope = "svm" //magic word
+const IscsiDefaultInitiator = "default"  //magic word
 
 func (r *StorageVirtualMachineReconciler) reconcileIscsiUpdate(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
 	uuid string, oc *ontap.Client, log logr.Logger) error {
@@ -57,7 +57,7 @@ func (r *StorageVirtualMachineReconciler) reconcileIscsiUpdate(ctx context.Conte
 			alias = svmCR.Spec.IscsiConfig.Alias
 		}
 		upsertIscsiService.Target.Alias = alias
-		upsertIscsiService.Svm.Uuid = svmCR.Spec.SvmUuid
+		upsertIscsiService.Svm.Uuid = svmCR.GetSvmUuid()
 
 		jsonPayload, err := json.Marshal(upsertIscsiService)
 		if err != nil {
@@ -126,6 +126,56 @@ func (r *StorageVirtualMachineReconciler) reconcileIscsiUpdate(ctx context.Conte
 
 	// END ISCSI SERVICE
 
+	// ISCSI IGROUPS
+
+	if svmCR.Spec.IscsiConfig.Igroups == nil {
+		log.Info("No iSCSI igroups defined - skipping updates")
+	} else {
+		err = r.reconcileIscsiIgroups(ctx, svmCR, uuid, oc, log)
+		if err != nil {
+			_ = r.setConditionIscsiIgroup(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "IscsiUpsertIgroupFailed", "Error: "+err.Error())
+			return err
+		}
+		_ = r.setConditionIscsiIgroup(ctx, svmCR, CONDITION_STATUS_TRUE)
+		r.Recorder.Event(svmCR, "Normal", "IscsiUpsertIgroupSucceeded", "Upserted iSCSI igroup(s) successfully")
+	}
+
+	// END ISCSI IGROUPS
+
+	// ISCSI NODE IGROUP
+
+	if svmCR.Spec.IscsiConfig.NodeIgroup == nil {
+		log.Info("No iSCSI node igroup defined - skipping updates")
+	} else {
+		err = r.reconcileIscsiNodeIgroup(ctx, svmCR, uuid, oc, log)
+		if err != nil {
+			_ = r.setConditionIscsiIgroup(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "IscsiUpsertNodeIgroupFailed", "Error: "+err.Error())
+			return err
+		}
+		_ = r.setConditionIscsiIgroup(ctx, svmCR, CONDITION_STATUS_TRUE)
+	}
+
+	// END ISCSI NODE IGROUP
+
+	// ISCSI CHAP
+
+	if svmCR.Spec.IscsiConfig.Chap == nil {
+		log.Info("No iSCSI CHAP defined - skipping updates")
+	} else {
+		err = r.reconcileIscsiChap(ctx, svmCR, uuid, oc, log)
+		if err != nil {
+			_ = r.setConditionIscsiChap(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "IscsiUpsertChapFailed", "Error: "+err.Error())
+			return err
+		}
+		_ = r.setConditionIscsiChap(ctx, svmCR, CONDITION_STATUS_TRUE)
+		r.Recorder.Event(svmCR, "Normal", "IscsiUpsertChapSucceeded", "Upserted iSCSI CHAP successfully")
+	}
+
+	// END ISCSI CHAP
+
 	// ISCSI LIFS
 
 	// Check to see if ISCSI interfaces are defined in custom resource
@@ -136,24 +186,12 @@ func (r *StorageVirtualMachineReconciler) reconcileIscsiUpdate(ctx context.Conte
 	}
 
 	// Check to see if cluster version is less than 9.10 and assign the correct
-	// LIF service policy
+	// LIF service policy - the version was detected in STEP 4
 
-	var IscsiLifServicePolicy string
-	cluster, err := oc.GetCluster()
-
-	if err != nil {
-		log.Error(err, "Error getting cluster version")
-		IscsiLifServicePolicy = Iscsi909ServicePolicy
-	} else {
-		if cluster.Version.Generation > 8 {
-			if cluster.Version.Major > 9 {
-				IscsiLifServicePolicy = Iscsi910ServicePolicy
-			} else {
-				IscsiLifServicePolicy = Iscsi909ServicePolicy
-			}
-		} else {
-			IscsiLifServicePolicy = Iscsi909ServicePolicy
-		}
+	IscsiLifServicePolicy := r.operatorConfig().ServicePolicies.IscsiLegacy
+	capabilities := oc.Capabilities()
+	if capabilities.Version.Known() && capabilities.Supports(ontap.FeatureIscsiServicePolicy) {
+		IscsiLifServicePolicy = r.operatorConfig().ServicePolicies.Iscsi
 	}
 
 	log.Info("Using iSCSI LIF service policy as: " + IscsiLifServicePolicy)
@@ -238,6 +276,418 @@ func (r *StorageVirtualMachineReconciler) reconcileIscsiUpdate(ctx context.Conte
 	return nil
 }
 
+func (r *StorageVirtualMachineReconciler) reconcileIscsiIgroups(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, uuid string, oc *ontap.Client, log logr.Logger) error {
+
+	igroupsRetrieved, err := oc.GetIgroupsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting iSCSI igroups for SVM: "+uuid+" - requeuing")
+		return err
+	}
+
+	for _, val := range svmCR.Spec.IscsiConfig.Igroups {
+		err = UpsertIgroup(val, findIgroup(igroupsRetrieved, val.Name), uuid, oc, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Delete igroups created by the operator that are no longer defined in the custom resource
+	for _, val := range igroupsRetrieved.Records {
+		if val.Comment != defaultComment {
+			continue
+		}
+		defined := svmCR.Spec.IscsiConfig.NodeIgroup != nil && svmCR.Spec.IscsiConfig.NodeIgroup.Name == val.Name
+		for _, igroup := range svmCR.Spec.IscsiConfig.Igroups {
+			if igroup.Name == val.Name {
+				defined = true
+				break
+			}
+		}
+		if defined {
+			continue
+		}
+		log.Info("iSCSI igroup delete attempt: " + val.Name)
+		err = oc.DeleteIgroup(val.Uuid)
+		if err != nil {
+			log.Error(err, "Error occurred when deleting iSCSI igroup: "+val.Name)
+			// don't requeue on failed delete request - igroups with mapped LUNs can't be deleted
+		} else {
+			log.Info("iSCSI igroup delete successful: " + val.Name)
+		}
+	}
+
+	// Report the igroups as they are now on the SVM
+	igroupsRetrieved, err = oc.GetIgroupsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting iSCSI igroups for status")
+		return nil
+	}
+
+	status := svmCR.Status.Iscsi
+	if status == nil {
+		status = &gateway.IscsiStatus{}
+	}
+	var igroupStatuses []gateway.IscsiIgroupStatus
+	for _, val := range svmCR.Spec.IscsiConfig.Igroups {
+		current := findIgroup(igroupsRetrieved, val.Name)
+		if current == nil {
+			continue
+		}
+		igroupStatus := gateway.IscsiIgroupStatus{Name: current.Name, Uuid: current.Uuid}
+		for _, initiator := range current.Initiators {
+			igroupStatus.Initiators = append(igroupStatus.Initiators, initiator.Name)
+		}
+		igroupStatuses = append(igroupStatuses, igroupStatus)
+	}
+	if !reflect.DeepEqual(status.Igroups, igroupStatuses) {
+		status.Igroups = igroupStatuses
+		svmCR.Status.Iscsi = status
+		_ = r.updateStatus(ctx, svmCR)
+	}
+
+	return nil
+}
+
+// reconcileIscsiNodeIgroup keeps the node igroup's initiators in sync with the IQNs published
+// by the selected nodes in the gateway.netapp.com/iscsi-iqn annotation
+func (r *StorageVirtualMachineReconciler) reconcileIscsiNodeIgroup(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, uuid string, oc *ontap.Client, log logr.Logger) error {
+
+	nodeIgroup := svmCR.Spec.IscsiConfig.NodeIgroup
+
+	nodes, err := r.listNodes(ctx, nodeIgroup.NodeSelector)
+	if err != nil {
+		log.Error(err, "Error listing nodes for iSCSI node igroup: "+nodeIgroup.Name)
+		return err
+	}
+
+	desired := gateway.IscsiIgroup{
+		Name:       nodeIgroup.Name,
+		OsType:     nodeIgroup.OsType,
+		Initiators: NodeAnnotationValues(nodes, NodeIscsiIqnAnnotation),
+	}
+	log.Info("iSCSI node igroup " + nodeIgroup.Name + " initiators: " + strings.Join(desired.Initiators, ","))
+
+	igroupsRetrieved, err := oc.GetIgroupsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting iSCSI igroups for SVM: "+uuid+" - requeuing")
+		return err
+	}
+
+	return UpsertIgroup(desired, findIgroup(igroupsRetrieved, nodeIgroup.Name), uuid, oc, log)
+}
+
+func findIgroup(igroups ontap.IgroupsResponse, name string) *ontap.Igroup {
+	for i := range igroups.Records {
+		if igroups.Records[i].Name == name {
+			return &igroups.Records[i]
+		}
+	}
+	return nil
+}
+
+// UpsertIgroup creates the igroup or brings its OS type, portset and initiators in line with the definition
+func UpsertIgroup(igroupToUpsert gateway.IscsiIgroup, current *ontap.Igroup, uuid string, oc *ontap.Client, log logr.Logger) (err error) {
+	osType := igroupToUpsert.OsType
+	if osType == "" {
+		osType = "linux"
+	}
+
+	if current == nil {
+		var newIgroup ontap.Igroup
+		newIgroup.Name = igroupToUpsert.Name
+		newIgroup.Svm.Uuid = uuid
+		newIgroup.OsType = osType
+		newIgroup.Protocol = "iscsi"
+		newIgroup.Comment = defaultComment
+		for _, val := range igroupToUpsert.Initiators {
+			newIgroup.Initiators = append(newIgroup.Initiators, ontap.IgroupInitiator{Name: val})
+		}
+		if igroupToUpsert.Portset != "" {
+			newIgroup.Portset = &ontap.Ref{Name: igroupToUpsert.Portset}
+		}
+
+		jsonPayload, err := json.Marshal(newIgroup)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for iSCSI igroup creation: "+igroupToUpsert.Name)
+			return err
+		}
+
+		if oc.Debug {
+			log.Info("[DEBUG] iSCSI igroup creation payload: " + fmt.Sprintf("%#v\n", newIgroup))
+		}
+
+		log.Info("iSCSI igroup creation attempt: " + igroupToUpsert.Name)
+		err = oc.CreateIgroup(jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when creating iSCSI igroup: "+igroupToUpsert.Name)
+			return err
+		}
+		log.Info("iSCSI igroup creation successful: " + igroupToUpsert.Name)
+		return nil
+	}
+
+	// Compare OS type and portset
+	patch := make(map[string]interface{})
+	if current.OsType != osType {
+		patch["os_type"] = osType
+	}
+	currentPortset := ""
+	if current.Portset != nil {
+		currentPortset = current.Portset.Name
+	}
+	if currentPortset != igroupToUpsert.Portset {
+		// an empty name unbinds the portset
+		patch["portset"] = map[string]string{"name": igroupToUpsert.Portset}
+	}
+
+	if len(patch) > 0 {
+		jsonPayload, err := json.Marshal(patch)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for iSCSI igroup update: "+igroupToUpsert.Name)
+			return err
+		}
+
+		if oc.Debug {
+			log.Info("[DEBUG] iSCSI igroup update payload: " + fmt.Sprintf("%#v\n", patch))
+		}
+
+		log.Info("iSCSI igroup update attempt: " + igroupToUpsert.Name)
+		err = oc.PatchIgroup(current.Uuid, jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when updating iSCSI igroup: "+igroupToUpsert.Name)
+			return err
+		}
+		log.Info("iSCSI igroup update successful: " + igroupToUpsert.Name)
+	}
+
+	// Add missing initiators
+	var missing ontap.IgroupInitiatorsUpsert
+	for _, val := range igroupToUpsert.Initiators {
+		found := false
+		for _, initiator := range current.Initiators {
+			if strings.EqualFold(initiator.Name, val) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing.Records = append(missing.Records, ontap.IgroupInitiator{Name: val})
+		}
+	}
+
+	if len(missing.Records) > 0 {
+		jsonPayload, err := json.Marshal(missing)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for iSCSI igroup initiators: "+igroupToUpsert.Name)
+			return err
+		}
+		log.Info("iSCSI igroup " + igroupToUpsert.Name + " add initiators attempt")
+		err = oc.AddIgroupInitiators(current.Uuid, jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when adding initiators to iSCSI igroup: "+igroupToUpsert.Name)
+			return err
+		}
+		log.Info("iSCSI igroup " + igroupToUpsert.Name + " add initiators successful")
+	}
+
+	// Remove initiators not defined
+	for _, initiator := range current.Initiators {
+		found := false
+		for _, val := range igroupToUpsert.Initiators {
+			if strings.EqualFold(initiator.Name, val) {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		log.Info("iSCSI igroup " + igroupToUpsert.Name + " remove initiator attempt: " + initiator.Name)
+		err = oc.DeleteIgroupInitiator(current.Uuid, initiator.Name)
+		if err != nil {
+			log.Error(err, "Error occurred when removing initiator from iSCSI igroup: "+igroupToUpsert.Name)
+			return err
+		}
+		log.Info("iSCSI igroup " + igroupToUpsert.Name + " remove initiator successful: " + initiator.Name)
+	}
+
+	return nil
+}
+
+func (r *StorageVirtualMachineReconciler) reconcileIscsiChap(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, uuid string, oc *ontap.Client, log logr.Logger) error {
+
+	credentialsRetrieved, err := oc.GetIscsiCredentialsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting iSCSI credentials for SVM: "+uuid+" - requeuing")
+		return err
+	}
+
+	status := svmCR.Status.Iscsi
+	if status == nil {
+		status = &gateway.IscsiStatus{}
+	}
+
+	var chapStatuses []gateway.IscsiChapStatus
+	for _, val := range svmCR.Spec.IscsiConfig.Chap {
+		var desired ontap.IscsiCredentials
+		chapStatus := gateway.IscsiChapStatus{Initiator: val.Initiator, AuthenticationType: "none"}
+
+		if val.Inbound != nil {
+			secret, err := r.getSecret(ctx, *val.Inbound, svmCR)
+			if err != nil {
+				log.Error(err, "Error getting inbound CHAP secret for initiator: "+val.Initiator)
+				return err
+			}
+			desired.Chap = &ontap.IscsiChapUsers{Inbound: &ontap.IscsiChapUser{
+				User:     string(secret.Data["username"]),
+				Password: string(secret.Data["password"]),
+			}}
+			chapStatus.AuthenticationType = "chap"
+			chapStatus.InboundSecretVersion = secret.ResourceVersion
+		}
+
+		if val.Outbound != nil {
+			secret, err := r.getSecret(ctx, *val.Outbound, svmCR)
+			if err != nil {
+				log.Error(err, "Error getting outbound CHAP secret for initiator: "+val.Initiator)
+				return err
+			}
+			if desired.Chap == nil {
+				desired.Chap = &ontap.IscsiChapUsers{}
+			}
+			desired.Chap.Outbound = &ontap.IscsiChapUser{
+				User:     string(secret.Data["username"]),
+				Password: string(secret.Data["password"]),
+			}
+			chapStatus.OutboundSecretVersion = secret.ResourceVersion
+		}
+		desired.AuthenticationType = chapStatus.AuthenticationType
+
+		var current *ontap.IscsiCredentials
+		for i := range credentialsRetrieved.Records {
+			if strings.EqualFold(credentialsRetrieved.Records[i].Initiator, val.Initiator) {
+				current = &credentialsRetrieved.Records[i]
+				break
+			}
+		}
+
+		if current == nil {
+			desired.Initiator = val.Initiator
+			desired.Svm.Uuid = uuid
+
+			jsonPayload, err := json.Marshal(desired)
+			if err != nil {
+				log.Error(err, "Error creating the json payload for iSCSI credentials creation")
+				return err
+			}
+			log.Info("iSCSI credentials creation attempt: " + val.Initiator)
+			err = oc.CreateIscsiCredentials(jsonPayload)
+			if err != nil {
+				log.Error(err, "Error occurred when creating iSCSI credentials: "+val.Initiator)
+				return err
+			}
+			log.Info("iSCSI credentials creation successful: " + val.Initiator)
+		} else if iscsiChapChanged(desired, *current, chapStatus, status.Chap) {
+			jsonPayload, err := json.Marshal(desired)
+			if err != nil {
+				log.Error(err, "Error creating the json payload for iSCSI credentials update")
+				return err
+			}
+			log.Info("iSCSI credentials update attempt: " + val.Initiator)
+			err = oc.PatchIscsiCredentials(uuid, current.Initiator, jsonPayload)
+			if err != nil {
+				log.Error(err, "Error occurred when updating iSCSI credentials: "+val.Initiator)
+				return err
+			}
+			log.Info("iSCSI credentials update successful: " + val.Initiator)
+		}
+
+		chapStatuses = append(chapStatuses, chapStatus)
+	}
+
+	// Delete credentials of initiators no longer defined in the custom resource
+	// only those the operator set up before, as recorded in the status, and never the default security settings
+	for _, val := range credentialsRetrieved.Records {
+		if val.Initiator == IscsiDefaultInitiator || !containsIscsiChapStatus(status.Chap, val.Initiator) {
+			continue
+		}
+		defined := false
+		for _, chap := range svmCR.Spec.IscsiConfig.Chap {
+			if strings.EqualFold(chap.Initiator, val.Initiator) {
+				defined = true
+				break
+			}
+		}
+		if defined {
+			continue
+		}
+		log.Info("iSCSI credentials delete attempt: " + val.Initiator)
+		err = oc.DeleteIscsiCredentials(uuid, val.Initiator)
+		if err != nil {
+			log.Error(err, "Error occurred when deleting iSCSI credentials: "+val.Initiator)
+			// don't requeue on failed delete request
+		} else {
+			log.Info("iSCSI credentials delete successful: " + val.Initiator)
+		}
+	}
+
+	if !reflect.DeepEqual(status.Chap, chapStatuses) {
+		status.Chap = chapStatuses
+		svmCR.Status.Iscsi = status
+		_ = r.updateStatus(ctx, svmCR)
+	}
+
+	return nil
+}
+
+func containsIscsiChapStatus(chapStatuses []gateway.IscsiChapStatus, initiator string) bool {
+	for _, val := range chapStatuses {
+		if strings.EqualFold(val.Initiator, initiator) {
+			return true
+		}
+	}
+	return false
+}
+
+// iscsiChapChanged compares the authentication type and CHAP users
+// passwords can't be read back so a changed secret version also triggers an update
+func iscsiChapChanged(desired ontap.IscsiCredentials, current ontap.IscsiCredentials,
+	desiredStatus gateway.IscsiChapStatus, previous []gateway.IscsiChapStatus) bool {
+
+	if desired.AuthenticationType != current.AuthenticationType {
+		return true
+	}
+
+	var desiredInbound, currentInbound, desiredOutbound, currentOutbound string
+	if desired.Chap != nil && desired.Chap.Inbound != nil {
+		desiredInbound = desired.Chap.Inbound.User
+	}
+	if desired.Chap != nil && desired.Chap.Outbound != nil {
+		desiredOutbound = desired.Chap.Outbound.User
+	}
+	if current.Chap != nil && current.Chap.Inbound != nil {
+		currentInbound = current.Chap.Inbound.User
+	}
+	if current.Chap != nil && current.Chap.Outbound != nil {
+		currentOutbound = current.Chap.Outbound.User
+	}
+	if desiredInbound != currentInbound || desiredOutbound != currentOutbound {
+		return true
+	}
+
+	for _, val := range previous {
+		if strings.EqualFold(val.Initiator, desiredStatus.Initiator) {
+			return val.InboundSecretVersion != desiredStatus.InboundSecretVersion ||
+				val.OutboundSecretVersion != desiredStatus.OutboundSecretVersion
+		}
+	}
+	// never applied by the operator
+	return true
+}
+
 // STEP 14
 // iSCSI update
 // Note: Status of ISCSI_SERVICE can only be true or false
@@ -289,3 +739,51 @@ func (reconciler *StorageVirtualMachineReconciler) setConditionIscsiLif(ctx cont
 	}
 	return nil
 }
+
+const CONDITION_REASON_ISCSI_IGROUP = "iSCSIigroup"
+const CONDITION_MESSAGE_ISCSI_IGROUP_TRUE = "iSCSI igroup configuration succeeded"
+const CONDITION_MESSAGE_ISCSI_IGROUP_FALSE = "iSCSI igroup configuration failed"
+
+func (reconciler *StorageVirtualMachineReconciler) setConditionIscsiIgroup(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus) error {
+
+	// I don't want to delete old references to updates to make a history
+	// if reconciler.containsCondition(ctx, svmCR, CONDITION_REASON_ISCSI_IGROUP) {
+	// 	reconciler.deleteCondition(ctx, svmCR, CONDITION_TYPE_ISCSI_SERVICE, CONDITION_REASON_ISCSI_IGROUP)
+	// }
+
+	if status == CONDITION_STATUS_TRUE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_ISCSI_SERVICE, status,
+			CONDITION_REASON_ISCSI_IGROUP, CONDITION_MESSAGE_ISCSI_IGROUP_TRUE)
+	}
+
+	if status == CONDITION_STATUS_FALSE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_ISCSI_SERVICE, status,
+			CONDITION_REASON_ISCSI_IGROUP, CONDITION_MESSAGE_ISCSI_IGROUP_FALSE)
+	}
+	return nil
+}
+
+const CONDITION_REASON_ISCSI_CHAP = "iSCSIchap"
+const CONDITION_MESSAGE_ISCSI_CHAP_TRUE = "iSCSI CHAP configuration succeeded"
+const CONDITION_MESSAGE_ISCSI_CHAP_FALSE = "iSCSI CHAP configuration failed"
+
+func (reconciler *StorageVirtualMachineReconciler) setConditionIscsiChap(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus) error {
+
+	// I don't want to delete old references to updates to make a history
+	// if reconciler.containsCondition(ctx, svmCR, CONDITION_REASON_ISCSI_CHAP) {
+	// 	reconciler.deleteCondition(ctx, svmCR, CONDITION_TYPE_ISCSI_SERVICE, CONDITION_REASON_ISCSI_CHAP)
+	// }
+
+	if status == CONDITION_STATUS_TRUE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_ISCSI_SERVICE, status,
+			CONDITION_REASON_ISCSI_CHAP, CONDITION_MESSAGE_ISCSI_CHAP_TRUE)
+	}
+
+	if status == CONDITION_STATUS_FALSE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_ISCSI_SERVICE, status,
+			CONDITION_REASON_ISCSI_CHAP, CONDITION_MESSAGE_ISCSI_CHAP_FALSE)
+	}
+	return nil
+}
@@ -7,14 +7,13 @@ import (
 	gateway "gateway/api/v1beta3"
 	"gateway/internal/controller/ontap"
 	"reflect"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-const NvmeLifServicePolicy = "default-data-nvme-tcp" //magic word
-
 const NvmeLifServicePolicyScope = "svm" //magic word
 
 func (r *StorageVirtualMachineReconciler) reconcileNvmeUpdate(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
@@ -47,7 +46,7 @@ func (r *StorageVirtualMachineReconciler) reconcileNvmeUpdate(ctx context.Contex
 	if createNvmeService {
 		log.Info("No NVMe service defined for SVM: " + uuid + " - creating NVMe service")
 
-		upsertNvmeService.Svm.Uuid = svmCR.Spec.SvmUuid
+		upsertNvmeService.Svm.Uuid = svmCR.GetSvmUuid()
 		upsertNvmeService.Enabled = &svmCR.Spec.NvmeConfig.Enabled
 
 		jsonPayload, err := json.Marshal(upsertNvmeService)
@@ -112,6 +111,49 @@ func (r *StorageVirtualMachineReconciler) reconcileNvmeUpdate(ctx context.Contex
 
 	// END NVMe SERVICE
 
+	// NVMe NODE SUBSYSTEM
+
+	if svmCR.Spec.NvmeConfig.NodeSubsystem == nil {
+		log.Info("No NVMe node subsystem defined - skipping updates")
+	} else {
+		err = r.reconcileNvmeNodeSubsystem(ctx, svmCR, uuid, oc, log)
+		if err != nil {
+			_ = r.setConditionNvmeSubsystem(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "NvmeUpsertNodeSubsystemFailed", "Error: "+err.Error())
+			return err
+		}
+		_ = r.setConditionNvmeSubsystem(ctx, svmCR, CONDITION_STATUS_TRUE)
+	}
+
+	// END NVMe NODE SUBSYSTEM
+
+	// NVMe SUBSYSTEMS
+
+	if svmCR.Spec.NvmeConfig.Subsystems == nil {
+		log.Info("No NVMe subsystems defined - skipping updates")
+	} else {
+		err = r.reconcileNvmeSubsystems(ctx, svmCR, uuid, oc, log)
+		if err != nil {
+			_ = r.setConditionNvmeSubsystem(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "NvmeUpsertSubsystemFailed", "Error: "+err.Error())
+			return err
+		}
+
+		err = r.reconcileNvmeSubsystemMaps(svmCR, uuid, oc, log)
+		if err != nil {
+			_ = r.setConditionNvmeMap(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "NvmeUpsertMapFailed", "Error: "+err.Error())
+			return err
+		}
+		_ = r.setConditionNvmeMap(ctx, svmCR, CONDITION_STATUS_TRUE)
+
+		r.reportNvmeStatus(ctx, svmCR, uuid, oc, log)
+		_ = r.setConditionNvmeSubsystem(ctx, svmCR, CONDITION_STATUS_TRUE)
+		r.Recorder.Event(svmCR, "Normal", "NvmeUpsertSubsystemSucceeded", "Upserted NVMe subsystem(s) successfully")
+	}
+
+	// END NVMe SUBSYSTEMS
+
 	// NVMe LIFS
 
 	// Check to see if NVMe interfaces are defined in custom resource
@@ -124,7 +166,7 @@ func (r *StorageVirtualMachineReconciler) reconcileNvmeUpdate(ctx context.Contex
 	createNvmeLifs := false
 
 	// Check to see if NVMe interfaces defined and compare to custom resource's definitions
-	lifs, err := oc.GetNvmeInterfacesBySvmUuid(uuid, NvmeLifServicePolicy)
+	lifs, err := oc.GetNvmeInterfacesBySvmUuid(uuid, r.operatorConfig().ServicePolicies.Nvme)
 	if err != nil {
 		//error creating the json body
 		log.Error(err, "Error getting NVMe service LIFs for SVM: "+uuid)
@@ -145,7 +187,7 @@ func (r *StorageVirtualMachineReconciler) reconcileNvmeUpdate(ctx context.Contex
 		// if lifs.Records[index] is out of index - if so, need to create LIF
 		if createNvmeLifs || index > lifs.NumRecords-1 {
 			// Need to create LIF for val
-			err = CreateLif(val, NvmeLifServicePolicy, NvmeLifServicePolicyScope, uuid, oc, log)
+			err = CreateLif(val, r.operatorConfig().ServicePolicies.Nvme, NvmeLifServicePolicyScope, uuid, oc, log)
 			if err != nil {
 				_ = r.setConditionNvmeLif(ctx, svmCR, CONDITION_STATUS_FALSE)
 				r.Recorder.Event(svmCR, "Warning", "NvmeCreationLifFailed", "Error: "+err.Error())
@@ -160,7 +202,7 @@ func (r *StorageVirtualMachineReconciler) reconcileNvmeUpdate(ctx context.Contex
 				break
 			}
 
-			err = UpdateLif(val, lifs.Records[index], NvmeLifServicePolicy, oc, log)
+			err = UpdateLif(val, lifs.Records[index], r.operatorConfig().ServicePolicies.Nvme, oc, log)
 			if err != nil {
 				_ = r.setConditionNvmeLif(ctx, svmCR, CONDITION_STATUS_FALSE)
 				r.Recorder.Event(svmCR, "Warning", "NvmeUpdateLifFailed", "Error: "+err.Error())
@@ -201,6 +243,371 @@ func (r *StorageVirtualMachineReconciler) reconcileNvmeUpdate(ctx context.Contex
 	return nil
 }
 
+// reconcileNvmeNodeSubsystem keeps the node subsystem's hosts in sync with the NQNs published
+// by the selected nodes in the gateway.netapp.com/nvme-nqn annotation
+func (r *StorageVirtualMachineReconciler) reconcileNvmeNodeSubsystem(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, uuid string, oc *ontap.Client, log logr.Logger) error {
+
+	nodeSubsystem := svmCR.Spec.NvmeConfig.NodeSubsystem
+
+	nodes, err := r.listNodes(ctx, nodeSubsystem.NodeSelector)
+	if err != nil {
+		log.Error(err, "Error listing nodes for NVMe node subsystem: "+nodeSubsystem.Name)
+		return err
+	}
+
+	nqns := NodeAnnotationValues(nodes, NodeNvmeNqnAnnotation)
+	log.Info("NVMe node subsystem " + nodeSubsystem.Name + " hosts: " + strings.Join(nqns, ","))
+
+	var hosts []ontap.NvmeSubsystemHost
+	for _, val := range nqns {
+		hosts = append(hosts, ontap.NvmeSubsystemHost{Nqn: val})
+	}
+
+	subsystemsRetrieved, err := oc.GetNvmeSubsystemsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting NVMe subsystems for SVM: "+uuid+" - requeuing")
+		return err
+	}
+
+	return UpsertNvmeSubsystem(nodeSubsystem.Name, nodeSubsystem.OsType, hosts,
+		findNvmeSubsystem(subsystemsRetrieved, nodeSubsystem.Name), uuid, oc, log)
+}
+
+func (r *StorageVirtualMachineReconciler) reconcileNvmeSubsystems(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, uuid string, oc *ontap.Client, log logr.Logger) error {
+
+	subsystemsRetrieved, err := oc.GetNvmeSubsystemsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting NVMe subsystems for SVM: "+uuid+" - requeuing")
+		return err
+	}
+
+	keyVersions := make(map[string]map[string]string)
+
+	for _, val := range svmCR.Spec.NvmeConfig.Subsystems {
+		var hosts []ontap.NvmeSubsystemHost
+		versions := make(map[string]string)
+
+		for _, host := range val.Hosts {
+			newHost := ontap.NvmeSubsystemHost{Nqn: host.Nqn}
+			if host.DhHmacChap != nil {
+				secret, err := r.getSecret(ctx, host.DhHmacChap.Secret, svmCR)
+				if err != nil {
+					log.Error(err, "Error getting DH-HMAC-CHAP secret for host: "+host.Nqn)
+					return err
+				}
+				newHost.DhHmacChap = &ontap.NvmeDhHmacChap{
+					HostSecretKey:       string(secret.Data["hostSecretKey"]),
+					ControllerSecretKey: string(secret.Data["controllerSecretKey"]),
+					HashFunction:        host.DhHmacChap.HashFunction,
+					GroupSize:           host.DhHmacChap.GroupSize,
+				}
+				versions[host.Nqn] = secret.ResourceVersion
+			}
+			hosts = append(hosts, newHost)
+		}
+		keyVersions[val.Name] = versions
+
+		current := findNvmeSubsystem(subsystemsRetrieved, val.Name)
+		if current != nil {
+			// keys can't be read back from ONTAP so hosts whose secret changed are registered again
+			previous := nvmeKeyVersions(svmCR, val.Name)
+			var kept []ontap.NvmeSubsystemHost
+			for _, host := range current.Hosts {
+				version, hasKey := versions[host.Nqn]
+				if hasKey && previous[host.Nqn] != version {
+					log.Info("NVMe subsystem " + val.Name + " host key changed - removing host: " + host.Nqn)
+					err = oc.DeleteNvmeSubsystemHost(current.Uuid, host.Nqn)
+					if err != nil {
+						log.Error(err, "Error occurred when removing host from NVMe subsystem: "+val.Name)
+						return err
+					}
+					continue
+				}
+				kept = append(kept, host)
+			}
+			current.Hosts = kept
+		}
+
+		err = UpsertNvmeSubsystem(val.Name, val.OsType, hosts, current, uuid, oc, log)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Delete subsystems created by the operator that are no longer defined in the custom resource
+	for _, val := range subsystemsRetrieved.Records {
+		if val.Comment != defaultComment {
+			continue
+		}
+		defined := svmCR.Spec.NvmeConfig.NodeSubsystem != nil && svmCR.Spec.NvmeConfig.NodeSubsystem.Name == val.Name
+		for _, subsystem := range svmCR.Spec.NvmeConfig.Subsystems {
+			if subsystem.Name == val.Name {
+				defined = true
+				break
+			}
+		}
+		if defined {
+			continue
+		}
+		log.Info("NVMe subsystem delete attempt: " + val.Name)
+		err = oc.DeleteNvmeSubsystem(val.Uuid)
+		if err != nil {
+			log.Error(err, "Error occurred when deleting NVMe subsystem: "+val.Name)
+			// don't requeue on failed delete request - retried on the next reconcile
+		} else {
+			log.Info("NVMe subsystem delete successful: " + val.Name)
+		}
+	}
+
+	// remember the applied key versions for the status report
+	status := svmCR.Status.Nvme
+	if status == nil {
+		status = &gateway.NvmeStatus{}
+	}
+	var statuses []gateway.NvmeSubsystemStatus
+	for _, val := range svmCR.Spec.NvmeConfig.Subsystems {
+		subsystemStatus := gateway.NvmeSubsystemStatus{Name: val.Name}
+		if len(keyVersions[val.Name]) > 0 {
+			subsystemStatus.KeyVersions = keyVersions[val.Name]
+		}
+		statuses = append(statuses, subsystemStatus)
+	}
+	status.Subsystems = statuses
+	svmCR.Status.Nvme = status
+
+	return nil
+}
+
+// nvmeKeyVersions returns the DH-HMAC-CHAP secret versions last applied to the subsystem
+func nvmeKeyVersions(svmCR *gateway.StorageVirtualMachine, name string) map[string]string {
+	if svmCR.Status.Nvme == nil {
+		return nil
+	}
+	for _, val := range svmCR.Status.Nvme.Subsystems {
+		if val.Name == name {
+			return val.KeyVersions
+		}
+	}
+	return nil
+}
+
+// reconcileNvmeSubsystemMaps maps and unmaps namespaces so each defined subsystem
+// has exactly the namespaces listed in the custom resource
+func (r *StorageVirtualMachineReconciler) reconcileNvmeSubsystemMaps(svmCR *gateway.StorageVirtualMachine,
+	uuid string, oc *ontap.Client, log logr.Logger) error {
+
+	mapsRetrieved, err := oc.GetNvmeSubsystemMapsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting NVMe subsystem maps for SVM: "+uuid+" - requeuing")
+		return err
+	}
+
+	for _, val := range svmCR.Spec.NvmeConfig.Subsystems {
+		for _, path := range val.Namespaces {
+			found := false
+			for _, subsystemMap := range mapsRetrieved.Records {
+				if subsystemMap.Subsystem.Name == val.Name && subsystemMap.Namespace.Name == path {
+					found = true
+					break
+				}
+			}
+			if found {
+				continue
+			}
+
+			var newMap ontap.NvmeSubsystemMap
+			newMap.Svm.Uuid = uuid
+			newMap.Subsystem.Name = val.Name
+			newMap.Namespace.Name = path
+
+			jsonPayload, err := json.Marshal(newMap)
+			if err != nil {
+				log.Error(err, "Error creating the json payload for NVMe subsystem map creation")
+				return err
+			}
+			log.Info("NVMe subsystem map creation attempt: " + path + " to " + val.Name)
+			err = oc.CreateNvmeSubsystemMap(jsonPayload)
+			if err != nil {
+				log.Error(err, "Error occurred when mapping namespace "+path+" to NVMe subsystem: "+val.Name)
+				return err
+			}
+			log.Info("NVMe subsystem map creation successful: " + path + " to " + val.Name)
+		}
+
+		for _, subsystemMap := range mapsRetrieved.Records {
+			if subsystemMap.Subsystem.Name != val.Name {
+				continue
+			}
+			defined := false
+			for _, path := range val.Namespaces {
+				if subsystemMap.Namespace.Name == path {
+					defined = true
+					break
+				}
+			}
+			if defined {
+				continue
+			}
+			log.Info("NVMe subsystem map delete attempt: " + subsystemMap.Namespace.Name + " from " + val.Name)
+			err = oc.DeleteNvmeSubsystemMap(subsystemMap.Subsystem.Uuid, subsystemMap.Namespace.Uuid)
+			if err != nil {
+				log.Error(err, "Error occurred when unmapping namespace "+subsystemMap.Namespace.Name+" from NVMe subsystem: "+val.Name)
+				return err
+			}
+			log.Info("NVMe subsystem map delete successful: " + subsystemMap.Namespace.Name + " from " + val.Name)
+		}
+	}
+
+	return nil
+}
+
+// reportNvmeStatus reads back each defined subsystem's target NQN, hosts and namespaces into the status
+func (r *StorageVirtualMachineReconciler) reportNvmeStatus(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, uuid string, oc *ontap.Client, log logr.Logger) {
+
+	subsystemsRetrieved, err := oc.GetNvmeSubsystemsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting NVMe subsystems for status")
+		_ = r.updateStatus(ctx, svmCR)
+		return
+	}
+	mapsRetrieved, err := oc.GetNvmeSubsystemMapsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting NVMe subsystem maps for status")
+	}
+
+	if svmCR.Status.Nvme == nil {
+		return
+	}
+	for indx, val := range svmCR.Status.Nvme.Subsystems {
+		current := findNvmeSubsystem(subsystemsRetrieved, val.Name)
+		if current == nil {
+			continue
+		}
+		val.Uuid = current.Uuid
+		val.TargetNqn = current.TargetNqn
+		val.Hosts = nil
+		for _, host := range current.Hosts {
+			val.Hosts = append(val.Hosts, host.Nqn)
+		}
+		val.Namespaces = nil
+		for _, subsystemMap := range mapsRetrieved.Records {
+			if subsystemMap.Subsystem.Name == val.Name {
+				val.Namespaces = append(val.Namespaces, subsystemMap.Namespace.Name)
+			}
+		}
+		svmCR.Status.Nvme.Subsystems[indx] = val
+	}
+
+	_ = r.updateStatus(ctx, svmCR)
+}
+
+func findNvmeSubsystem(subsystems ontap.NvmeSubsystemsResponse, name string) *ontap.NvmeSubsystem {
+	for i := range subsystems.Records {
+		if subsystems.Records[i].Name == name {
+			return &subsystems.Records[i]
+		}
+	}
+	return nil
+}
+
+// UpsertNvmeSubsystem creates the subsystem or adds and removes hosts to match the definition
+func UpsertNvmeSubsystem(name string, osType string, hosts []ontap.NvmeSubsystemHost, current *ontap.NvmeSubsystem,
+	uuid string, oc *ontap.Client, log logr.Logger) (err error) {
+
+	if osType == "" {
+		osType = "linux"
+	}
+
+	if current == nil {
+		var newSubsystem ontap.NvmeSubsystem
+		newSubsystem.Name = name
+		newSubsystem.Svm.Uuid = uuid
+		newSubsystem.OsType = osType
+		newSubsystem.Comment = defaultComment
+		newSubsystem.Hosts = hosts
+
+		jsonPayload, err := json.Marshal(newSubsystem)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for NVMe subsystem creation: "+name)
+			return err
+		}
+
+		if oc.Debug {
+			log.Info("[DEBUG] NVMe subsystem creation payload: " + fmt.Sprintf("%#v\n", newSubsystem))
+		}
+
+		log.Info("NVMe subsystem creation attempt: " + name)
+		err = oc.CreateNvmeSubsystem(jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when creating NVMe subsystem: "+name)
+			return err
+		}
+		log.Info("NVMe subsystem creation successful: " + name)
+		return nil
+	}
+
+	// the OS type of a subsystem can't be changed after creation
+	if current.OsType != osType {
+		log.Info("NVMe subsystem " + name + " OS type is " + current.OsType + " - it can't be changed to " + osType)
+	}
+
+	// Add missing hosts
+	var missing ontap.NvmeSubsystemHostsUpsert
+	for _, val := range hosts {
+		found := false
+		for _, host := range current.Hosts {
+			if host.Nqn == val.Nqn {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing.Records = append(missing.Records, val)
+		}
+	}
+
+	if len(missing.Records) > 0 {
+		jsonPayload, err := json.Marshal(missing)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for NVMe subsystem hosts: "+name)
+			return err
+		}
+		log.Info("NVMe subsystem " + name + " add hosts attempt")
+		err = oc.AddNvmeSubsystemHosts(current.Uuid, jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when adding hosts to NVMe subsystem: "+name)
+			return err
+		}
+		log.Info("NVMe subsystem " + name + " add hosts successful")
+	}
+
+	// Remove hosts not defined
+	for _, host := range current.Hosts {
+		found := false
+		for _, val := range hosts {
+			if host.Nqn == val.Nqn {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		log.Info("NVMe subsystem " + name + " remove host attempt: " + host.Nqn)
+		err = oc.DeleteNvmeSubsystemHost(current.Uuid, host.Nqn)
+		if err != nil {
+			log.Error(err, "Error occurred when removing host from NVMe subsystem: "+name)
+			return err
+		}
+		log.Info("NVMe subsystem " + name + " remove host successful: " + host.Nqn)
+	}
+
+	return nil
+}
+
 // STEP 15
 // NVMe update
 // Note: Status of NVME_SERVICE can only be true or false
@@ -252,3 +659,51 @@ func (reconciler *StorageVirtualMachineReconciler) setConditionNvmeLif(ctx conte
 	}
 	return nil
 }
+
+const CONDITION_REASON_NVME_SUBSYSTEM = "NVMesubsystem"
+const CONDITION_MESSAGE_NVME_SUBSYSTEM_TRUE = "NVMe subsystem configuration succeeded"
+const CONDITION_MESSAGE_NVME_SUBSYSTEM_FALSE = "NVMe subsystem configuration failed"
+
+func (reconciler *StorageVirtualMachineReconciler) setConditionNvmeSubsystem(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus) error {
+
+	// I don't want to delete old references to updates to make a history
+	// if reconciler.containsCondition(ctx, svmCR, CONDITION_REASON_NVME_SUBSYSTEM) {
+	// 	reconciler.deleteCondition(ctx, svmCR, CONDITION_TYPE_NVME_SERVICE, CONDITION_REASON_NVME_SUBSYSTEM)
+	// }
+
+	if status == CONDITION_STATUS_TRUE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_NVME_SERVICE, status,
+			CONDITION_REASON_NVME_SUBSYSTEM, CONDITION_MESSAGE_NVME_SUBSYSTEM_TRUE)
+	}
+
+	if status == CONDITION_STATUS_FALSE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_NVME_SERVICE, status,
+			CONDITION_REASON_NVME_SUBSYSTEM, CONDITION_MESSAGE_NVME_SUBSYSTEM_FALSE)
+	}
+	return nil
+}
+
+const CONDITION_REASON_NVME_MAP = "NVMemap"
+const CONDITION_MESSAGE_NVME_MAP_TRUE = "NVMe subsystem map configuration succeeded"
+const CONDITION_MESSAGE_NVME_MAP_FALSE = "NVMe subsystem map configuration failed"
+
+func (reconciler *StorageVirtualMachineReconciler) setConditionNvmeMap(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus) error {
+
+	// I don't want to delete old references to updates to make a history
+	// if reconciler.containsCondition(ctx, svmCR, CONDITION_REASON_NVME_MAP) {
+	// 	reconciler.deleteCondition(ctx, svmCR, CONDITION_TYPE_NVME_SERVICE, CONDITION_REASON_NVME_MAP)
+	// }
+
+	if status == CONDITION_STATUS_TRUE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_NVME_SERVICE, status,
+			CONDITION_REASON_NVME_MAP, CONDITION_MESSAGE_NVME_MAP_TRUE)
+	}
+
+	if status == CONDITION_STATUS_FALSE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_NVME_SERVICE, status,
+			CONDITION_REASON_NVME_MAP, CONDITION_MESSAGE_NVME_MAP_FALSE)
+	}
+	return nil
+}
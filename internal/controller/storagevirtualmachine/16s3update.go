@@ -2,21 +2,24 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	gateway "gateway/api/v1beta3"
 	"gateway/internal/controller/ontap"
 	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-const S3LifServicePolicy = "gateway-custom-service-policy-s3" //magic word
-const S3LifServicePolicyScope = "svm"                         //magic word
+const S3LifServicePolicyScope = "svm" //magic word
 
 func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
 	uuid string, oc *ontap.Client, log logr.Logger) error {
@@ -47,9 +50,12 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 	if createS3Service {
 		log.Info("No S3 service defined for SVM: " + uuid + " - creating S3 service")
 
-		upsertS3Service.Svm.Uuid = svmCR.Spec.SvmUuid
+		upsertS3Service.Svm.Uuid = svmCR.GetSvmUuid()
 		upsertS3Service.Enabled = svmCR.Spec.S3Config.Enabled
 		upsertS3Service.Name = svmCR.Spec.S3Config.Name
+		if len(oc.Capabilities().Unsupported(s3UnixUserFeatures(svmCR.Spec.S3Config))) == 0 {
+			upsertS3Service.DefaultUnixUser = svmCR.Spec.S3Config.DefaultUnixUser
+		}
 
 		if svmCR.Spec.S3Config.Http != nil {
 			upsertS3Service.IsHttpEnabled = svmCR.Spec.S3Config.Http.Enabled
@@ -59,9 +65,9 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 		}
 		if svmCR.Spec.S3Config.Https != nil {
 			upsertS3Service.IsHttpsEnabled = svmCR.Spec.S3Config.Https.Enabled
-			if svmCR.Spec.S3Config.Http.Enabled {
+			if svmCR.Spec.S3Config.Https.Enabled {
 				upsertS3Service.SecurePort = svmCR.Spec.S3Config.Https.Port
-				cert, err := CreateServerCertificate(svmCR.Spec.S3Config.Https.Certificate.CommonName, svmCR.Spec.S3Config.Https.Certificate.Type, svmCR.Spec.S3Config.Https.Certificate.ExpiryTime, uuid, svmCR.Spec.SvmName, oc, log)
+				cert, err := r.s3ServerCertificate(ctx, svmCR, uuid, oc, log)
 				if err != nil {
 					_ = r.setConditionS3Cert(ctx, svmCR, CONDITION_STATUS_FALSE)
 					return err
@@ -115,7 +121,7 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 			upsertS3Service.IsHttpsEnabled = svmCR.Spec.S3Config.Https.Enabled
 			if svmCR.Spec.S3Config.Https.Enabled {
 				upsertS3Service.SecurePort = svmCR.Spec.S3Config.Https.Port
-				cert, err := CreateServerCertificate(svmCR.Spec.S3Config.Https.Certificate.CommonName, svmCR.Spec.S3Config.Https.Certificate.Type, svmCR.Spec.S3Config.Https.Certificate.ExpiryTime, uuid, svmCR.Spec.SvmName, oc, log)
+				cert, err := r.s3ServerCertificate(ctx, svmCR, uuid, oc, log)
 				if err != nil {
 					_ = r.setConditionS3Cert(ctx, svmCR, CONDITION_STATUS_FALSE)
 					return err
@@ -132,6 +138,12 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 			upsertS3Service.Name = svmCR.Spec.S3Config.Name
 		}
 
+		if svmCR.Spec.S3Config.DefaultUnixUser != "" && S3Service.DefaultUnixUser != svmCR.Spec.S3Config.DefaultUnixUser &&
+			len(oc.Capabilities().Unsupported(s3UnixUserFeatures(svmCR.Spec.S3Config))) == 0 {
+			updateS3Service = true
+			upsertS3Service.DefaultUnixUser = svmCR.Spec.S3Config.DefaultUnixUser
+		}
+
 		if oc.Debug && updateS3Service {
 			log.Info("[DEBUG] S3 service update payload: " + fmt.Sprintf("%#v\n", upsertS3Service))
 		}
@@ -164,6 +176,19 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 
 	// END S3 SERVICE
 
+	// S3 CERTIFICATE
+
+	if svmCR.Spec.S3Config.Https != nil && svmCR.Spec.S3Config.Https.Enabled {
+		err = r.reconcileS3Certificate(ctx, svmCR, uuid, oc, log)
+		if err != nil {
+			_ = r.setConditionS3Cert(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "S3CertificateFailed", "Error: "+err.Error())
+			return err
+		}
+	}
+
+	// END S3 CERTIFICATE
+
 	// S3 LIFS
 
 	// Check to see if S3 interfaces are defined in custom resource
@@ -175,18 +200,21 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 		createS3Lifs := false
 
 		// Check for custom S3 LIF service policy
-		err := oc.CheckExistsInterfaceServicePolicyByName(S3LifServicePolicy)
+		unlockCluster := r.lockCluster(oc, log)
+		err := oc.CheckExistsInterfaceServicePolicyByName(r.operatorConfig().ServicePolicies.S3)
 		if err != nil {
-			log.Info("LIF S3 Service Policy " + S3LifServicePolicy + " does not exist - creating")
-			err := CreateLifServicePolicy(S3LifServicePolicy, S3LifServicePolicyScope, uuid, oc, log)
+			log.Info("LIF S3 Service Policy " + r.operatorConfig().ServicePolicies.S3 + " does not exist - creating")
+			err := CreateLifServicePolicy(r.operatorConfig().ServicePolicies.S3, S3LifServicePolicyScope, uuid, oc, log)
 			if err != nil {
+				unlockCluster()
 				_ = r.setConditionS3Lif(ctx, svmCR, CONDITION_STATUS_FALSE)
 				return err
 			}
 		}
+		unlockCluster()
 
 		// Check to see if S3 interfaces defined and compare to custom resource's definitions
-		lifs, err := oc.GetS3InterfacesBySvmUuid(uuid, S3LifServicePolicy)
+		lifs, err := oc.GetS3InterfacesBySvmUuid(uuid, r.operatorConfig().ServicePolicies.S3)
 		if err != nil {
 			//error creating the json body
 			log.Error(err, "Error getting S3 service LIFs for SVM: "+uuid)
@@ -207,7 +235,7 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 			// if lifs.Records[index] is out of index - if so, need to create LIF
 			if createS3Lifs || index > lifs.NumRecords-1 {
 				// Need to create LIF for val
-				err = CreateLif(val, S3LifServicePolicy, S3LifServicePolicyScope, uuid, oc, log)
+				err = CreateLif(val, r.operatorConfig().ServicePolicies.S3, S3LifServicePolicyScope, uuid, oc, log)
 				if err != nil {
 					_ = r.setConditionS3Lif(ctx, svmCR, CONDITION_STATUS_FALSE)
 					r.Recorder.Event(svmCR, "Warning", "S3CreationLifFailed", "Error: "+err.Error())
@@ -222,7 +250,7 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 					break
 				}
 
-				err = UpdateLif(val, lifs.Records[index], S3LifServicePolicy, oc, log)
+				err = UpdateLif(val, lifs.Records[index], r.operatorConfig().ServicePolicies.S3, oc, log)
 				if err != nil {
 					_ = r.setConditionS3Lif(ctx, svmCR, CONDITION_STATUS_FALSE)
 					r.Recorder.Event(svmCR, "Warning", "S3UpdateLifFailed", "Error: "+err.Error())
@@ -262,100 +290,230 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 	// END S3 LIFS
 
 	// S3 USERS
-	if svmCR.Spec.S3Config.Users == nil {
-		// If none, exit with no error
-		log.Info("No S3 users defined - skipping")
-	} else {
-		log.Info("Starting S3 users reconcillation")
+	// users removed from the custom resource are deleted also when no users are defined anymore
+	log.Info("Starting S3 users reconcillation")
+
+	//Check to see if S3 users defined and compare to custom resource
+	usersRetrieved, err := oc.GetS3UsersBySvmUuid(uuid)
+	if err != nil {
+		//error creating the json body
+		log.Error(err, "Error getting S3 users for SVM: "+uuid+" - requeuing")
+		_ = r.setConditionS3User(ctx, svmCR, CONDITION_STATUS_FALSE)
+		return err
+	}
+
+	if usersRetrieved.NumRecords == 0 {
+		// No exports for the SVM provided in UUID
+		// create new export(s)
+		log.Info("No S3 users defined for SVM: " + uuid + " - creating S3 user(s)")
+	}
+
+	var userStatuses []gateway.S3UserStatus
+	var recorded []gateway.S3UserStatus
+	if svmCR.Status.S3 != nil {
+		recorded = slices.Clone(svmCR.Status.S3.Users)
+	}
+	// ONTAP users of previous keys whose grace period ended - deleted once groups and bucket policies no longer grant them
+	var expiredUsers []string
+	connection := r.s3Connection(ctx, svmCR, uuid, oc, log)
+
+	for _, val := range svmCR.Spec.S3Config.Users {
+		previous := findS3UserStatus(recorded, val.Name)
+		ontapUser := s3OntapUser(val.Name, previous)
 
-		//Check to see if S3 users defined and compare to custom resource
-		usersRetrieved, err := oc.GetS3UsersBySvmUuid(uuid)
+		createS3User := true //default to create
+		for j := 0; j < usersRetrieved.NumRecords; j++ {
+			if ontapUser == usersRetrieved.Records[j].Name {
+				//skip this one
+				createS3User = false
+			}
+		}
+
+		var created *ontap.S3User
+		if createS3User {
+			user, err := CreateUser(gateway.S3User{Name: ontapUser}, uuid, oc, log)
+			if err != nil {
+				_ = r.setConditionS3User(ctx, svmCR, CONDITION_STATUS_FALSE)
+				r.Recorder.Event(svmCR, "Warning", "S3UserFailed", "Error: "+err.Error())
+				return err
+			}
+			if len(user.Records) > 0 {
+				created = &user.Records[0]
+			}
+		} else {
+			log.Info("S3 user already created: " + val.Name)
+		}
+
+		// Create or update the secret with the access key and secret key
+		userStatus, err := r.reconcileS3UserSecret(ctx, svmCR, val, previous, created, uuid, oc, log)
 		if err != nil {
-			//error creating the json body
-			log.Error(err, "Error getting S3 users for SVM: "+uuid+" - requeuing")
-			_ = r.setConditionS3User(ctx, svmCR, CONDITION_STATUS_FALSE)
+			_ = r.setConditionS3UserSecret(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "S3UserFailed", "Error: "+err.Error())
 			return err
 		}
-
-		if usersRetrieved.NumRecords == 0 {
-			// No exports for the SVM provided in UUID
-			// create new export(s)
-			log.Info("No S3 users defined for SVM: " + uuid + " - creating S3 user(s)")
+		userStatuses = append(userStatuses, userStatus)
+		if previous != nil && previous.PreviousOntapUser != "" && previous.PreviousOntapUser != userStatus.PreviousOntapUser &&
+			previous.PreviousOntapUser != s3OntapUser(val.Name, &userStatus) {
+			expiredUsers = append(expiredUsers, previous.PreviousOntapUser)
+		}
+		if previous == nil || previous.OntapUser != userStatus.OntapUser || previous.PreviousOntapUser != userStatus.PreviousOntapUser {
+			// the ONTAP user holding the keys in the secret is recorded right away, also when a later user fails
+			setS3UserStatus(svmCR, userStatus)
+			_ = r.updateStatus(ctx, svmCR)
 		}
 
-		createS3User := true //default to create
+		// Publish the connection details for the applications using the user's secret
+		err = r.reconcileS3UserConnection(ctx, svmCR, val, connection, log)
+		if err != nil {
+			_ = r.setConditionS3UserSecret(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "S3UserFailed", "Error: "+err.Error())
+			return err
+		}
+	}
 
-		for i, val := range svmCR.Spec.S3Config.Users {
-			for j := 0; j < usersRetrieved.NumRecords; j++ {
-				if val.Name == usersRetrieved.Records[j].Name {
-					//skip this one
-					createS3User = false
-				}
+	// Delete the S3 users created by the operator that are not defined in the custom resource
+	// those recorded in the status or with the operator's comment - users created by others are left alone
+	keep := make(map[string]bool)
+	for _, val := range userStatuses {
+		for _, name := range s3OntapUsers(val) {
+			keep[name] = true
+		}
+	}
+	for _, name := range expiredUsers {
+		keep[name] = true
+	}
+	for _, val := range usersRetrieved.Records {
+		if val.Name == S3RootUser || strings.HasPrefix(val.Name, S3CosiUserPrefix) || keep[val.Name] {
+			continue
+		}
+		owner := s3UserOwner(recorded, val.Name)
+		if owner == nil && val.Comment != defaultComment {
+			continue
+		}
+		// ONTAP users left from a user's earlier key rotations are deleted as well, without the user's objects
+		defined := owner != nil && findS3UserStatus(userStatuses, owner.Name) != nil
+		log.Info("S3 user delete attempt: " + val.Name)
+		err = oc.DeleteS3User(uuid, val.Name)
+		if err != nil {
+			log.Error(err, "Error occurred when deleting S3 user: "+val.Name)
+			// don't requeue on failed delete request - the user stays recorded so the deletion is retried
+			if owner != nil && !defined {
+				userStatuses = append(userStatuses, *owner)
 			}
-			if createS3User {
-				user, err := CreateUser(val, uuid, oc, log)
-				if err != nil {
-					_ = r.setConditionS3User(ctx, svmCR, CONDITION_STATUS_FALSE)
-					r.Recorder.Event(svmCR, "Warning", "S3UserFailed", "Error: "+err.Error())
-					return err
-				} else {
-					// Create a secret with the access key and secret key
-
-					var secretNamespace string
-					if svmCR.Spec.S3Config.Users[i].Namespace != nil {
-						secretNamespace = *svmCR.Spec.S3Config.Users[i].Namespace
-					} else {
-						secretNamespace = svmCR.Namespace
-					}
-					secret := &corev1.Secret{
-						ObjectMeta: metav1.ObjectMeta{
-							Name:      user.Records[0].Name,
-							Namespace: secretNamespace,
-						},
-						StringData: map[string]string{
-							"accessKeyID":     user.Records[0].AccessKey,
-							"secretAccessKey": user.Records[0].SecretKey,
-						},
-						Type: corev1.SecretTypeOpaque,
-					}
-					namespaceName := client.ObjectKey{
-						Name:      user.Records[0].Name,
-						Namespace: secretNamespace,
-					}
-					secretCheck := &corev1.Secret{}
-					err = r.Get(ctx, namespaceName, secretCheck)
-					if err != nil {
-						if errors.IsNotFound(err) {
-							//create it
-							err = r.Create(ctx, secret)
-							if err != nil {
-								_ = r.setConditionS3UserSecret(ctx, svmCR, CONDITION_STATUS_FALSE)
-								r.Recorder.Event(svmCR, "Warning", "S3UserFailed", "Error: "+err.Error())
-								log.Error(err, "Error creating S3 user secret for SVM: "+uuid+" and user: "+user.Records[0].Name+" with access key: "+user.Records[0].AccessKey+" and secret key: "+user.Records[0].SecretKey)
-							} else {
-								log.Info("S3 User and secret creation successful: " + val.Name)
-							}
-						} else {
-							_ = r.setConditionS3UserSecret(ctx, svmCR, CONDITION_STATUS_FALSE)
-							r.Recorder.Event(svmCR, "Warning", "S3UserFailed", "Error: "+err.Error())
-							log.Error(err, "Error checking S3 user secret for SVM: "+uuid+" and user: "+user.Records[0].Name+" with access key: "+user.Records[0].AccessKey+" and secret key: "+user.Records[0].SecretKey)
-						}
-
-					}
-
-				}
-			} else {
-				//reset default back to true
-				createS3User = true
-				log.Info("S3 user already created: " + val.Name)
+		} else {
+			log.Info("S3 user delete successful: " + val.Name)
+			if owner == nil {
+				r.deleteS3UserObjects(ctx, svmCR, val.Name, log)
+			} else if !defined {
+				r.deleteS3UserObjects(ctx, svmCR, owner.Name, log)
 			}
 		}
-		_ = r.setConditionS3User(ctx, svmCR, CONDITION_STATUS_TRUE)
-		r.Recorder.Event(svmCR, "Normal", "S3UserSucceeded", "Created S3 user(s) successfully")
+	}
 
+	if svmCR.Status.S3 == nil {
+		svmCR.Status.S3 = &gateway.S3Status{}
+	}
+	if !reflect.DeepEqual(svmCR.Status.S3.Users, userStatuses) {
+		svmCR.Status.S3.Users = userStatuses
+		_ = r.updateStatus(ctx, svmCR)
+	}
+
+	if len(svmCR.Spec.S3Config.Users) > 0 {
+		_ = r.setConditionS3User(ctx, svmCR, CONDITION_STATUS_TRUE)
+		r.Recorder.Event(svmCR, "Normal", "S3UserSucceeded", "Upserted S3 user(s) successfully")
 	}
+
 	//END S3 USERS
 
+	// S3 POLICIES
+
+	var createdPolicies []string
+	if svmCR.Status.S3 != nil {
+		createdPolicies = svmCR.Status.S3.Policies
+	}
+	if svmCR.Spec.S3Config.Policies == nil && len(createdPolicies) == 0 {
+		log.Info("No S3 policies defined - skipping")
+	} else {
+		createdPolicies, err = r.reconcileS3Policies(svmCR, createdPolicies, uuid, oc, log)
+		// recorded before checking the error so those created before a failure are still known
+		if svmCR.Status.S3 == nil {
+			svmCR.Status.S3 = &gateway.S3Status{}
+		}
+		if !reflect.DeepEqual(svmCR.Status.S3.Policies, createdPolicies) {
+			svmCR.Status.S3.Policies = createdPolicies
+			_ = r.updateStatus(ctx, svmCR)
+		}
+		if err != nil {
+			_ = r.setConditionS3Policy(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "S3PolicyFailed", "Error: "+err.Error())
+			return err
+		}
+		_ = r.setConditionS3Policy(ctx, svmCR, CONDITION_STATUS_TRUE)
+		r.Recorder.Event(svmCR, "Normal", "S3PolicySucceeded", "Upserted S3 policies successfully")
+	}
+
+	// END S3 POLICIES
+
+	// S3 GROUPS
+
+	var createdGroups []string
+	if svmCR.Status.S3 != nil {
+		createdGroups = svmCR.Status.S3.Groups
+	}
+	if svmCR.Spec.S3Config.Groups == nil && len(createdGroups) == 0 {
+		log.Info("No S3 groups defined - skipping")
+	} else {
+		createdGroups, err = r.reconcileS3Groups(svmCR, createdGroups, uuid, oc, log)
+		// recorded before checking the error so those created before a failure are still known
+		if svmCR.Status.S3 == nil {
+			svmCR.Status.S3 = &gateway.S3Status{}
+		}
+		if !reflect.DeepEqual(svmCR.Status.S3.Groups, createdGroups) {
+			svmCR.Status.S3.Groups = createdGroups
+			_ = r.updateStatus(ctx, svmCR)
+		}
+		if err != nil {
+			_ = r.setConditionS3Group(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "S3GroupFailed", "Error: "+err.Error())
+			return err
+		}
+		_ = r.setConditionS3Group(ctx, svmCR, CONDITION_STATUS_TRUE)
+		r.Recorder.Event(svmCR, "Normal", "S3GroupSucceeded", "Upserted S3 groups successfully")
+	}
+
+	// END S3 GROUPS
+
+	// S3 NAME MAPPINGS
+
+	if svmCR.Spec.S3Config.NameMappings == nil {
+		log.Info("No S3 name mappings defined - skipping")
+	} else if unsupported := oc.Capabilities().Unsupported(s3UnixUserFeatures(svmCR.Spec.S3Config)); len(unsupported) > 0 {
+		// reported by the capabilities condition
+		log.Info("S3 name mappings unsupported by ONTAP " + oc.Capabilities().Version.String() + " - skipping")
+	} else {
+		var applied []gateway.S3NameMapping
+		if svmCR.Status.S3 != nil {
+			applied = svmCR.Status.S3.NameMappings
+		}
+		err = reconcileS3NameMappings(svmCR.Spec.S3Config.NameMappings, applied, uuid, oc, log)
+		if err != nil {
+			_ = r.setConditionS3NameMapping(ctx, svmCR, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "S3NameMappingFailed", "Error: "+err.Error())
+			return err
+		}
+		if svmCR.Status.S3 == nil {
+			svmCR.Status.S3 = &gateway.S3Status{}
+		}
+		if !reflect.DeepEqual(svmCR.Status.S3.NameMappings, svmCR.Spec.S3Config.NameMappings) {
+			svmCR.Status.S3.NameMappings = svmCR.Spec.S3Config.NameMappings
+			_ = r.updateStatus(ctx, svmCR)
+		}
+		_ = r.setConditionS3NameMapping(ctx, svmCR, CONDITION_STATUS_TRUE)
+		r.Recorder.Event(svmCR, "Normal", "S3NameMappingSucceeded", "Upserted S3 name mappings successfully")
+	}
+
+	// END S3 NAME MAPPINGS
+
 	//S3 BUCKETS
 
 	if svmCR.Spec.S3Config.Buckets == nil {
@@ -363,6 +521,9 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 		log.Info("No S3 buckets defined - skipping")
 	} else {
 
+		// Bucket and volume jobs started before an operator restart complete first so their buckets and volumes are listed
+		r.resumeJobs(ctx, svmCR, oc, log, JobOperationS3BucketCreate, JobOperationS3BucketPatch, JobOperationVolumeCreate)
+
 		//Check to see if S3 buckets defined and compare to custom resource
 		bucketsRetrieved, err := oc.GetS3BucketsBySvmUuid(uuid)
 		if err != nil {
@@ -380,51 +541,38 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 
 		for _, definedBucket := range svmCR.Spec.S3Config.Buckets {
 
-			createBucket := true
+			// buckets using features the release doesn't support are skipped - the others are still reconciled
+			if unsupported := oc.Capabilities().Unsupported(s3BucketFeatures(definedBucket)); len(unsupported) > 0 {
+				message := "ONTAP " + oc.Capabilities().Version.String() + " doesn't support: " + ontap.Describe(unsupported)
+				log.Info("S3 bucket " + definedBucket.Name + " skipped: " + message)
+				r.Recorder.Event(svmCR, "Warning", "S3BucketUnsupported", "Skipped S3 bucket "+definedBucket.Name+": "+message)
+				continue
+			}
+
+			var currentBucket *ontap.S3Bucket
 
 			for j := 0; j < bucketsRetrieved.NumRecords; j++ {
 
 				if definedBucket.Name == bucketsRetrieved.Records[j].Name {
-					createBucket = false
+					currentBucket = &bucketsRetrieved.Records[j]
 					log.Info("S3 bucket already present: " + bucketsRetrieved.Records[j].Name)
 				}
 			}
 
-			if createBucket {
-				var newBucket ontap.S3Bucket
-				newBucket.Name = definedBucket.Name
-				if definedBucket.Type != "" {
-					newBucket.Type = definedBucket.Type
-				} else {
-					newBucket.Type = "s3" //magic word
-				}
-
-				if definedBucket.Size > 102005473280 {
-					newBucket.Size = definedBucket.Size
-				} else {
-					newBucket.Size = 102005473280 //magic word - 95GiB
-				}
-
-				if definedBucket.Comment != "" {
-					newBucket.Comment = definedBucket.Comment
-				} else {
-					newBucket.Comment = "Gateway created" //magic word
-				}
-				var newBucketStatement ontap.S3BucketPolicyStatements
-				newBucketStatement.Effect = "allow"                                                           //magic word
-				newBucketStatement.Actions = []string{"ListBucket", "GetObject", "PutObject", "DeleteObject"} //magic word                             //magic word
-
-				var principals []string
-				for _, val := range svmCR.Spec.S3Config.Users {
-					principals = append(principals, val.Name)
+			// NAS buckets need their volume path to be exported by the SVM's NFS configuration
+			nasPath := ""
+			if definedBucket.Nas != nil {
+				nasPath, err = r.nasBucketPath(ctx, svmCR, definedBucket, uuid, oc, log)
+				if err != nil {
+					_ = r.setConditionS3Bucket(ctx, svmCR, CONDITION_STATUS_FALSE)
+					r.Recorder.Event(svmCR, "Warning", "S3BucketFailed", "Error: "+err.Error())
+					return err
 				}
-				newBucketStatement.Principals = principals
-				var resources []string
-				resources = append(resources, newBucket.Name)
-				resources = append(resources, newBucket.Name+"/*")
-				newBucketStatement.Resources = resources
+			}
 
-				newBucket.Policy.Statements = append(newBucket.Policy.Statements, newBucketStatement)
+			if currentBucket == nil {
+				newBucket := S3BucketFromSpec(definedBucket, svmCR.Spec.S3Config.Users, s3UserPrincipals(svmCR), oc.Capabilities())
+				newBucket.NasPath = nasPath
 
 				jsonPayload, err := json.Marshal(newBucket)
 				if err != nil {
@@ -433,8 +581,14 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 					return err
 				}
 
+				if oc.Debug {
+					log.Info("[DEBUG] S3 bucket creation payload: " + fmt.Sprintf("%#v\n", newBucket))
+				}
+
 				log.Info("S3 bucket creation attempt: " + newBucket.Name)
-				err = oc.CreateS3Bucket(uuid, jsonPayload)
+				_, err = r.runJob(ctx, svmCR, JobOperationS3BucketCreate, newBucket.Name, func() (string, error) {
+					return oc.StartS3BucketCreation(uuid, jsonPayload)
+				}, oc, log)
 				if err != nil {
 					log.Error(err, fmt.Sprintf("Error occurred when creating S3 bucket: %v", newBucket.Name))
 					_ = r.setConditionS3Bucket(ctx, svmCR, CONDITION_STATUS_FALSE)
@@ -442,19 +596,801 @@ func (r *StorageVirtualMachineReconciler) reconcileS3Update(ctx context.Context,
 					return err
 				}
 
+			} else {
+				if nasPath != "" && nasPath != currentBucket.NasPath {
+					// ONTAP only sets the path of a NAS bucket when the bucket is created
+					log.Info("S3 bucket " + definedBucket.Name + " path is " + currentBucket.NasPath +
+						" and can't be changed to " + nasPath + " - ignoring")
+				}
+
+				patchBucket, changed := s3BucketChanges(definedBucket, *currentBucket, s3UserPrincipals(svmCR), log)
+				if !changed {
+					continue
+				}
+
+				jsonPayload, err := json.Marshal(patchBucket)
+				if err != nil {
+					//error creating the json body
+					log.Error(err, fmt.Sprintf("Error creating the json payload for S3 bucket update %v", definedBucket.Name))
+					return err
+				}
+
+				if oc.Debug {
+					log.Info("[DEBUG] S3 bucket update payload: " + fmt.Sprintf("%#v\n", patchBucket))
+				}
+
+				log.Info("S3 bucket update attempt: " + definedBucket.Name)
+				_, err = r.runJob(ctx, svmCR, JobOperationS3BucketPatch, definedBucket.Name, func() (string, error) {
+					return oc.StartS3BucketPatch(uuid, currentBucket.Uuid, jsonPayload)
+				}, oc, log)
+				if err != nil {
+					log.Error(err, fmt.Sprintf("Error occurred when updating S3 bucket: %v", definedBucket.Name))
+					_ = r.setConditionS3Bucket(ctx, svmCR, CONDITION_STATUS_FALSE)
+					r.Recorder.Event(svmCR, "Warning", "S3BucketFailed", "Failed to update S3 bucket: "+definedBucket.Name)
+					return err
+				}
+				log.Info("S3 bucket update successful: " + definedBucket.Name)
 			}
 
 		}
 		_ = r.setConditionS3Bucket(ctx, svmCR, CONDITION_STATUS_TRUE)
-		r.Recorder.Event(svmCR, "Normal", "S3BucketSucceeded", "Created S3 bucket(s) successfully")
+		r.Recorder.Event(svmCR, "Normal", "S3BucketSucceeded", "Upserted S3 bucket(s) successfully")
 
 	}
 
 	//END S3 BUCKETS
 
+	for _, name := range expiredUsers {
+		log.Info("S3 user delete attempt (grace period ended): " + name)
+		err = oc.DeleteS3User(uuid, name)
+		if err != nil {
+			log.Error(err, "Error occurred when deleting S3 user: "+name)
+			// don't requeue on failed delete request - the user has the operator's comment so the deletion is retried
+		} else {
+			log.Info("S3 user delete successful: " + name)
+		}
+	}
+
 	return nil
 }
 
+// s3ServerCertificate installs the server certificate for a new HTTPS endpoint
+// from the tls secret when provided, otherwise signed by a CA certificate created on the SVM
+func (r *StorageVirtualMachineReconciler) s3ServerCertificate(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	uuid string, oc *ontap.Client, log logr.Logger) (ontap.Certificate, error) {
+
+	https := svmCR.Spec.S3Config.Https
+	if https.TlsSecret != nil {
+		secret, err := r.getSecret(ctx, *https.TlsSecret, svmCR)
+		if err != nil {
+			log.Error(err, "Error getting S3 tls secret: "+https.TlsSecret.Name)
+			return ontap.Certificate{}, err
+		}
+		cert, err := InstallServerCertificate(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey], uuid, oc, log)
+		if err != nil {
+			return cert, err
+		}
+		setS3CertificateStatus(svmCR, cert, tlsSecretHash(secret))
+		return cert, nil
+	}
+
+	if https.Certificate == nil {
+		return ontap.Certificate{}, fmt.Errorf("either caCertificate or tlsSecret is required for S3 HTTPS")
+	}
+	expiryTime := https.Certificate.ExpiryTime
+	if expiryTime == "" {
+		expiryTime = r.operatorConfig().Certificates.DefaultExpiry
+	}
+	return CreateServerCertificate(https.Certificate.CommonName, https.Certificate.Type, expiryTime, uuid, svmCR.Spec.SvmName, oc, log)
+}
+
+// reconcileS3Certificate re-installs the tls secret's certificate when the secret changed
+// and reports the certificate bound to the S3 server in the status
+func (r *StorageVirtualMachineReconciler) reconcileS3Certificate(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	uuid string, oc *ontap.Client, log logr.Logger) error {
+
+	s3Service, err := oc.GetS3ServiceBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error retrieving S3 service for SVM by UUID - requeuing")
+		return err
+	}
+
+	previous := svmCR.Status.S3
+	if previous == nil {
+		previous = &gateway.S3Status{}
+	}
+	statusBefore := previous.DeepCopy()
+
+	https := svmCR.Spec.S3Config.Https
+	if https.TlsSecret != nil {
+		secret, err := r.getSecret(ctx, *https.TlsSecret, svmCR)
+		if err != nil {
+			log.Error(err, "Error getting S3 tls secret: "+https.TlsSecret.Name)
+			return err
+		}
+
+		installed := previous.Certificate
+		if installed == nil || installed.SecretHash != tlsSecretHash(secret) || installed.Uuid != s3Service.Certificate.Uuid {
+			log.Info("S3 tls secret changed - installing server certificate from secret: " + secret.Name)
+			cert, err := InstallServerCertificate(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey], uuid, oc, log)
+			if err != nil {
+				return err
+			}
+
+			var patchS3Service struct {
+				Certificate ontap.Resource `json:"certificate"`
+			}
+			patchS3Service.Certificate.Uuid = cert.Uuid
+			jsonPayload, err := json.Marshal(patchS3Service)
+			if err != nil {
+				log.Error(err, "Error creating the json payload for S3 service certificate update")
+				return err
+			}
+			log.Info("S3 service certificate update attempt for SVM: " + uuid)
+			err = oc.PatchS3Service(uuid, jsonPayload)
+			if err != nil {
+				log.Error(err, "Error updating the S3 service certificate - requeuing")
+				return err
+			}
+			log.Info("S3 service certificate update successful")
+			r.Recorder.Event(svmCR, "Normal", "S3CertificateSucceeded", "Installed S3 server certificate from secret "+secret.Name)
+
+			// Delete the certificate the operator installed from the previous version of the secret
+			if installed != nil && installed.SecretHash != "" && installed.Uuid != "" && installed.Uuid != cert.Uuid {
+				log.Info("Previous server certificate delete attempt: " + installed.Name)
+				err = oc.DeleteCertificate(installed.Uuid)
+				if err != nil {
+					log.Error(err, "Error occurred when deleting previous server certificate: "+installed.Name)
+					// don't requeue on failed delete request
+				} else {
+					log.Info("Previous server certificate delete successful: " + installed.Name)
+				}
+			}
+
+			setS3CertificateStatus(svmCR, cert, tlsSecretHash(secret))
+			s3Service.Certificate.Uuid = cert.Uuid
+		}
+	}
+
+	// Report the certificate bound to the S3 server
+	if s3Service.Certificate.Uuid != "" {
+		cert, err := oc.GetCertificateByUuid(s3Service.Certificate.Uuid)
+		if err != nil {
+			log.Error(err, "Error getting S3 server certificate: "+s3Service.Certificate.Uuid)
+		} else {
+			secretHash := ""
+			if https.TlsSecret != nil && svmCR.Status.S3 != nil && svmCR.Status.S3.Certificate != nil {
+				secretHash = svmCR.Status.S3.Certificate.SecretHash
+			}
+			setS3CertificateStatus(svmCR, cert, secretHash)
+		}
+	}
+
+	_ = r.setConditionS3Cert(ctx, svmCR, CONDITION_STATUS_TRUE)
+	if !reflect.DeepEqual(statusBefore, svmCR.Status.S3) {
+		_ = r.updateStatus(ctx, svmCR)
+	}
+	return nil
+}
+
+// tlsSecretHash identifies the certificate chain and key in a tls secret
+// metadata changes to the secret don't cause the certificate to be re-installed
+func tlsSecretHash(secret *corev1.Secret) string {
+	hash := sha256.New()
+	hash.Write(secret.Data[corev1.TLSCertKey])
+	hash.Write(secret.Data[corev1.TLSPrivateKeyKey])
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func setS3CertificateStatus(svmCR *gateway.StorageVirtualMachine, cert ontap.Certificate, secretHash string) {
+	if svmCR.Status.S3 == nil {
+		svmCR.Status.S3 = &gateway.S3Status{}
+	}
+	certStatus := &gateway.S3CertificateStatus{
+		Name:       cert.Name,
+		Uuid:       cert.Uuid,
+		CommonName: cert.CommonName,
+		SecretHash: secretHash,
+	}
+	if expiry, err := time.Parse(time.RFC3339, cert.ExpiryTime); err == nil {
+		certStatus.NotAfter = &metav1.Time{Time: expiry}
+	}
+	svmCR.Status.S3.Certificate = certStatus
+}
+
+// reconcileS3Policies creates and patches the server-level policies defined in the custom resource
+// and deletes the other policies created by the operator - those in created or with the operator's comment
+// it returns the names of the policies created by the operator that are still on the SVM, also after an error
+func (r *StorageVirtualMachineReconciler) reconcileS3Policies(svmCR *gateway.StorageVirtualMachine, created []string,
+	uuid string, oc *ontap.Client, log logr.Logger) ([]string, error) {
+
+	policiesRetrieved, err := oc.GetS3PoliciesBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting S3 policies for SVM: "+uuid+" - requeuing")
+		return created, err
+	}
+
+	// policies deleted by others are no longer recorded
+	managed := slices.DeleteFunc(slices.Clone(created), func(name string) bool {
+		return !slices.ContainsFunc(policiesRetrieved.Records, func(policy ontap.S3Policy) bool { return policy.Name == name })
+	})
+
+	for _, val := range svmCR.Spec.S3Config.Policies {
+		desired := S3PolicyFromSpec(val)
+
+		var current *ontap.S3Policy
+		for j := range policiesRetrieved.Records {
+			if policiesRetrieved.Records[j].Name == val.Name {
+				current = &policiesRetrieved.Records[j]
+				break
+			}
+		}
+
+		if current == nil {
+			jsonPayload, err := json.Marshal(desired)
+			if err != nil {
+				log.Error(err, "Error creating the json payload for S3 policy creation: "+val.Name)
+				return managed, err
+			}
+			if oc.Debug {
+				log.Info("[DEBUG] S3 policy creation payload: " + fmt.Sprintf("%#v\n", desired))
+			}
+			log.Info("S3 policy creation attempt: " + val.Name)
+			err = oc.CreateS3Policy(uuid, jsonPayload)
+			if err != nil {
+				log.Error(err, "Error occurred when creating S3 policy: "+val.Name)
+				return managed, err
+			}
+			log.Info("S3 policy creation successful: " + val.Name)
+			managed = append(managed, val.Name)
+			continue
+		}
+
+		if current.Comment == desired.Comment && s3PolicyStatementsEqual(desired.Statements, current.Statements) {
+			continue
+		}
+
+		// the policy name is the key and can't be patched
+		desired.Name = ""
+		jsonPayload, err := json.Marshal(desired)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for S3 policy update: "+val.Name)
+			return managed, err
+		}
+		if oc.Debug {
+			log.Info("[DEBUG] S3 policy update payload: " + fmt.Sprintf("%#v\n", desired))
+		}
+		log.Info("S3 policy update attempt: " + val.Name)
+		err = oc.PatchS3Policy(uuid, val.Name, jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when updating S3 policy: "+val.Name)
+			return managed, err
+		}
+		log.Info("S3 policy update successful: " + val.Name)
+	}
+
+	// Delete the policies created by the operator that are not defined in the custom resource
+	for _, val := range policiesRetrieved.Records {
+		if boolValue(val.ReadOnly) || (val.Comment != defaultComment && !slices.Contains(created, val.Name)) {
+			continue
+		}
+		defined := false
+		for _, policy := range svmCR.Spec.S3Config.Policies {
+			if policy.Name == val.Name {
+				defined = true
+				break
+			}
+		}
+		if defined {
+			continue
+		}
+		log.Info("S3 policy delete attempt: " + val.Name)
+		err = oc.DeleteS3Policy(uuid, val.Name)
+		if err != nil {
+			log.Error(err, "Error occurred when deleting S3 policy: "+val.Name)
+			// don't requeue on failed delete request - policies still attached to a group can't be deleted
+		} else {
+			log.Info("S3 policy delete successful: " + val.Name)
+			managed = slices.DeleteFunc(managed, func(name string) bool { return name == val.Name })
+		}
+	}
+
+	return managed, nil
+}
+
+func S3PolicyFromSpec(policy gateway.S3Policy) ontap.S3Policy {
+	result := ontap.S3Policy{
+		Name:       policy.Name,
+		Comment:    s3Comment(policy.Comment),
+		Statements: []ontap.S3PolicyStatement{},
+	}
+	for _, val := range policy.Statements {
+		effect := val.Effect
+		if effect == "" {
+			effect = "allow" //magic word
+		}
+		result.Statements = append(result.Statements, ontap.S3PolicyStatement{
+			Sid:       val.Sid,
+			Effect:    effect,
+			Actions:   val.Actions,
+			Resources: val.Resources,
+		})
+	}
+	return result
+}
+
+// s3PolicyStatementsEqual compares the statements in order, ignoring the order of the values within a statement
+func s3PolicyStatementsEqual(desired []ontap.S3PolicyStatement, current []ontap.S3PolicyStatement) bool {
+	if len(desired) != len(current) {
+		return false
+	}
+	for i, val := range desired {
+		if val.Sid != "" && val.Sid != current[i].Sid {
+			return false
+		}
+		if val.Effect != current[i].Effect ||
+			!sameValues(val.Actions, current[i].Actions) ||
+			!sameValues(val.Resources, current[i].Resources) {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileS3Groups creates and patches the groups defined in the custom resource
+// and deletes the groups created by the operator that are not defined - those in created or with the operator's comment
+// it returns the names of the groups created by the operator that are still on the SVM, also after an error
+func (r *StorageVirtualMachineReconciler) reconcileS3Groups(svmCR *gateway.StorageVirtualMachine, created []string,
+	uuid string, oc *ontap.Client, log logr.Logger) ([]string, error) {
+
+	groupsRetrieved, err := oc.GetS3GroupsBySvmUuid(uuid)
+	if err != nil {
+		log.Error(err, "Error getting S3 groups for SVM: "+uuid+" - requeuing")
+		return created, err
+	}
+
+	// groups deleted by others are no longer recorded
+	managed := slices.DeleteFunc(slices.Clone(created), func(name string) bool {
+		return !slices.ContainsFunc(groupsRetrieved.Records, func(group ontap.S3Group) bool { return group.Name == name })
+	})
+
+	principals := s3UserPrincipals(svmCR)
+	for _, val := range svmCR.Spec.S3Config.Groups {
+		users := s3ExpandPrincipals(val.Users, principals)
+		desired := ontap.S3Group{
+			Name:     val.Name,
+			Comment:  s3Comment(val.Comment),
+			Users:    s3NameRefs(users),
+			Policies: s3NameRefs(val.Policies),
+		}
+
+		var current *ontap.S3Group
+		for j := range groupsRetrieved.Records {
+			if groupsRetrieved.Records[j].Name == val.Name {
+				current = &groupsRetrieved.Records[j]
+				break
+			}
+		}
+
+		if current == nil {
+			jsonPayload, err := json.Marshal(desired)
+			if err != nil {
+				log.Error(err, "Error creating the json payload for S3 group creation: "+val.Name)
+				return managed, err
+			}
+			if oc.Debug {
+				log.Info("[DEBUG] S3 group creation payload: " + fmt.Sprintf("%#v\n", desired))
+			}
+			log.Info("S3 group creation attempt: " + val.Name)
+			err = oc.CreateS3Group(uuid, jsonPayload)
+			if err != nil {
+				log.Error(err, "Error occurred when creating S3 group: "+val.Name)
+				return managed, err
+			}
+			log.Info("S3 group creation successful: " + val.Name)
+			managed = append(managed, val.Name)
+			continue
+		}
+
+		if current.Comment == desired.Comment &&
+			sameValues(s3Names(current.Users), users) &&
+			sameValues(s3Names(current.Policies), val.Policies) {
+			continue
+		}
+
+		jsonPayload, err := json.Marshal(desired)
+		if err != nil {
+			log.Error(err, "Error creating the json payload for S3 group update: "+val.Name)
+			return managed, err
+		}
+		if oc.Debug {
+			log.Info("[DEBUG] S3 group update payload: " + fmt.Sprintf("%#v\n", desired))
+		}
+		log.Info("S3 group update attempt: " + val.Name)
+		err = oc.PatchS3Group(uuid, current.Id, jsonPayload)
+		if err != nil {
+			log.Error(err, "Error occurred when updating S3 group: "+val.Name)
+			return managed, err
+		}
+		log.Info("S3 group update successful: " + val.Name)
+	}
+
+	// Delete the groups created by the operator that are not defined in the custom resource
+	for _, val := range groupsRetrieved.Records {
+		if val.Comment != defaultComment && !slices.Contains(created, val.Name) {
+			continue
+		}
+		defined := false
+		for _, group := range svmCR.Spec.S3Config.Groups {
+			if group.Name == val.Name {
+				defined = true
+				break
+			}
+		}
+		if defined {
+			continue
+		}
+		log.Info("S3 group delete attempt: " + val.Name)
+		err = oc.DeleteS3Group(uuid, val.Id)
+		if err != nil {
+			log.Error(err, "Error occurred when deleting S3 group: "+val.Name)
+			// don't requeue on failed delete request
+		} else {
+			log.Info("S3 group delete successful: " + val.Name)
+			managed = slices.DeleteFunc(managed, func(name string) bool { return name == val.Name })
+		}
+	}
+
+	return managed, nil
+}
+
+// s3Comment returns the comment of a group or policy - without one in the custom resource
+// the default comment marks it as created by the operator, also when it isn't recorded in the status
+func s3Comment(comment string) string {
+	if comment == "" {
+		return defaultComment
+	}
+	return comment
+}
+
+// s3UserPrincipals maps the users managed by the operator to the ONTAP users holding their valid keys
+// groups and bucket policies grant all of them, so the previous keys keep their access during a grace period
+func s3UserPrincipals(svmCR *gateway.StorageVirtualMachine) map[string][]string {
+	principals := make(map[string][]string)
+	if svmCR.Status.S3 == nil {
+		return principals
+	}
+	for _, val := range svmCR.Status.S3.Users {
+		principals[val.Name] = s3OntapUsers(val)
+	}
+	return principals
+}
+
+// s3ExpandPrincipals replaces the users managed by the operator with their ONTAP users
+func s3ExpandPrincipals(names []string, principals map[string][]string) []string {
+	var result []string
+	for _, val := range names {
+		if ontapUsers, ok := principals[val]; ok {
+			result = append(result, ontapUsers...)
+		} else {
+			result = append(result, val)
+		}
+	}
+	return result
+}
+
+// s3RotatePrincipals returns the policy with the ONTAP users of the users managed by the operator
+// replaced by those currently holding their valid keys
+func s3RotatePrincipals(policy *ontap.S3BucketPolicy, principals map[string][]string) *ontap.S3BucketPolicy {
+	result := &ontap.S3BucketPolicy{Statements: []ontap.S3BucketPolicyStatements{}}
+	for _, val := range policy.Statements {
+		var names []string
+		var users []string
+		for _, principal := range val.Principals {
+			name := principal
+			if _, ok := principals[name]; !ok {
+				name = strings.TrimSuffix(principal, S3RotatedUserSuffix)
+			}
+			if _, ok := principals[name]; ok {
+				if !slices.Contains(users, name) {
+					users = append(users, name)
+				}
+				continue
+			}
+			names = append(names, principal)
+		}
+		val.Principals = append(names, s3ExpandPrincipals(users, principals)...)
+		result.Statements = append(result.Statements, val)
+	}
+	return result
+}
+
+func s3NameRefs(names []string) []ontap.S3NameRef {
+	refs := []ontap.S3NameRef{}
+	for _, val := range names {
+		refs = append(refs, ontap.S3NameRef{Name: val})
+	}
+	return refs
+}
+
+func s3Names(refs []ontap.S3NameRef) []string {
+	var names []string
+	for _, val := range refs {
+		names = append(names, val.Name)
+	}
+	return names
+}
+
+// S3BucketFromSpec returns the bucket creation payload for the custom resource's bucket definition
+// without a policy in the definition, all S3 users get read/write access to the bucket
+// principals maps the users to the ONTAP users holding their keys
+// releases before NAS buckets don't know the bucket type and only create S3 buckets
+func S3BucketFromSpec(definedBucket gateway.S3Bucket, users []gateway.S3User, principals map[string][]string,
+	capabilities ontap.Capabilities) ontap.S3Bucket {
+	var newBucket ontap.S3Bucket
+	newBucket.Name = definedBucket.Name
+	if definedBucket.Type != "" {
+		newBucket.Type = definedBucket.Type
+	} else {
+		newBucket.Type = "s3" //magic word
+	}
+
+	// NAS buckets use the size of the volume behind their path
+	if newBucket.Type != "nas" { //magic word
+		if definedBucket.Size > 102005473280 {
+			newBucket.Size = definedBucket.Size
+		} else {
+			newBucket.Size = 102005473280 //magic word - 95GiB
+		}
+	}
+
+	if definedBucket.Comment != "" {
+		newBucket.Comment = definedBucket.Comment
+	} else {
+		newBucket.Comment = "Gateway created" //magic word
+	}
+
+	if definedBucket.Policy != nil {
+		newBucket.Policy = S3BucketPolicyFromSpec(definedBucket.Policy, principals)
+	} else {
+		var newBucketStatement ontap.S3BucketPolicyStatements
+		newBucketStatement.Effect = "allow"                                                           //magic word
+		newBucketStatement.Actions = []string{"ListBucket", "GetObject", "PutObject", "DeleteObject"} //magic word
+
+		var names []string
+		for _, val := range users {
+			names = append(names, val.Name)
+		}
+		newBucketStatement.Principals = s3ExpandPrincipals(names, principals)
+		var resources []string
+		resources = append(resources, newBucket.Name)
+		resources = append(resources, newBucket.Name+"/*")
+		newBucketStatement.Resources = resources
+
+		newBucket.Policy = &ontap.S3BucketPolicy{}
+		newBucket.Policy.Statements = append(newBucket.Policy.Statements, newBucketStatement)
+	}
+
+	newBucket.VersioningState = definedBucket.Versioning
+
+	if definedBucket.LifecycleRules != nil {
+		newBucket.LifecycleManagement = S3BucketLifecycleFromSpec(definedBucket.LifecycleRules)
+	}
+
+	if definedBucket.Retention != nil {
+		newBucket.Retention = &ontap.S3BucketRetention{
+			Mode:          definedBucket.Retention.Mode,
+			DefaultPeriod: definedBucket.Retention.DefaultPeriod,
+		}
+	}
+
+	if !capabilities.Supports(ontap.FeatureS3NasBuckets) {
+		newBucket.Type = ""
+	}
+
+	return newBucket
+}
+
+func S3BucketPolicyFromSpec(policy *gateway.S3BucketPolicy, principals map[string][]string) *ontap.S3BucketPolicy {
+	result := &ontap.S3BucketPolicy{Statements: []ontap.S3BucketPolicyStatements{}}
+	for _, val := range policy.Statements {
+		effect := val.Effect
+		if effect == "" {
+			effect = "allow" //magic word
+		}
+		result.Statements = append(result.Statements, ontap.S3BucketPolicyStatements{
+			Sid:        val.Sid,
+			Effect:     effect,
+			Actions:    val.Actions,
+			Principals: s3ExpandPrincipals(val.Principals, principals),
+			Resources:  val.Resources,
+		})
+	}
+	return result
+}
+
+func S3BucketLifecycleFromSpec(rules []gateway.S3LifecycleRule) *ontap.S3BucketLifecycleManagement {
+	result := &ontap.S3BucketLifecycleManagement{Rules: []ontap.S3BucketLifecycleRule{}}
+	for _, val := range rules {
+		enabled := true
+		if val.Enabled != nil {
+			enabled = *val.Enabled
+		}
+		rule := ontap.S3BucketLifecycleRule{Name: val.Name, Enabled: &enabled}
+		if val.Prefix != "" {
+			rule.ObjectFilter = &ontap.S3BucketLifecycleObjectFilter{Prefix: val.Prefix}
+		}
+		if val.ExpirationDays != nil {
+			rule.Expiration = &ontap.S3BucketLifecycleExpiration{ObjectAgeDays: val.ExpirationDays}
+		}
+		if val.NonCurrentExpirationDays != nil {
+			rule.NonCurrentVersionExpiration = &ontap.S3BucketLifecycleNonCurrent{NonCurrentDays: val.NonCurrentExpirationDays}
+		}
+		if val.AbortIncompleteMultipartUploadDays != nil {
+			rule.AbortIncompleteMultipartUpload = &ontap.S3BucketLifecycleAbortMultipart{AfterInitiationDays: val.AbortIncompleteMultipartUploadDays}
+		}
+		result.Rules = append(result.Rules, rule)
+	}
+	return result
+}
+
+// s3BucketChanges returns the patch payload for an existing bucket
+// only the settings provided in the custom resource are compared
+// the ONTAP users of the custom resource's users in the default policy follow their key rotations
+func s3BucketChanges(definedBucket gateway.S3Bucket, currentBucket ontap.S3Bucket, principals map[string][]string,
+	log logr.Logger) (ontap.S3Bucket, bool) {
+	var patchBucket ontap.S3Bucket
+	changed := false
+
+	if definedBucket.Policy != nil {
+		desired := S3BucketPolicyFromSpec(definedBucket.Policy, principals)
+		if !s3BucketPolicyEqual(desired, currentBucket.Policy) {
+			log.Info("S3 bucket " + definedBucket.Name + " policy changed")
+			patchBucket.Policy = desired
+			changed = true
+		}
+	} else if currentBucket.Policy != nil {
+		desired := s3RotatePrincipals(currentBucket.Policy, principals)
+		if !s3BucketPolicyEqual(desired, currentBucket.Policy) {
+			log.Info("S3 bucket " + definedBucket.Name + " policy users changed after a key rotation")
+			patchBucket.Policy = desired
+			changed = true
+		}
+	}
+
+	if definedBucket.Versioning != "" && definedBucket.Versioning != currentBucket.VersioningState {
+		log.Info("S3 bucket " + definedBucket.Name + " versioning changed to " + definedBucket.Versioning)
+		patchBucket.VersioningState = definedBucket.Versioning
+		changed = true
+	}
+
+	if definedBucket.LifecycleRules != nil {
+		desired := S3BucketLifecycleFromSpec(definedBucket.LifecycleRules)
+		if !s3BucketLifecycleEqual(desired, currentBucket.LifecycleManagement) {
+			log.Info("S3 bucket " + definedBucket.Name + " lifecycle rules changed")
+			patchBucket.LifecycleManagement = desired
+			changed = true
+		}
+	}
+
+	if definedBucket.Retention != nil {
+		currentRetention := ontap.S3BucketRetention{}
+		if currentBucket.Retention != nil {
+			currentRetention = *currentBucket.Retention
+		}
+		if definedBucket.Retention.Mode != "" && definedBucket.Retention.Mode != currentRetention.Mode {
+			// ONTAP only sets the locking mode when the bucket is created
+			log.Info("S3 bucket " + definedBucket.Name + " retention mode is " + currentRetention.Mode +
+				" and can't be changed to " + definedBucket.Retention.Mode + " - ignoring")
+		}
+		if definedBucket.Retention.DefaultPeriod != "" &&
+			!strings.EqualFold(definedBucket.Retention.DefaultPeriod, currentRetention.DefaultPeriod) {
+			log.Info("S3 bucket " + definedBucket.Name + " default retention period changed to " + definedBucket.Retention.DefaultPeriod)
+			patchBucket.Retention = &ontap.S3BucketRetention{DefaultPeriod: definedBucket.Retention.DefaultPeriod}
+			changed = true
+		}
+	}
+
+	return patchBucket, changed
+}
+
+// s3BucketPolicyEqual compares the statements in order, ignoring the order of the values within a statement
+func s3BucketPolicyEqual(desired *ontap.S3BucketPolicy, current *ontap.S3BucketPolicy) bool {
+	var currentStatements []ontap.S3BucketPolicyStatements
+	if current != nil {
+		currentStatements = current.Statements
+	}
+	if len(desired.Statements) != len(currentStatements) {
+		return false
+	}
+	for i, val := range desired.Statements {
+		if val.Sid != "" && val.Sid != currentStatements[i].Sid {
+			return false
+		}
+		if val.Effect != currentStatements[i].Effect ||
+			!sameValues(val.Actions, currentStatements[i].Actions) ||
+			!sameValues(val.Principals, currentStatements[i].Principals) ||
+			!sameValues(val.Resources, currentStatements[i].Resources) {
+			return false
+		}
+	}
+	return true
+}
+
+// s3BucketLifecycleEqual compares the rules by name
+func s3BucketLifecycleEqual(desired *ontap.S3BucketLifecycleManagement, current *ontap.S3BucketLifecycleManagement) bool {
+	var currentRules []ontap.S3BucketLifecycleRule
+	if current != nil {
+		currentRules = current.Rules
+	}
+	if len(desired.Rules) != len(currentRules) {
+		return false
+	}
+	for _, val := range desired.Rules {
+		found := false
+		for _, rule := range currentRules {
+			if rule.Name != val.Name {
+				continue
+			}
+			found = true
+			if boolDiffers(rule.Enabled, *val.Enabled) {
+				return false
+			}
+			var desiredPrefix, currentPrefix string
+			if val.ObjectFilter != nil {
+				desiredPrefix = val.ObjectFilter.Prefix
+			}
+			if rule.ObjectFilter != nil {
+				currentPrefix = rule.ObjectFilter.Prefix
+			}
+			if desiredPrefix != currentPrefix {
+				return false
+			}
+			var desiredDays, currentDays *int
+			if val.Expiration != nil {
+				desiredDays = val.Expiration.ObjectAgeDays
+			}
+			if rule.Expiration != nil {
+				currentDays = rule.Expiration.ObjectAgeDays
+			}
+			if !sameDays(desiredDays, currentDays) {
+				return false
+			}
+			desiredDays, currentDays = nil, nil
+			if val.NonCurrentVersionExpiration != nil {
+				desiredDays = val.NonCurrentVersionExpiration.NonCurrentDays
+			}
+			if rule.NonCurrentVersionExpiration != nil {
+				currentDays = rule.NonCurrentVersionExpiration.NonCurrentDays
+			}
+			if !sameDays(desiredDays, currentDays) {
+				return false
+			}
+			desiredDays, currentDays = nil, nil
+			if val.AbortIncompleteMultipartUpload != nil {
+				desiredDays = val.AbortIncompleteMultipartUpload.AfterInitiationDays
+			}
+			if rule.AbortIncompleteMultipartUpload != nil {
+				currentDays = rule.AbortIncompleteMultipartUpload.AfterInitiationDays
+			}
+			if !sameDays(desiredDays, currentDays) {
+				return false
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func sameDays(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 // STEP 16
 // S3 update
 // Note: Status of S3_SERVICE can only be true or false
@@ -579,6 +1515,78 @@ func (reconciler *StorageVirtualMachineReconciler) setConditionS3Cert(ctx contex
 	return nil
 }
 
+const CONDITION_REASON_S3_POLICY = "S3policy"
+const CONDITION_MESSAGE_S3_POLICY_TRUE = "S3 policy configuration succeeded"
+const CONDITION_MESSAGE_S3_POLICY_FALSE = "S3 policy configuration failed"
+
+func (reconciler *StorageVirtualMachineReconciler) setConditionS3Policy(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus) error {
+
+	// I don't want to delete old references to updates to make a history
+	// if reconciler.containsCondition(ctx, svmCR, CONDITION_REASON_S3_POLICY) {
+	// 	reconciler.deleteCondition(ctx, svmCR, CONDITION_TYPE_S3_SERVICE, CONDITION_REASON_S3_POLICY)
+	// }
+
+	if status == CONDITION_STATUS_TRUE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_S3_SERVICE, status,
+			CONDITION_REASON_S3_POLICY, CONDITION_MESSAGE_S3_POLICY_TRUE)
+	}
+
+	if status == CONDITION_STATUS_FALSE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_S3_SERVICE, status,
+			CONDITION_REASON_S3_POLICY, CONDITION_MESSAGE_S3_POLICY_FALSE)
+	}
+	return nil
+}
+
+const CONDITION_REASON_S3_GROUP = "S3group"
+const CONDITION_MESSAGE_S3_GROUP_TRUE = "S3 group configuration succeeded"
+const CONDITION_MESSAGE_S3_GROUP_FALSE = "S3 group configuration failed"
+
+func (reconciler *StorageVirtualMachineReconciler) setConditionS3Group(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus) error {
+
+	// I don't want to delete old references to updates to make a history
+	// if reconciler.containsCondition(ctx, svmCR, CONDITION_REASON_S3_GROUP) {
+	// 	reconciler.deleteCondition(ctx, svmCR, CONDITION_TYPE_S3_SERVICE, CONDITION_REASON_S3_GROUP)
+	// }
+
+	if status == CONDITION_STATUS_TRUE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_S3_SERVICE, status,
+			CONDITION_REASON_S3_GROUP, CONDITION_MESSAGE_S3_GROUP_TRUE)
+	}
+
+	if status == CONDITION_STATUS_FALSE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_S3_SERVICE, status,
+			CONDITION_REASON_S3_GROUP, CONDITION_MESSAGE_S3_GROUP_FALSE)
+	}
+	return nil
+}
+
+const CONDITION_REASON_S3_NAMEMAPPING = "S3namemapping"
+const CONDITION_MESSAGE_S3_NAMEMAPPING_TRUE = "S3 name mapping configuration succeeded"
+const CONDITION_MESSAGE_S3_NAMEMAPPING_FALSE = "S3 name mapping configuration failed"
+
+func (reconciler *StorageVirtualMachineReconciler) setConditionS3NameMapping(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus) error {
+
+	// I don't want to delete old references to updates to make a history
+	// if reconciler.containsCondition(ctx, svmCR, CONDITION_REASON_S3_NAMEMAPPING) {
+	// 	reconciler.deleteCondition(ctx, svmCR, CONDITION_TYPE_S3_SERVICE, CONDITION_REASON_S3_NAMEMAPPING)
+	// }
+
+	if status == CONDITION_STATUS_TRUE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_S3_SERVICE, status,
+			CONDITION_REASON_S3_NAMEMAPPING, CONDITION_MESSAGE_S3_NAMEMAPPING_TRUE)
+	}
+
+	if status == CONDITION_STATUS_FALSE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_S3_SERVICE, status,
+			CONDITION_REASON_S3_NAMEMAPPING, CONDITION_MESSAGE_S3_NAMEMAPPING_FALSE)
+	}
+	return nil
+}
+
 const CONDITION_REASON_S3_BUCKET = "S3bucket"
 const CONDITION_MESSAGE_S3_BUCKET_TRUE = "S3 bucket configuration succeeded"
 const CONDITION_MESSAGE_S3_BUCKET_FALSE = "S3 bucket configuration failed"
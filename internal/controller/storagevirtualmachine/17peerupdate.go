@@ -6,20 +6,19 @@ import (
 	"fmt"
 	gateway "gateway/api/v1beta3"
 	"gateway/internal/controller/ontap"
+	"slices"
 	"strings"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-const InterclusterLifServicePolicy = "default-intercluster" //magic word
-const InterclusterLifServicePolicyScope = "cluster"         //magic word
-const ClusterPeerAvailable = "available"                    //magic word
-const SvmPeerPending = "pending"                            //magic word
-const SvmPeerPeered = "peered"                              //magic word
+const InterclusterLifServicePolicyScope = "cluster" //magic word
+const ClusterPeerAvailable = "available"            //magic word
+const SvmPeerPending = "pending"                    //magic word
+const SvmPeerPeered = "peered"                      //magic word
 
 func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
 	uuid string, oc *ontap.Client, log logr.Logger) error {
@@ -27,43 +26,83 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 	log.Info("STEP 17: Update Peering service")
 
 	// Check to see if Peer configuration is provided in custom resource
-	if svmCR.Spec.PeerConfig == nil {
+	peers := peerConfigs(svmCR)
+	if len(peers) == 0 && len(svmCR.Status.Peers) == 0 {
 		// If not, exit with no error
 		log.Info("No Peering service defined - skipping STEP 17")
 		return nil
 	}
 
+	// Each relationship is reconciled on its own so one waiting peer doesn't hold back the others
+	var peerErr error
+	for i := range peers {
+		err := r.reconcilePeer(ctx, svmCR, &peers[i], uuid, oc, log)
+		if err != nil && peerErr == nil {
+			peerErr = err
+		}
+	}
+
+	r.deleteRemovedPeers(ctx, svmCR, peers, oc, log)
+
+	return peerErr
+}
+
+// peerConfigs returns the peer relationships of the custom resource
+// the deprecated peer is included unless peers holds a relationship with the same name
+func peerConfigs(svmCR *gateway.StorageVirtualMachine) []gateway.PeerSubSpec {
+	var peers []gateway.PeerSubSpec
+	if svmCR.Spec.PeerConfig != nil {
+		legacy := true
+		for _, val := range svmCR.Spec.Peers {
+			if val.Name == svmCR.Spec.PeerConfig.Name {
+				legacy = false
+			}
+		}
+		if legacy {
+			peers = append(peers, *svmCR.Spec.PeerConfig)
+		}
+	}
+	peers = append(peers, svmCR.Spec.Peers...)
+	return peers
+}
+
+// reconcilePeer creates the intercluster LIFs, the cluster peer and the SVM peer of one relationship
+func (r *StorageVirtualMachineReconciler) reconcilePeer(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	peer *gateway.PeerSubSpec, uuid string, oc *ontap.Client, log logr.Logger) error {
+
+	log = log.WithValues("peer", peer.Name)
+
 	// Intercluster LIFS
 
 	// Check to see if Intercluster interfaces are defined in custom resource
-	if svmCR.Spec.PeerConfig.Lifs == nil {
+	if peer.Lifs == nil {
 		// If not, exit with no error
 		log.Info("No Intercluster LIFs defined - skipping updates")
 	} else {
 		createInterclusterLifs := false
 
 		// Check to see if Intercluster interfaces defined and compare to custom resource's definitions
-		lifs, err := oc.GetIpInterfacesByServicePolicy(InterclusterLifServicePolicy)
+		lifs, err := oc.GetIpInterfacesByServicePolicy(r.operatorConfig().ServicePolicies.Intercluster)
 		if err != nil {
 			//error creating the json body
-			log.Error(err, "Error getting Intercluster LIFs for cluster: "+svmCR.Spec.ClusterManagementHost)
-			_ = r.setConditionPeerLif(ctx, svmCR, CONDITION_STATUS_FALSE)
+			log.Error(err, "Error getting Intercluster LIFs for cluster: "+oc.Host)
+			_ = r.setConditionPeerLif(ctx, svmCR, peer.Name, CONDITION_STATUS_FALSE)
 			return err
 		}
 
 		if lifs.NumRecords == 0 {
 			// no Intercluster LIFs i cluster
 			// create new LIF(s)
-			log.Info("No Intercluster LIFs defined for cluster: " + svmCR.Spec.ClusterManagementHost + " - creating Intercluster Lif(s)")
+			log.Info("No Intercluster LIFs defined for cluster: " + oc.Host + " - creating Intercluster Lif(s)")
 			createInterclusterLifs = true
 		}
 
 		if createInterclusterLifs {
 			//creating lifs
-			for _, val := range svmCR.Spec.PeerConfig.Lifs {
-				err = CreateLif(val, InterclusterLifServicePolicy, InterclusterLifServicePolicyScope, uuid, oc, log)
+			for _, val := range peer.Lifs {
+				err = r.createInterclusterLif(ctx, svmCR, val, uuid, oc, log)
 				if err != nil {
-					_ = r.setConditionPeerLif(ctx, svmCR, CONDITION_STATUS_FALSE)
+					_ = r.setConditionPeerLif(ctx, svmCR, peer.Name, CONDITION_STATUS_FALSE)
 					return err
 				}
 			}
@@ -72,7 +111,7 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 			// update LIFs
 			createLif := true
 			currentLifIndex := -1
-			for _, val := range svmCR.Spec.PeerConfig.Lifs {
+			for _, val := range peer.Lifs {
 
 				for i, lif := range lifs.Records {
 					if val.IPAddress == lif.Ip.Address {
@@ -85,17 +124,17 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 
 				if createLif {
 					// Need to create LIF for val
-					err = CreateLif(val, InterclusterLifServicePolicy, InterclusterLifServicePolicyScope, uuid, oc, log)
+					err = r.createInterclusterLif(ctx, svmCR, val, uuid, oc, log)
 					if err != nil {
-						_ = r.setConditionPeerLif(ctx, svmCR, CONDITION_STATUS_FALSE)
+						_ = r.setConditionPeerLif(ctx, svmCR, peer.Name, CONDITION_STATUS_FALSE)
 						r.Recorder.Event(svmCR, "Warning", "PeerCreationLifFailed", "Error: "+err.Error())
 						return err
 					}
 
 				} else {
-					err = UpdateLif(val, lifs.Records[currentLifIndex], InterclusterLifServicePolicy, oc, log)
+					err = UpdateLif(val, lifs.Records[currentLifIndex], r.operatorConfig().ServicePolicies.Intercluster, oc, log)
 					if err != nil {
-						_ = r.setConditionPeerLif(ctx, svmCR, CONDITION_STATUS_FALSE)
+						_ = r.setConditionPeerLif(ctx, svmCR, peer.Name, CONDITION_STATUS_FALSE)
 						r.Recorder.Event(svmCR, "Warning", "PeerUpdateLifFailed", "Error: "+err.Error())
 						return err
 					}
@@ -106,7 +145,7 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 			}
 
 		} // Checking for NFS LIFs updates
-		_ = r.setConditionPeerLif(ctx, svmCR, CONDITION_STATUS_TRUE)
+		_ = r.setConditionPeerLif(ctx, svmCR, peer.Name, CONDITION_STATUS_TRUE)
 		r.Recorder.Event(svmCR, "Normal", "PeerUpsertLifSucceeded", "Upserted Intercluster LIF(s) successfully")
 	} // LIFs defined in custom resource
 
@@ -114,8 +153,24 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 
 	// CLUSTER PEERING
 
+	settings, err := r.peerSettings(ctx, svmCR, peer, log)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			_ = r.setConditionPeerClusterService(ctx, svmCR, peer.Name, CONDITION_STATUS_FALSE)
+			r.Recorder.Event(svmCR, "Warning", "ClusterPeerCreationFailed", "Error: "+err.Error())
+		}
+		return err
+	}
+	peerStatus := gateway.PeerStatus{Name: peer.Name, Initiator: settings.initiator, PassphraseSecret: settings.passphraseSecret}
+	if current := findPeerStatus(svmCR, peer.Name); current != nil {
+		peerStatus.ClusterPeerState = current.ClusterPeerState
+		peerStatus.RemoteClusterName = current.RemoteClusterName
+		peerStatus.SvmPeerState = current.SvmPeerState
+	}
+
 	log.Info("Check Cluster peer relationship")
 	createClusterPeer := true //default true
+	clusterPeerName := peer.Name
 
 	clusterPeers, err := oc.GetClusterPeers()
 	if err != nil && errors.IsNotFound(err) {
@@ -128,29 +183,44 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 
 	if clusterPeers.NumRecords != 0 {
 		for _, val := range clusterPeers.Records {
-			if val.Name == svmCR.Spec.PeerConfig.Name {
-				for _, ip := range val.Remote.Addresses {
-					if ip == svmCR.Spec.PeerConfig.Remote.Ipaddress {
-						createClusterPeer = false
-					}
+			// complete the inventory once a requested cluster peer shows up
+			if val.Name == peer.Name {
+				r.recordClusterPeer(ctx, svmCR, val)
+			}
+			// relationships to SVMs on the same remote cluster share its cluster peer
+			for _, ip := range val.Remote.Addresses {
+				if slices.Contains(settings.addresses, ip) {
+					createClusterPeer = false
+					clusterPeerName = val.Name
+					peerStatus.ClusterPeerState = val.Status.State
+					peerStatus.RemoteClusterName = val.Remote.Name
 				}
 			}
 		}
 	}
+	peerStatus.ClusterPeerName = clusterPeerName
+	peerStatus.RemoteSvmName = settings.svmName
+	r.reportPeerStatus(ctx, svmCR, peerStatus)
 
 	var upsertClusterPeer ontap.ClusterPeer
 
+	if createClusterPeer && settings.auto && !settings.initiator && settings.remoteClusterPeerState == "" {
+		// accept the cluster peer once the initiator requested it
+		log.Info("Waiting for remote StorageVirtualMachine " + peer.RemoteRef.Name + " to request the cluster peer - requeuing")
+		return errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"}, "waiting for cluster peer")
+	}
+
 	if createClusterPeer {
 
-		log.Info("No Cluster peer defined for cluster: " + svmCR.Spec.PeerConfig.Remote.Ipaddress + " - creating Cluster Peer")
-		upsertClusterPeer.Name = svmCR.Spec.PeerConfig.Name
-		upsertClusterPeer.Authentication.Passphrase = svmCR.Spec.PeerConfig.Passphrase
-		upsertClusterPeer.Encryption.Proposed = svmCR.Spec.PeerConfig.Encryption
-		for _, val := range svmCR.Spec.PeerConfig.Applications {
+		log.Info("No Cluster peer defined for cluster: " + strings.Join(settings.addresses, ",") + " - creating Cluster Peer")
+		upsertClusterPeer.Name = peer.Name
+		upsertClusterPeer.Authentication.Passphrase = settings.passphrase
+		upsertClusterPeer.Encryption.Proposed = peer.Encryption
+		for _, val := range peer.Applications {
 			upsertClusterPeer.Applications = append(upsertClusterPeer.Applications, val.App)
 		}
 
-		upsertClusterPeer.Remote.Addresses = append(upsertClusterPeer.Remote.Addresses, svmCR.Spec.PeerConfig.Remote.Ipaddress)
+		upsertClusterPeer.Remote.Addresses = append(upsertClusterPeer.Remote.Addresses, settings.addresses...)
 
 		var localSVM ontap.SvmRef
 		localSVM.Name = svmCR.Spec.SvmName
@@ -160,7 +230,7 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 		if err != nil {
 			//error creating the json body
 			log.Error(err, "Error creating the json payload for cluster peer creation - requeuing")
-			_ = r.setConditionPeerClusterService(ctx, svmCR, CONDITION_STATUS_FALSE)
+			_ = r.setConditionPeerClusterService(ctx, svmCR, peer.Name, CONDITION_STATUS_FALSE)
 			return err
 		}
 
@@ -168,14 +238,16 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 			log.Info("[DEBUG] Cluster peer creation payload: " + fmt.Sprintf("%#v\n", upsertClusterPeer))
 		}
 
+		r.recordClusterPeerIntent(ctx, svmCR, peer.Name)
 		err = oc.CreateClusterPeer(jsonPayload)
 		if err != nil {
 			if strings.Contains(err.Error(), "context deadline exceeded") || strings.Contains(err.Error(), "An introductory RPC to the peer address") {
 				log.Info("Waiting for cluster peer to respond")
 				return err
 			} else {
+				r.forgetClusterPeerIntent(ctx, svmCR, peer.Name)
 				log.Error(err, "Error creating the cluster peer - requeuing")
-				_ = r.setConditionPeerClusterService(ctx, svmCR, CONDITION_STATUS_FALSE)
+				_ = r.setConditionPeerClusterService(ctx, svmCR, peer.Name, CONDITION_STATUS_FALSE)
 				r.Recorder.Event(svmCR, "Warning", "ClusterPeerCreationFailed", "Error: "+err.Error())
 				return err
 			}
@@ -187,24 +259,14 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 		if clusterPeers.NumRecords != 0 {
 			requeue := true
 			for _, val := range clusterPeers.Records {
-				if val.Status.State == ClusterPeerAvailable {
+				if val.Name == clusterPeerName && val.Status.State == ClusterPeerAvailable {
 					requeue = false
 
-					if svmCR.Spec.PeerConfig.Remote.Clustername == "" {
+					if peer.Remote.Clustername == "" {
+						// the remote cluster name is reported in the status
 						log.Info("Remote cluster " + val.Remote.Name + " accepted")
-						//Add the remote cluster name to CR
-						patch := client.MergeFrom(svmCR.DeepCopy())
-						svmCR.Spec.PeerConfig.Remote.Clustername = val.Remote.Name
-						err = r.Patch(ctx, svmCR, patch)
-						if err != nil {
-							log.Error(err, "Error patching the new cluster peer uuid in the custom resource - requeuing")
-							r.Recorder.Event(svmCR, "Warning", "ClusterPeerCreationFailed", "Error: "+err.Error())
-							_ = r.setConditionSVMCreation(ctx, svmCR, CONDITION_STATUS_FALSE)
-							return err
-						}
-
 					}
-					_ = r.setConditionPeerClusterService(ctx, svmCR, CONDITION_STATUS_TRUE)
+					_ = r.setConditionPeerClusterService(ctx, svmCR, peer.Name, CONDITION_STATUS_TRUE)
 					r.Recorder.Event(svmCR, "Normal", "ClusterPeerCreationSucceeded", "Created cluster peer successfully")
 					log.Info("Cluster peer created successful with remote cluster " + val.Remote.Name)
 				}
@@ -238,29 +300,37 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 
 	if svmPeers.NumRecords != 0 {
 		for _, val := range svmPeers.Records {
-			if val.Peer.Cluster.Name == svmCR.Spec.PeerConfig.Name {
+			if val.Peer.Cluster.Name == clusterPeerName && val.Peer.Svm.Name == settings.svmName {
 				createSvmPeer = false
+				peerStatus.SvmPeerState = val.State
 			}
 		}
 	}
+	r.reportPeerStatus(ctx, svmCR, peerStatus)
 
 	var upsertSvmPeer ontap.SvmPeer
 
+	if createSvmPeer && settings.auto && !settings.initiator {
+		// accept the SVM peer once the initiator requested it
+		log.Info("Waiting for remote StorageVirtualMachine " + peer.RemoteRef.Name + " to request the SVM peer - requeuing")
+		return errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"}, "waiting for SVM peer")
+	}
+
 	if createSvmPeer {
 
-		log.Info("No SVM peer for remote cluster " + svmCR.Spec.PeerConfig.Remote.Clustername + " and local SVM " + svmCR.Spec.SvmName + " - creating SVM peer")
-		for _, val := range svmCR.Spec.PeerConfig.Applications {
+		log.Info("No SVM peer for remote cluster " + clusterPeerName + " and local SVM " + svmCR.Spec.SvmName + " - creating SVM peer")
+		for _, val := range peer.Applications {
 			upsertSvmPeer.Applications = append(upsertSvmPeer.Applications, val.App)
 		}
 		upsertSvmPeer.LocalSvm.Name = svmCR.Spec.SvmName
-		upsertSvmPeer.Peer.Cluster.Name = svmCR.Spec.PeerConfig.Name
-		upsertSvmPeer.Peer.Svm.Name = svmCR.Spec.PeerConfig.Remote.Svmname
+		upsertSvmPeer.Peer.Cluster.Name = clusterPeerName
+		upsertSvmPeer.Peer.Svm.Name = settings.svmName
 
 		jsonPayload, err := json.Marshal(upsertSvmPeer)
 		if err != nil {
 			//error creating the json body
 			log.Error(err, "Error creating the json payload for SVM peer creation - requeuing")
-			_ = r.setConditionPeerSvmService(ctx, svmCR, CONDITION_STATUS_FALSE)
+			_ = r.setConditionPeerSvmService(ctx, svmCR, peer.Name, CONDITION_STATUS_FALSE)
 			return err
 		}
 
@@ -275,7 +345,7 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 				return err
 			} else {
 				log.Error(err, "Error creating the SVM peer - requeuing")
-				_ = r.setConditionPeerSvmService(ctx, svmCR, CONDITION_STATUS_FALSE)
+				_ = r.setConditionPeerSvmService(ctx, svmCR, peer.Name, CONDITION_STATUS_FALSE)
 				r.Recorder.Event(svmCR, "Warning", "SvmPeerCreationFailed", "Error: "+err.Error())
 				return err
 			}
@@ -285,9 +355,14 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 		return errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"}, "waiting for SVM peer")
 	} else {
 		if svmPeers.NumRecords != 0 {
+			remoteClusterName := peer.Remote.Clustername
+			if remoteClusterName == "" {
+				remoteClusterName = peerStatus.RemoteClusterName
+			}
 			requeue := true
 			for _, val := range svmPeers.Records {
-				if val.State == SvmPeerPending && val.Peer.Cluster.Name == svmCR.Spec.PeerConfig.Remote.Clustername {
+				if val.State == SvmPeerPending && (val.Peer.Cluster.Name == clusterPeerName || val.Peer.Cluster.Name == remoteClusterName) &&
+					val.Peer.Svm.Name == settings.svmName {
 					log.Info("Remote SVM " + val.Peer.Svm.Name + "peer request pending - patching")
 
 					//PATCH
@@ -297,7 +372,7 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 					if err != nil {
 						//error creating the json body
 						log.Error(err, "Error creating the json payload for SVM peer patch - requeuing")
-						_ = r.setConditionPeerSvmService(ctx, svmCR, CONDITION_STATUS_FALSE)
+						_ = r.setConditionPeerSvmService(ctx, svmCR, peer.Name, CONDITION_STATUS_FALSE)
 						return err
 					}
 
@@ -308,17 +383,17 @@ func (r *StorageVirtualMachineReconciler) reconcilePeerUpdate(ctx context.Contex
 					err = oc.PatchSvmPeer(jsonPayload, val.Uuid)
 					if err != nil {
 						log.Error(err, "Error patching the SVM peer - requeuing")
-						_ = r.setConditionPeerSvmService(ctx, svmCR, CONDITION_STATUS_FALSE)
+						_ = r.setConditionPeerSvmService(ctx, svmCR, peer.Name, CONDITION_STATUS_FALSE)
 						r.Recorder.Event(svmCR, "Warning", "SvmPeerPatchFailed", "Error: "+err.Error())
 						return err
 					}
 					log.Info("SVM peer patch created successful - requeuing to verify SVM peer")
 					return errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"}, "waiting for SVM peer")
-				} else if val.State == SvmPeerPeered {
+				} else if val.State == SvmPeerPeered && val.Peer.Svm.Name == settings.svmName {
 					requeue = false
-					_ = r.setConditionPeerSvmService(ctx, svmCR, CONDITION_STATUS_TRUE)
+					_ = r.setConditionPeerSvmService(ctx, svmCR, peer.Name, CONDITION_STATUS_TRUE)
 					r.Recorder.Event(svmCR, "Normal", "SvmPeerCreationSucceeded", "Created SVM peer successfully")
-					log.Info("SVM peer created successful with remote SVM: " + svmCR.Spec.PeerConfig.Remote.Svmname)
+					log.Info("SVM peer created successful with remote SVM: " + settings.svmName)
 				}
 
 			}
@@ -346,7 +421,7 @@ const CONDITION_MESSAGE_PEERCLUSTER_SERVICE_TRUE = "Cluster peer configuration s
 const CONDITION_MESSAGE_PEERCLUSTER_SERVICE_FALSE = "Cluster peer configuration failed"
 
 func (reconciler *StorageVirtualMachineReconciler) setConditionPeerClusterService(ctx context.Context,
-	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus) error {
+	svmCR *gateway.StorageVirtualMachine, name string, status metav1.ConditionStatus) error {
 
 	// I don't want to delete old references to updates to make a history
 	// if reconciler.containsCondition(ctx, svmCR, CONDITION_REASON_PEERCLUSTER_SERVICE) {
@@ -355,12 +430,12 @@ func (reconciler *StorageVirtualMachineReconciler) setConditionPeerClusterServic
 
 	if status == CONDITION_STATUS_TRUE {
 		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_PEERCLUSTER_SERVICE, status,
-			CONDITION_REASON_PEERCLUSTER_SERVICE, CONDITION_MESSAGE_PEERCLUSTER_SERVICE_TRUE)
+			CONDITION_REASON_PEERCLUSTER_SERVICE, CONDITION_MESSAGE_PEERCLUSTER_SERVICE_TRUE+": "+name)
 	}
 
 	if status == CONDITION_STATUS_FALSE {
 		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_PEERCLUSTER_SERVICE, status,
-			CONDITION_REASON_PEERCLUSTER_SERVICE, CONDITION_MESSAGE_PEERCLUSTER_SERVICE_FALSE)
+			CONDITION_REASON_PEERCLUSTER_SERVICE, CONDITION_MESSAGE_PEERCLUSTER_SERVICE_FALSE+": "+name)
 	}
 	return nil
 }
@@ -370,7 +445,7 @@ const CONDITION_MESSAGE_PEER_LIF_TRUE = "Peer LIF configuration succeeded"
 const CONDITION_MESSAGE_PEER_LIF_FALSE = "Peer LIF configuration failed"
 
 func (reconciler *StorageVirtualMachineReconciler) setConditionPeerLif(ctx context.Context,
-	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus) error {
+	svmCR *gateway.StorageVirtualMachine, name string, status metav1.ConditionStatus) error {
 
 	// I don't want to delete old references to updates to make a history
 	// if reconciler.containsCondition(ctx, svmCR, CONDITION_REASON_PEER_LIF) {
@@ -379,12 +454,12 @@ func (reconciler *StorageVirtualMachineReconciler) setConditionPeerLif(ctx conte
 
 	if status == CONDITION_STATUS_TRUE {
 		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_PEERCLUSTER_SERVICE, status,
-			CONDITION_REASON_PEER_LIF, CONDITION_MESSAGE_PEER_LIF_TRUE)
+			CONDITION_REASON_PEER_LIF, CONDITION_MESSAGE_PEER_LIF_TRUE+": "+name)
 	}
 
 	if status == CONDITION_STATUS_FALSE {
 		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_PEERCLUSTER_SERVICE, status,
-			CONDITION_REASON_PEER_LIF, CONDITION_MESSAGE_PEER_LIF_FALSE)
+			CONDITION_REASON_PEER_LIF, CONDITION_MESSAGE_PEER_LIF_FALSE+": "+name)
 	}
 	return nil
 }
@@ -395,7 +470,7 @@ const CONDITION_MESSAGE_PEERSVM_SERVICE_TRUE = "SVM peer configuration succeeded
 const CONDITION_MESSAGE_PEERSVM_SERVICE_FALSE = "Cluster peer configuration failed"
 
 func (reconciler *StorageVirtualMachineReconciler) setConditionPeerSvmService(ctx context.Context,
-	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus) error {
+	svmCR *gateway.StorageVirtualMachine, name string, status metav1.ConditionStatus) error {
 
 	// I don't want to delete old references to updates to make a history
 	// if reconciler.containsCondition(ctx, svmCR, CONDITION_REASON_PEERSVM_SERVICE) {
@@ -404,12 +479,12 @@ func (reconciler *StorageVirtualMachineReconciler) setConditionPeerSvmService(ct
 
 	if status == CONDITION_STATUS_TRUE {
 		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_PEERSVM_SERVICE, status,
-			CONDITION_REASON_PEERSVM_SERVICE, CONDITION_MESSAGE_PEERSVM_SERVICE_TRUE)
+			CONDITION_REASON_PEERSVM_SERVICE, CONDITION_MESSAGE_PEERSVM_SERVICE_TRUE+": "+name)
 	}
 
 	if status == CONDITION_STATUS_FALSE {
 		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_PEERSVM_SERVICE, status,
-			CONDITION_REASON_PEERSVM_SERVICE, CONDITION_MESSAGE_PEERSVM_SERVICE_FALSE)
+			CONDITION_REASON_PEERSVM_SERVICE, CONDITION_MESSAGE_PEERSVM_SERVICE_FALSE+": "+name)
 	}
 	return nil
 }
@@ -6,23 +6,57 @@ import (
 	"net/url"
 
 	gateway "gateway/api/v1beta3"
+	ontapcluster "gateway/internal/controller/ontapcluster"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+// reconcileOntapCluster looks up the OntapCluster referenced by the custom resource
+// returns nil when the custom resource uses the deprecated clusterHost
+func (r *StorageVirtualMachineReconciler) reconcileOntapCluster(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, log logr.Logger) (*gateway.OntapCluster, error) {
+
+	if svmCR.Spec.ClusterRef == nil {
+		return nil, nil
+	}
+
+	log.Info("STEP 2: Look up OntapCluster")
+
+	cluster, err := ontapcluster.Lookup(ctx, r.Client, svmCR)
+	if err != nil && errors.IsNotFound(err) {
+		log.Error(err, "OntapCluster "+svmCR.Spec.ClusterRef.Name+" does not exist - waiting for it to be created")
+		_ = r.setConditionHostFound(ctx, svmCR, CONDITION_STATUS_FALSE)
+		return nil, err
+	} else if err != nil {
+		log.Error(err, "Error getting OntapCluster "+svmCR.Spec.ClusterRef.Name+" - requeuing")
+		_ = r.setConditionHostFound(ctx, svmCR, CONDITION_STATUS_UNKNOWN)
+		return nil, err
+	}
+	log.Info("Using OntapCluster: " + cluster.Namespace + "/" + cluster.Name)
+	return cluster, nil
+}
+
 func (r *StorageVirtualMachineReconciler) reconcileClusterHost(ctx context.Context,
-	svmCR *gateway.StorageVirtualMachine, log logr.Logger) (string, error) {
+	svmCR *gateway.StorageVirtualMachine, cluster *gateway.OntapCluster, log logr.Logger) (string, error) {
 
 	log.Info("STEP 2: Identify cluster host")
 
-	// Get cluster management url
+	// Get cluster management url - the OntapCluster's replaces the deprecated clusterHost
 	host := svmCR.Spec.ClusterManagementHost
+	if cluster != nil {
+		host = cluster.Spec.ManagementHost
+	}
 	name := ""
 	if host == "" {
 		err := errors.NewBadRequest("No Cluster Management LIF provided")
-		log.Error(err, "The custom resource has no clusterHost")
+		log.Error(err, "The custom resource has neither clusterRef nor clusterHost")
 		_ = r.setConditionHostFound(ctx, svmCR, CONDITION_STATUS_FALSE)
 		return host, err
 	}
@@ -34,7 +68,7 @@ func (r *StorageVirtualMachineReconciler) reconcileClusterHost(ctx context.Conte
 		if err != nil {
 			log.Error(err, "clusterHost in the custom resource is invalid")
 			_ = r.setConditionHostFound(ctx, svmCR, CONDITION_STATUS_UNKNOWN)
-			return clusterUrl.Host, err
+			return "", err
 		}
 		name = clusterUrl.Host
 	} else {
@@ -85,3 +119,43 @@ func (reconciler *StorageVirtualMachineReconciler) setConditionHostFound(ctx con
 
 	return nil
 }
+
+// ontapClusterToStorageVirtualMachines enqueues the custom resources referencing the OntapCluster
+func (r *StorageVirtualMachineReconciler) ontapClusterToStorageVirtualMachines(ctx context.Context, obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+
+	var svmList gateway.StorageVirtualMachineList
+	err := r.List(ctx, &svmList)
+	if err != nil {
+		return requests
+	}
+	for _, svm := range svmList.Items {
+		if ontapcluster.References(&svm, obj) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: svm.Namespace,
+				Name:      svm.Name,
+			}})
+		}
+	}
+	return requests
+}
+
+// ontapClusterChangedPredicate lets through created and deleted OntapClusters, spec changes
+// and a cluster becoming reachable again or lost, but not the periodic status updates
+var ontapClusterChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldCluster, ok := e.ObjectOld.(*gateway.OntapCluster)
+		if !ok {
+			return false
+		}
+		newCluster, ok := e.ObjectNew.(*gateway.OntapCluster)
+		if !ok {
+			return false
+		}
+		return oldCluster.Generation != newCluster.Generation ||
+			oldCluster.Status.Connected != newCluster.Status.Connected
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
+}
@@ -5,6 +5,8 @@ package controller
 import (
 	"context"
 	gateway "gateway/api/v1beta3"
+	ontapcluster "gateway/internal/controller/ontapcluster"
+	"reflect"
 	"strings"
 
 	"github.com/go-logr/logr"
@@ -12,6 +14,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 const clusterAdminRequest = "ontap-cluster-admin" // magic word
@@ -35,7 +41,7 @@ func (r *StorageVirtualMachineReconciler) reconcileSecret(ctx context.Context, s
 		Namespace: namespace,
 	}, secret)
 	if err != nil && errors.IsNotFound(err) {
-		log.Error(err, "Secret does not exist - not requeuing")
+		log.Error(err, "Secret does not exist - waiting for the secret to be created")
 		if secretType == clusterAdminRequest {
 			_ = r.setConditionClusterSecretLookup(ctx, svmCR, CONDITION_STATUS_FALSE)
 		} else if secretType == svmAdminRequest {
@@ -43,7 +49,7 @@ func (r *StorageVirtualMachineReconciler) reconcileSecret(ctx context.Context, s
 		}
 		return nil, err
 	} else if err != nil {
-		log.Error(err, "Failed to get secret - not requeuing")
+		log.Error(err, "Failed to get secret - requeuing")
 		if secretType == clusterAdminRequest {
 			_ = r.setConditionClusterSecretLookup(ctx, svmCR, CONDITION_STATUS_FALSE)
 		} else if secretType == svmAdminRequest {
@@ -53,24 +59,25 @@ func (r *StorageVirtualMachineReconciler) reconcileSecret(ctx context.Context, s
 	}
 
 	if strings.TrimSpace(string(secret.Data["username"])) == "" {
-
-		log.Error(errors.NewBadRequest("Missing username"), secret.Name+"has no username - not requeuing")
+		err = errors.NewBadRequest("Missing username")
+		log.Error(err, secret.Name+" has no username - waiting for a change of the secret")
 		if secretType == clusterAdminRequest {
 			_ = r.setConditionClusterSecretLookup(ctx, svmCR, CONDITION_STATUS_FALSE)
 		} else if secretType == svmAdminRequest {
 			_ = r.setConditionVsadminSecretLookup(ctx, svmCR, CONDITION_STATUS_FALSE)
 		}
-		return nil, nil
+		return nil, err
 	}
 
 	if strings.TrimSpace(string(secret.Data["password"])) == "" {
-		log.Error(errors.NewBadRequest("Missing password"), secret.Name+"has no password - not requeuing")
+		err = errors.NewBadRequest("Missing password")
+		log.Error(err, secret.Name+" has no password - waiting for a change of the secret")
 		if secretType == clusterAdminRequest {
 			_ = r.setConditionClusterSecretLookup(ctx, svmCR, CONDITION_STATUS_FALSE)
 		} else if secretType == svmAdminRequest {
 			_ = r.setConditionVsadminSecretLookup(ctx, svmCR, CONDITION_STATUS_FALSE)
 		}
-		return nil, nil
+		return nil, err
 	}
 
 	//log.Info("username: " + string(secret.Data["username"]))
@@ -87,6 +94,27 @@ func (r *StorageVirtualMachineReconciler) reconcileSecret(ctx context.Context, s
 	return secret, nil
 }
 
+// getSecret returns the referenced secret
+// the namespace defaults to the custom resource's namespace
+func (r *StorageVirtualMachineReconciler) getSecret(ctx context.Context, ref gateway.NamespacedName,
+	svmCR *gateway.StorageVirtualMachine) (*corev1.Secret, error) {
+
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = svmCR.Namespace
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{
+		Name:      ref.Name,
+		Namespace: namespace,
+	}, secret)
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
 // STEP 3
 // Resolve Secret
 // Note: Status of CLUSTER_SECRET_LOOKUP can only be true or false
@@ -140,3 +168,101 @@ func (reconciler *StorageVirtualMachineReconciler) setConditionVsadminSecretLook
 	}
 	return nil
 }
+
+// secretToStorageVirtualMachines enqueues the custom resource that manages an S3 user secret
+// and every custom resource that references the secret as its credentials, an iSCSI CHAP or NVMe DH-HMAC-CHAP secret or its S3 tls secret,
+// directly or through its OntapCluster
+func (r *StorageVirtualMachineReconciler) secretToStorageVirtualMachines(ctx context.Context, obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+
+	labels := obj.GetLabels()
+	if labels[SvmNameLabel] != "" && labels[SvmNamespaceLabel] != "" {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: labels[SvmNamespaceLabel],
+			Name:      labels[SvmNameLabel],
+		}})
+	}
+
+	var svmList gateway.StorageVirtualMachineList
+	err := r.List(ctx, &svmList)
+	if err != nil {
+		return requests
+	}
+	for _, svm := range svmList.Items {
+		cluster, err := ontapcluster.Lookup(ctx, r.Client, &svm)
+		if err == nil && cluster != nil && ontapcluster.ReferencesSecret(cluster, obj) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: svm.Namespace,
+				Name:      svm.Name,
+			}})
+			continue
+		}
+		refs := []gateway.NamespacedName{svm.Spec.ClusterCredentialSecret, svm.Spec.VsadminCredentialSecret}
+		if svm.Spec.S3Config != nil && svm.Spec.S3Config.Https != nil && svm.Spec.S3Config.Https.TlsSecret != nil {
+			refs = append(refs, *svm.Spec.S3Config.Https.TlsSecret)
+		}
+		if svm.Spec.IscsiConfig != nil {
+			for _, chap := range svm.Spec.IscsiConfig.Chap {
+				if chap.Inbound != nil {
+					refs = append(refs, *chap.Inbound)
+				}
+				if chap.Outbound != nil {
+					refs = append(refs, *chap.Outbound)
+				}
+			}
+		}
+		if svm.Spec.NvmeConfig != nil {
+			for _, subsystem := range svm.Spec.NvmeConfig.Subsystems {
+				for _, host := range subsystem.Hosts {
+					if host.DhHmacChap != nil {
+						refs = append(refs, host.DhHmacChap.Secret)
+					}
+				}
+			}
+		}
+		for _, ref := range refs {
+			namespace := ref.Namespace
+			if namespace == "" {
+				namespace = svm.Namespace
+			}
+			if ref.Name == obj.GetName() && namespace == obj.GetNamespace() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: svm.Namespace,
+					Name:      svm.Name,
+				}})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// secretChangedPredicate lets through deleted S3 user secrets, S3 user secrets marked for rotation,
+// created secrets that a custom resource may be waiting for and secrets whose data changed,
+// for example fixed credentials or a tls secret renewed by cert-manager
+// secrets the operator creates for a custom resource carry its labels and are left out on creation
+var secretChangedPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return e.Object.GetLabels()[SvmNameLabel] == ""
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldSecret, ok := e.ObjectOld.(*corev1.Secret)
+		if !ok {
+			return false
+		}
+		newSecret, ok := e.ObjectNew.(*corev1.Secret)
+		if !ok {
+			return false
+		}
+		if newSecret.Labels[S3UserLabel] != "" {
+			return oldSecret.Annotations[S3RotateKeysAnnotation] != newSecret.Annotations[S3RotateKeysAnnotation]
+		}
+		return !reflect.DeepEqual(oldSecret.Data, newSecret.Data)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return e.Object.GetLabels()[S3UserLabel] != ""
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
+}
@@ -11,16 +11,27 @@ import (
 )
 
 func (r *StorageVirtualMachineReconciler) reconcileGetClient(ctx context.Context,
-	svmCR *gateway.StorageVirtualMachine,
-	adminSecret *corev1.Secret, host string, trustSSL bool,
+	svmCR *gateway.StorageVirtualMachine, ontapCluster *gateway.OntapCluster,
+	adminSecret *corev1.Secret, host string,
 	log logr.Logger) (*ontap.Client, error) {
 
 	log.Info("STEP 4: Create ONTAP client")
 
-	oc, err := ontap.NewClient(
-		string(adminSecret.Data["username"]),
-		string(adminSecret.Data["password"]),
-		host, svmCR.Spec.SvmDebug, trustSSL)
+	var oc *ontap.Client
+	var err error
+	if ontapCluster != nil {
+		// shared with the other custom resources on the OntapCluster
+		oc, err = r.Clients.Client(ctx, r.Client, ontapCluster, adminSecret, r.operatorConfig(), svmCR.Spec.SvmDebug)
+	} else {
+		oc, err = ontap.NewClient(
+			string(adminSecret.Data["username"]),
+			string(adminSecret.Data["password"]),
+			host, svmCR.Spec.SvmDebug || r.operatorConfig().Ontap.Debug, r.operatorConfig().Ontap.TrustSSL)
+		if err == nil {
+			oc.TimeOut = r.operatorConfig().Ontap.Timeout.Duration
+			oc.JobTimeout = r.operatorConfig().Ontap.JobTimeout.Duration
+		}
+	}
 
 	if err != nil {
 		log.Error(err, "Error creating ONTAP client - requeueing")
@@ -31,14 +42,26 @@ func (r *StorageVirtualMachineReconciler) reconcileGetClient(ctx context.Context
 	log.Info("ONTAP client created")
 	_ = r.setConditionONTAPCreation(ctx, svmCR, CONDITION_STATUS_TRUE)
 
-	cluster, err := oc.GetCluster()
+	// the OntapCluster reconciler already checks the connection and detects the version
+	if ontapCluster != nil && ontapCluster.Status.Connected && ontapCluster.Status.Version != "" {
+		version, err := ontap.ParseVersion(ontapCluster.Status.Version)
+		if err == nil {
+			oc.SetClusterVersion(version)
+			log.Info("Using cluster: " + host + " reporting ONTAP version: " + version.String())
+			r.reconcileCapabilities(ctx, svmCR, oc.Capabilities(), log)
+			return oc, nil
+		}
+	}
+
+	version, err := oc.ClusterVersion()
 	if err != nil {
 		log.Error(err, "Error retrieving cluster - requeuing")
 		return oc, err
 	}
 
 	log.Info("Connected to cluster: " + host)
-	log.Info("Cluster reporting ONTAP version: " + cluster.Version.Full)
+	log.Info("Cluster reporting ONTAP version: " + version.String())
+	r.reconcileCapabilities(ctx, svmCR, oc.Capabilities(), log)
 
 	return oc, nil
 
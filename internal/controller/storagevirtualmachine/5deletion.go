@@ -21,7 +21,26 @@ import (
 )
 
 const finalizerName = "gateway.netapp.com/finalizer" //magic word
-const checkingNumber = 5                             //magic number
+
+// reconcileDeletionWithoutCluster removes the finalizer of a custom resource whose OntapCluster is gone
+// the SVM in ONTAP can't be reached, so it is left in place whatever the deletion policy
+func (r *StorageVirtualMachineReconciler) reconcileDeletionWithoutCluster(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, log logr.Logger) (ctrl.Result, error) {
+
+	log.Info("STEP 5: OntapCluster " + svmCR.Spec.ClusterRef.Name + " does not exist - removing finalizer without deleting the SVM in ONTAP")
+	if !controllerutil.ContainsFinalizer(svmCR, finalizerName) {
+		return ctrl.Result{}, nil
+	}
+	r.Recorder.Event(svmCR, "Warning", "SvmDeletionSkipped",
+		"OntapCluster "+svmCR.Spec.ClusterRef.Name+" does not exist - SVM "+svmCR.Spec.SvmName+" is left in ONTAP")
+	controllerutil.RemoveFinalizer(svmCR, finalizerName)
+	err := r.Update(ctx, svmCR)
+	if err != nil {
+		log.Error(err, "Error during removal of finalizer - requeuing")
+		return requeueTransient(err)
+	}
+	return ctrl.Result{}, nil
+}
 
 func (r *StorageVirtualMachineReconciler) reconcileDeletions(ctx context.Context,
 	svmCR *gateway.StorageVirtualMachine, oc *ontap.Client, log logr.Logger) (ctrl.Result, error) {
@@ -69,9 +88,11 @@ func (r *StorageVirtualMachineReconciler) finalizeSVM(ctx context.Context,
 
 	if svmCR.Spec.SvmDeletionPolicy == gateway.DeletionPolicyDelete {
 
+		peers := peerConfigs(svmCR)
+
 		//check to see if SVM peering is present and defined by custom resource
-		if svmCR.Spec.PeerConfig != nil {
-			for i := 0; i < checkingNumber; i++ {
+		if len(peers) > 0 {
+			for i := 0; i < r.operatorConfig().Deletion.CheckAttempts; i++ {
 				log.Info(fmt.Sprintf("Checking for SVM peers - attempt %v", i+1))
 				svmPeerServices, err := oc.GetSvmPeers(svmCR.Spec.SvmName)
 				if err != nil && errors.IsNotFound(err) {
@@ -92,74 +113,22 @@ func (r *StorageVirtualMachineReconciler) finalizeSVM(ctx context.Context,
 					}
 				}
 
-				if (i + 1) == checkingNumber {
+				if (i + 1) == r.operatorConfig().Deletion.CheckAttempts {
 					//maximum attempts reached - force another reconciliation
 					return errors.NewTooManyRequests(fmt.Sprintf("SVM peers still present after %v attempts - re-reconciling", i+1), 1)
 				}
 
-				time.Sleep(5 * time.Second) //wait 5 seconds before checking again
-			}
-		}
-
-		//check to see if cluster peering is present and defined by custom resource
-		if svmCR.Spec.PeerConfig != nil {
-			for i := 0; i < checkingNumber; i++ {
-				log.Info(fmt.Sprintf("Checking for cluster peers - attempt %v", i+1))
-				clusterPeerServices, err := oc.GetClusterPeers()
-				if err != nil && errors.IsNotFound(err) {
-					log.Info("No cluster peers found - continuing with deletion")
-					break //no need to check anymore
-				}
-				if err != nil {
-					log.Error(err, "Error get cluster peers")
-				}
-				if clusterPeerServices.NumRecords != 0 {
-
-					//delete the peer relationship
-					for _, peer := range clusterPeerServices.Records {
-						log.Info("Deleting cluster peer: " + peer.Name)
-						oc.DeleteClusterPeer(peer.Uuid)
-					}
-				}
-
-				if (i + 1) == checkingNumber {
-					//maximum attempts reached - force another reconciliation
-					return errors.NewTooManyRequests(fmt.Sprintf("Cluster peer still present after %v attempts - re-reconciling", i+1), 1)
-				}
-
-				time.Sleep(5 * time.Second) //wait 5 seconds before checking again
+				time.Sleep(r.operatorConfig().Deletion.CheckInterval.Duration) //wait before checking again
 			}
 		}
 
-		//check to see if intercluster Lifs are present and defined by custom resource
-		if svmCR.Spec.PeerConfig != nil {
-			log.Info("Checking for intercluster LIFs")
-			lifs, err := oc.GetIpInterfacesByServicePolicy(InterclusterLifServicePolicy)
-			if err != nil {
-				//error creating the json body
-				log.Error(err, "Error getting Intercluster LIFs for cluster: "+svmCR.Spec.ClusterManagementHost)
-			}
-
-			if lifs.NumRecords != 0 {
-
-				for _, crLif := range svmCR.Spec.PeerConfig.Lifs {
-					for _, clusterLif := range lifs.Records {
-
-						if crLif.IPAddress == clusterLif.Ip.Address {
-							log.Info("Deleting intercluster LIF: " + clusterLif.Ip.Address)
-							//delete this LIF
-							err = oc.DeleteIpInterface(clusterLif.Uuid)
-							if err != nil {
-								log.Error(err, "Error deleting an intercluster Lif defined in the custom resource: "+clusterLif.Ip.Address)
-							}
-						}
-					}
-				}
-			} else {
-				log.Info("No intercluster LIFs found - continuing with deletion")
-				//no need to check anymore
-			}
-
+		//only delete the cluster peers and intercluster LIFs the operator created for the custom resource
+		//and hand over the ones other custom resources still use
+		unlockCluster := r.lockCluster(oc, log)
+		err := r.releaseInventory(ctx, svmCR, oc, log)
+		unlockCluster()
+		if err != nil {
+			return err
 		}
 
 		//check to see if S3 is defined by custom resource
@@ -167,9 +136,9 @@ func (r *StorageVirtualMachineReconciler) finalizeSVM(ctx context.Context,
 
 			//check to see if S3 buckets are present
 			log.Info("Checking for S3 buckets")
-			for i := 0; i < checkingNumber; i++ {
+			for i := 0; i < r.operatorConfig().Deletion.CheckAttempts; i++ {
 				log.Info(fmt.Sprintf("Checking for S3 buckets - attempt %v", i+1))
-				bucketsRetrieved, err := oc.GetS3BucketsBySvmUuid(svmCR.Spec.SvmUuid)
+				bucketsRetrieved, err := oc.GetS3BucketsBySvmUuid(svmCR.GetSvmUuid())
 
 				if err != nil {
 					log.Error(err, "Error retrieving S3 buckets list from SVM: "+svmCR.Spec.SvmName)
@@ -178,7 +147,7 @@ func (r *StorageVirtualMachineReconciler) finalizeSVM(ctx context.Context,
 				if bucketsRetrieved.NumRecords != 0 {
 					for i := 0; i < bucketsRetrieved.NumRecords; i++ {
 						log.Info("Deleting S3 bucket: " + bucketsRetrieved.Records[i].Name)
-						err = oc.DeleteS3Bucket(svmCR.Spec.SvmUuid, bucketsRetrieved.Records[i].Uuid)
+						err = oc.DeleteS3Bucket(svmCR.GetSvmUuid(), bucketsRetrieved.Records[i].Uuid)
 
 						if err != nil {
 							log.Error(err, "Error deleting S3 bucket: "+bucketsRetrieved.Records[i].Name)
@@ -190,12 +159,12 @@ func (r *StorageVirtualMachineReconciler) finalizeSVM(ctx context.Context,
 					break //no need to check anymore
 				}
 
-				if (i + 1) == checkingNumber {
+				if (i + 1) == r.operatorConfig().Deletion.CheckAttempts {
 					//maximum attempts reached - force another reconciliation
 					return errors.NewTooManyRequests(fmt.Sprintf("S3 buckets still present after %v attempts - re-reconciling", i+1), 1)
 				}
 
-				time.Sleep(5 * time.Second) //wait 5 seconds before checking again
+				time.Sleep(r.operatorConfig().Deletion.CheckInterval.Duration) //wait before checking again
 			}
 
 			//check to see if secret was created and delete it
@@ -234,13 +203,13 @@ func (r *StorageVirtualMachineReconciler) finalizeSVM(ctx context.Context,
 
 		}
 
-		uuid := strings.TrimSpace(svmCR.Spec.SvmUuid)
+		uuid := strings.TrimSpace(svmCR.GetSvmUuid())
 		if uuid == "" {
 			log.Info("SVM uuid retrieved from the custom resource is empty - skipping deletion")
 			return nil
 		}
 
-		for i := 0; i < checkingNumber; i++ {
+		for i := 0; i < r.operatorConfig().Deletion.CheckAttempts; i++ {
 			log.Info(fmt.Sprintf("Checking for SVM deletion - attempt %v", i+1))
 			svm, err := oc.GetStorageVMByUUID(uuid)
 			if err != nil {
@@ -257,13 +226,13 @@ func (r *StorageVirtualMachineReconciler) finalizeSVM(ctx context.Context,
 				}
 			}
 
-			if (i + 1) == checkingNumber {
+			if (i + 1) == r.operatorConfig().Deletion.CheckAttempts {
 				//maximum attempts reached - force another reconciliation
 				return errors.NewTooManyRequests(fmt.Sprintf("SVM not deleted after %v attempts - re-reconciling", i+1), 1)
 			}
 
 			log.Info("SVM not deleted yet")
-			time.Sleep(5 * time.Second) //wait 5 seconds before checking again
+			time.Sleep(r.operatorConfig().Deletion.CheckInterval.Duration) //wait before checking again
 		}
 
 	}
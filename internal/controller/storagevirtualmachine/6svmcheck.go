@@ -23,7 +23,7 @@ func (r *StorageVirtualMachineReconciler) reconcileSvmCheck(ctx context.Context,
 	var svm ontap.SvmByUUID
 
 	// Check to see if SVM exists by the uuid in CR
-	uuid := strings.TrimSpace(svmCR.Spec.SvmUuid)
+	uuid := strings.TrimSpace(svmCR.GetSvmUuid())
 	if uuid == "" {
 		log.Info("SVM uuid retrieved from the custom resource is empty, need to create the SVM")
 		_ = r.setConditionSVMFound(ctx, svmCR, CONDITION_STATUS_FALSE)
@@ -40,6 +40,18 @@ func (r *StorageVirtualMachineReconciler) reconcileSvmCheck(ctx context.Context,
 			return svm, nil
 		}
 		log.Info("SVM uuid in the custom resource is valid", "svm retrieved: ", svm)
+
+		// Track the uuid in the status - migrates the uuid earlier versions wrote to the spec
+		// and adopts the SVM whose uuid is provided in the spec
+		if svmCR.Status.SvmUuid != uuid {
+			log.Info("Recording SVM uuid in the custom resource's status: " + uuid)
+			svmCR.Status.SvmUuid = uuid
+			_ = r.updateStatus(ctx, svmCR)
+		}
+		if svmCR.Spec.SvmUuid != "" && strings.TrimSpace(svmCR.Spec.SvmUuid) != uuid {
+			log.Info("SVM uuid " + svmCR.Spec.SvmUuid + " in the spec differs from the managed SVM " + uuid + " - ignoring")
+		}
+
 		_ = r.setConditionSVMFound(ctx, svmCR, CONDITION_STATUS_TRUE)
 		return svm, nil
 	}
@@ -12,20 +12,63 @@ import (
 	"gateway/internal/controller/ontap"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-const defaultComment = "Created by Astra Gateway"       //magic word
-const managementLIFServicePolicy = "default-management" //magic word
+const defaultComment = "Created by Astra Gateway" //magic word
 
 func (r *StorageVirtualMachineReconciler) reconcileSvmCreation(ctx context.Context,
 	svmCR *gateway.StorageVirtualMachine, oc *ontap.Client, log logr.Logger) (ctrl.Result, error) {
 
 	log.Info("STEP 7: Create SVM")
 
+	// The SVM may already exist when the operator restarted while creating it
+	uuid, err := r.recoverSvmUuid(ctx, svmCR, oc, log)
+	if err != nil {
+		r.Recorder.Event(svmCR, "Warning", "SvmCreationFailed", "Error: "+err.Error())
+		_ = r.setConditionSVMCreation(ctx, svmCR, CONDITION_STATUS_FALSE)
+		return ctrl.Result{}, err
+	}
+	if uuid == "" {
+		uuid, err = r.createSvm(ctx, svmCR, oc, log)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	log.Info("SVM new uuid: " + uuid)
+	//record the new uuid in the custom resource's status
+	svmCR.Status.SvmUuid = uuid
+	err = r.updateStatus(ctx, svmCR)
+	if err != nil {
+		log.Error(err, "Error recording the new uuid in the custom resource - requeuing")
+		r.Recorder.Event(svmCR, "Warning", "SvmCreationFailed", "Error: "+err.Error())
+		_ = r.setConditionSVMCreation(ctx, svmCR, CONDITION_STATUS_FALSE)
+		return ctrl.Result{}, err
+	}
+
+	//Set condition for SVM create
+	_ = r.setConditionSVMCreation(ctx, svmCR, CONDITION_STATUS_TRUE)
+
+	// Set finalizer
+	_, err = r.addFinalizer(ctx, svmCR)
+	if err != nil {
+		log.Error(err, "Error adding the finalizer to the custom resource - requeuing")
+		r.Recorder.Event(svmCR, "Warning", "SvmCreationFailed", "Error: "+err.Error())
+		return ctrl.Result{}, err //got another error - re-reconcile
+	}
+	r.Recorder.Event(svmCR, "Normal", "SvmCreationSuccesful", "SVM created with UUID: "+uuid)
+	log.Info("SVM created")
+	return ctrl.Result{}, nil
+}
+
+// createSvm requests the SVM and returns its uuid once the creation job completed
+func (r *StorageVirtualMachineReconciler) createSvm(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, oc *ontap.Client, log logr.Logger) (uuid string, err error) {
+
 	var payload ontap.SVMCreationPayload
 	payload.Name = svmCR.Spec.SvmName
 	payload.Comment = defaultComment
@@ -34,7 +77,7 @@ func (r *StorageVirtualMachineReconciler) reconcileSvmCreation(ctx context.Conte
 		ifpayload.Name = svmCR.Spec.ManagementLIF.Name
 		ifpayload.Ip.Address = svmCR.Spec.ManagementLIF.IPAddress
 		ifpayload.Ip.Netmask = svmCR.Spec.ManagementLIF.Netmask
-		ifpayload.ServicePolicy = managementLIFServicePolicy
+		ifpayload.ServicePolicy = r.operatorConfig().ServicePolicies.Management
 
 		var locpayload ontap.Location
 		locpayload.BroadcastDomain.Name = svmCR.Spec.ManagementLIF.BroadcastDomain
@@ -53,45 +96,60 @@ func (r *StorageVirtualMachineReconciler) reconcileSvmCreation(ctx context.Conte
 		log.Error(err, "Error creating the json payload for SVM creation - requeuing")
 		r.Recorder.Event(svmCR, "Warning", "SvmCreationFailed", "Error: "+err.Error())
 		_ = r.setConditionSVMCreation(ctx, svmCR, CONDITION_STATUS_FALSE)
-		return ctrl.Result{}, err
+		return "", err
 	}
 
 	log.Info("SVM creation attempt")
-	uuid, err := oc.CreateStorageVM(jsonPayload)
+	createJob, err := r.runJob(ctx, svmCR, JobOperationSvmCreate, svmCR.Spec.SvmName, func() (string, error) {
+		return oc.StartStorageVMCreation(jsonPayload)
+	}, oc, log)
+	if err == nil {
+		uuid, err = ontap.ParseUUID(createJob.Description, "/")
+	}
 	if err != nil {
 		log.Info("Uuid received was: " + uuid)
 		log.Error(err, "Error occurred when creating SVM - requeuing")
 		r.Recorder.Event(svmCR, "Warning", "SvmCreationFailed", "Error: "+err.Error())
-		r.Recorder.Event(svmCR, "Warning", "SvmCreationFailed", "Error: "+err.Error())
 		_ = r.setConditionSVMCreation(ctx, svmCR, CONDITION_STATUS_FALSE)
-		return ctrl.Result{}, err
+		return "", err
 	}
 
-	log.Info("SVM new uuid: " + uuid)
-	//patch the new uuid on the custom resource
-	patch := client.MergeFrom(svmCR.DeepCopy())
-	svmCR.Spec.SvmUuid = uuid
-	err = r.Patch(ctx, svmCR, patch)
-	if err != nil {
-		log.Error(err, "Error patching the new uuid in the custom resource - requeuing")
-		r.Recorder.Event(svmCR, "Warning", "SvmCreationFailed", "Error: "+err.Error())
-		_ = r.setConditionSVMCreation(ctx, svmCR, CONDITION_STATUS_FALSE)
-		return ctrl.Result{}, err
+	return uuid, nil
+}
+
+// recoverSvmUuid returns the uuid of an SVM created by an earlier reconcile whose uuid was never written
+// to the custom resource - either from the recorded creation job or by looking the SVM up by name
+// the uuid is empty when the SVM still has to be created
+func (r *StorageVirtualMachineReconciler) recoverSvmUuid(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, oc *ontap.Client, log logr.Logger) (string, error) {
+
+	createJob, found, err := r.resumeJob(ctx, svmCR, JobOperationSvmCreate, svmCR.Spec.SvmName, oc, log)
+	if ontap.IsJobRunning(err) {
+		return "", err
+	}
+	if found && err == nil {
+		uuid, err := ontap.ParseUUID(createJob.Description, "/")
+		if err == nil {
+			log.Info("SVM uuid recovered from the recorded creation job: " + uuid)
+			return uuid, nil
+		}
 	}
 
-	//Set condition for SVM create
-	_ = r.setConditionSVMCreation(ctx, svmCR, CONDITION_STATUS_TRUE)
+	svm, err := oc.GetStorageVMByName(svmCR.Spec.SvmName)
+	if err != nil && errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		log.Error(err, "Error looking up SVM by name: "+svmCR.Spec.SvmName+" - requeuing")
+		return "", err
+	}
 
-	// Set finalizer
-	_, err = r.addFinalizer(ctx, svmCR)
-	if err != nil {
-		log.Error(err, "Error adding the finalizer to the custom resource - requeuing")
-		r.Recorder.Event(svmCR, "Warning", "SvmCreationFailed", "Error: "+err.Error())
-		return ctrl.Result{}, err //got another error - re-reconcile
+	// only adopt an SVM the operator created
+	if svm.Comment != defaultComment {
+		log.Info("SVM " + svm.Name + " exists but was not created by the operator - attempting creation")
+		return "", nil
 	}
-	r.Recorder.Event(svmCR, "Normal", "SvmCreationSuccesful", "SVM created with UUID: "+uuid)
-	log.Info("SVM created")
-	return ctrl.Result{}, nil
+	log.Info("SVM uuid recovered by name: " + svm.Uuid)
+	return svm.Uuid, nil
 }
 
 func (r *StorageVirtualMachineReconciler) addFinalizer(ctx context.Context,
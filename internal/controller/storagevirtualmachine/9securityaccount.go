@@ -34,14 +34,14 @@ func (r *StorageVirtualMachineReconciler) reconcileSecurityAccount(ctx context.C
 	userNameToModify := string(credentials.Data["username"])
 
 	// Check to see if we have a uuid
-	if svmCR.Spec.SvmUuid == "" {
+	if svmCR.GetSvmUuid() == "" {
 		err := errors.NewBadRequest("No SVM uuid during security account update")
 		log.Error(err, "Error while updating SVM management credentials - requeuing")
 		return err
 	}
 
 	// Check to see if username exists
-	user, err := oc.GetSecurityAccount(svmCR.Spec.SvmUuid, userNameToModify)
+	user, err := oc.GetSecurityAccount(svmCR.GetSvmUuid(), userNameToModify)
 	if err != nil {
 		log.Error(err, "Error checking to see if username exists - requeuing")
 	}
@@ -90,7 +90,7 @@ func (r *StorageVirtualMachineReconciler) reconcileSecurityAccount(ctx context.C
 		}
 
 		log.Info("Security account patch attempt")
-		err = oc.PatchSecurityAccount(jsonPayload, svmCR.Spec.SvmUuid, userNameToModify)
+		err = oc.PatchSecurityAccount(jsonPayload, svmCR.GetSvmUuid(), userNameToModify)
 		if err != nil {
 			log.Error(err, "Error occurred when patching security account - requeuing")
 			_ = r.setConditionVsadminSecretUpdate(ctx, svmCR, CONDITION_STATUS_FALSE)
@@ -111,7 +111,7 @@ func (r *StorageVirtualMachineReconciler) reconcileSecurityAccount(ctx context.C
 		log.Info("User not found - try to create")
 		var payload ontap.SecurityAccountPayload
 		payload.Name = userNameToModify
-		payload.Owner.Uuid = svmCR.Spec.SvmUuid
+		payload.Owner.Uuid = svmCR.GetSvmUuid()
 
 		ssh := ontap.Application{
 			AppType:          ontap.Ssh,
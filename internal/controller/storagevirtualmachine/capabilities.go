@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"context"
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/controller/ontap"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Features of the custom resource that depend on the ONTAP version
+// a pipeline unit using a feature the cluster doesn't support is not run and reports Unsupported
+// instead of failing with the error ONTAP returns - objects of a unit, such as S3 buckets,
+// using an unsupported feature are skipped while the unit handles the others
+
+// nfsFeatures returns the version-dependent features of the NFS settings
+func nfsFeatures(svmCR *gateway.StorageVirtualMachine) []ontap.Feature {
+	var features []ontap.Feature
+	if svmCR.Spec.NfsConfig != nil && svmCR.Spec.NfsConfig.Enabled && svmCR.Spec.NfsConfig.Nfsv42 {
+		features = append(features, ontap.FeatureNfs42)
+	}
+	return features
+}
+
+// nvmeFeatures returns the version-dependent features of the NVMe settings
+func nvmeFeatures(svmCR *gateway.StorageVirtualMachine) []ontap.Feature {
+	var features []ontap.Feature
+	if svmCR.Spec.NvmeConfig == nil || !svmCR.Spec.NvmeConfig.Enabled {
+		return features
+	}
+	if len(svmCR.Spec.NvmeConfig.Lifs) > 0 {
+		features = append(features, ontap.FeatureNvmeTcp)
+	}
+	for _, subsystem := range svmCR.Spec.NvmeConfig.Subsystems {
+		for _, host := range subsystem.Hosts {
+			if host.DhHmacChap != nil {
+				features = append(features, ontap.FeatureNvmeInBandAuth)
+			}
+		}
+	}
+	return features
+}
+
+// s3Features returns the version-dependent features of the S3 service
+func s3Features(svmCR *gateway.StorageVirtualMachine) []ontap.Feature {
+	var features []ontap.Feature
+	if svmCR.Spec.S3Config != nil && svmCR.Spec.S3Config.Enabled {
+		features = append(features, ontap.FeatureS3)
+	}
+	return features
+}
+
+// s3ObjectFeatures returns the version-dependent features of the UNIX user mapping and the buckets
+func s3ObjectFeatures(svmCR *gateway.StorageVirtualMachine) []ontap.Feature {
+	var features []ontap.Feature
+	s3Config := svmCR.Spec.S3Config
+	if s3Config == nil || !s3Config.Enabled {
+		return features
+	}
+	features = append(features, s3UnixUserFeatures(s3Config)...)
+	for _, bucket := range s3Config.Buckets {
+		features = append(features, s3BucketFeatures(bucket)...)
+	}
+	return features
+}
+
+// s3UnixUserFeatures returns the features of the default UNIX user and the name mappings of NAS buckets
+func s3UnixUserFeatures(s3Config *gateway.S3SubSpec) []ontap.Feature {
+	var features []ontap.Feature
+	if s3Config.DefaultUnixUser != "" || len(s3Config.NameMappings) > 0 {
+		features = append(features, ontap.FeatureS3NasBuckets)
+	}
+	return features
+}
+
+// s3BucketFeatures returns the version-dependent features of a bucket
+func s3BucketFeatures(bucket gateway.S3Bucket) []ontap.Feature {
+	var features []ontap.Feature
+	if bucket.Policy != nil {
+		features = append(features, ontap.FeatureS3BucketPolicies)
+	}
+	if isNasBucket(bucket) {
+		features = append(features, ontap.FeatureS3NasBuckets)
+	}
+	if bucket.Versioning != "" {
+		features = append(features, ontap.FeatureS3Versioning)
+	}
+	if len(bucket.LifecycleRules) > 0 {
+		features = append(features, ontap.FeatureS3Lifecycle)
+	}
+	if bucket.Retention != nil {
+		features = append(features, ontap.FeatureS3ObjectLock)
+	}
+	return features
+}
+
+// reconcileCapabilities compares the features the custom resource uses with the cluster's ONTAP version
+// and reports the unsupported ones in a condition and a warning event
+func (r *StorageVirtualMachineReconciler) reconcileCapabilities(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, capabilities ontap.Capabilities, log logr.Logger) {
+
+	var features []ontap.Feature
+	for _, unit := range r.reconcileUnits() {
+		if unit.features != nil {
+			features = append(features, unit.features(svmCR)...)
+		}
+		if unit.objectFeatures != nil {
+			features = append(features, unit.objectFeatures(svmCR)...)
+		}
+	}
+
+	unsupported := capabilities.Unsupported(features)
+	if len(unsupported) == 0 {
+		_ = r.setConditionCapabilities(ctx, svmCR, CONDITION_STATUS_TRUE, "")
+		return
+	}
+
+	message := "ONTAP " + capabilities.Version.String() + " doesn't support: " + ontap.Describe(unsupported)
+	log.Info(message + " - skipping the steps and objects using them")
+	r.Recorder.Event(svmCR, "Warning", CONDITION_REASON_CAPABILITIES, message)
+	_ = r.setConditionCapabilities(ctx, svmCR, CONDITION_STATUS_FALSE, message)
+}
+
+// STEP 4
+// ONTAP capabilities
+// Note: Status of CAPABILITIES can only be true or false
+const CONDITION_TYPE_CAPABILITIES = "4ONTAPCapabilities"
+const CONDITION_REASON_CAPABILITIES = "ONTAPCapabilities"
+const CONDITION_MESSAGE_CAPABILITIES_TRUE = "Cluster supports the features of the custom resource"
+
+func (reconciler *StorageVirtualMachineReconciler) setConditionCapabilities(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, status metav1.ConditionStatus, message string) error {
+
+	if reconciler.containsCondition(svmCR, CONDITION_REASON_CAPABILITIES) {
+		reconciler.deleteCondition(ctx, svmCR, CONDITION_TYPE_CAPABILITIES, CONDITION_REASON_CAPABILITIES)
+	}
+
+	if status == CONDITION_STATUS_TRUE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_CAPABILITIES, status,
+			CONDITION_REASON_CAPABILITIES, CONDITION_MESSAGE_CAPABILITIES_TRUE)
+	}
+
+	if status == CONDITION_STATUS_FALSE {
+		return appendCondition(ctx, reconciler.Client, svmCR, CONDITION_TYPE_CAPABILITIES, status,
+			CONDITION_REASON_CAPABILITIES, message)
+	}
+	return nil
+}
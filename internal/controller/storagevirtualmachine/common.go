@@ -2,6 +2,7 @@ package controller
 
 import (
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	gateway "gateway/api/v1beta3"
 	"gateway/internal/controller/ontap"
@@ -127,6 +128,7 @@ func UpdateLif(lifDefinition gateway.LIF, lifToUpdate ontap.IpInterface, lifServ
 func CreateUser(userToCreate gateway.S3User, uuid string, oc *ontap.Client, log logr.Logger) (user ontap.S3UsersResponse, err error) {
 	var newUser ontap.S3User
 	newUser.Name = userToCreate.Name
+	newUser.Comment = defaultComment
 
 	jsonPayload, err := json.Marshal(newUser)
 	if err != nil {
@@ -201,11 +203,7 @@ func CreateServerCertificate(commonName string, catype string, expiryTime string
 		newCertificate.CommonName = commonName
 		newCertificate.Svm.Uuid = uuid
 		newCertificate.Type = catype
-		if expiryTime != "" {
-			newCertificate.ExpiryTime = expiryTime
-		} else {
-			newCertificate.ExpiryTime = "P725DT" //magic words
-		}
+		newCertificate.ExpiryTime = expiryTime
 
 		jsonPayload, err := json.Marshal(newCertificate)
 		if err != nil {
@@ -285,3 +283,51 @@ func CreateServerCertificate(commonName string, catype string, expiryTime string
 
 	return finalCert, nil
 }
+
+// InstallServerCertificate installs a PEM encoded certificate chain and private key as a server certificate on the SVM
+// the first certificate in the chain is the server certificate, the rest are intermediates
+func InstallServerCertificate(chainPem []byte, keyPem []byte, uuid string, oc *ontap.Client, log logr.Logger) (returnCert ontap.Certificate, err error) {
+	var certificates []string
+	rest := chainPem
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			certificates = append(certificates, string(pem.EncodeToMemory(block)))
+		}
+	}
+	if len(certificates) == 0 || len(keyPem) == 0 {
+		err = fmt.Errorf("tls secret must contain a PEM encoded certificate and private key")
+		log.Error(err, "Error reading server certificate")
+		return returnCert, err
+	}
+
+	var newServerCertificate ontap.Certificate
+	newServerCertificate.Svm.Uuid = uuid
+	newServerCertificate.Type = "server" //magic words
+	newServerCertificate.PublicCertificate = certificates[0]
+	newServerCertificate.PrivateKey = string(keyPem)
+	newServerCertificate.Intermediates = certificates[1:]
+	jsonPayload, err := json.Marshal(newServerCertificate)
+	if err != nil {
+		//error creating the json body
+		log.Error(err, "Error creating the json payload for server certificate installation")
+		return returnCert, err
+	}
+
+	log.Info("Server certificate installation attempt")
+	resp, err := oc.CreateCertificate(jsonPayload)
+	if err != nil {
+		log.Error(err, "Error occurred when installing server certificate")
+		return returnCert, err
+	}
+	if resp.NumRecords != 0 {
+		returnCert = resp.Records[0]
+	}
+	log.Info("Server certificate installation successful: " + returnCert.Name)
+
+	return returnCert, nil
+}
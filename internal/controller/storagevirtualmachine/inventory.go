@@ -0,0 +1,331 @@
+package controller
+
+import (
+	"context"
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/controller/ontap"
+	ontapcluster "gateway/internal/controller/ontapcluster"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Cluster peers and intercluster LIFs belong to the cluster and not to the SVM
+// so the custom resource keeps an inventory of the ones the operator created for it
+// and deletes only those when no other custom resource on the same cluster still uses them
+
+// inventory returns the custom resource's inventory, creating an empty one when missing
+func inventory(svmCR *gateway.StorageVirtualMachine) *gateway.InventoryStatus {
+	if svmCR.Status.Inventory == nil {
+		svmCR.Status.Inventory = &gateway.InventoryStatus{}
+	}
+	return svmCR.Status.Inventory
+}
+
+// ownedIndex returns the index of the object with the uuid or, if the uuid is empty, the name
+func ownedIndex(objects []gateway.OwnedObject, name string, uuid string) int {
+	for i, val := range objects {
+		if uuid != "" && val.Uuid == uuid {
+			return i
+		}
+		if uuid == "" && val.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeOwned returns the objects without the one with the uuid or, if the uuid is empty, the name
+func removeOwned(objects []gateway.OwnedObject, name string, uuid string) []gateway.OwnedObject {
+	i := ownedIndex(objects, name, uuid)
+	if i < 0 {
+		return objects
+	}
+	return append(objects[:i:i], objects[i+1:]...)
+}
+
+// recordClusterPeerIntent adds a cluster peer without uuid to the inventory before it is requested
+// so a peer that completes after a timed out request is still known as created by the operator
+func (r *StorageVirtualMachineReconciler) recordClusterPeerIntent(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, name string) {
+
+	inv := inventory(svmCR)
+	for _, val := range inv.ClusterPeers {
+		if val.Name == name {
+			return
+		}
+	}
+	inv.ClusterPeers = append(inv.ClusterPeers, gateway.OwnedObject{Name: name})
+	_ = r.updateStatus(ctx, svmCR)
+}
+
+// forgetClusterPeerIntent removes a cluster peer without uuid after its request failed
+func (r *StorageVirtualMachineReconciler) forgetClusterPeerIntent(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, name string) {
+
+	inv := inventory(svmCR)
+	i := ownedIndex(inv.ClusterPeers, name, "")
+	if i < 0 || inv.ClusterPeers[i].Uuid != "" {
+		return
+	}
+	inv.ClusterPeers = removeOwned(inv.ClusterPeers, name, "")
+	_ = r.updateStatus(ctx, svmCR)
+}
+
+// recordClusterPeer completes the inventory entry of a cluster peer requested by the operator
+func (r *StorageVirtualMachineReconciler) recordClusterPeer(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, clusterPeer ontap.ClusterPeer) {
+
+	inv := inventory(svmCR)
+	for i, val := range inv.ClusterPeers {
+		if val.Name == clusterPeer.Name && val.Uuid == "" {
+			inv.ClusterPeers[i].Uuid = clusterPeer.Uuid
+			_ = r.updateStatus(ctx, svmCR)
+			return
+		}
+	}
+}
+
+// createInterclusterLif creates an intercluster LIF and adds it to the inventory
+func (r *StorageVirtualMachineReconciler) createInterclusterLif(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	lif gateway.LIF, uuid string, oc *ontap.Client, log logr.Logger) error {
+
+	err := CreateLif(lif, r.operatorConfig().ServicePolicies.Intercluster, InterclusterLifServicePolicyScope, uuid, oc, log)
+	if err != nil {
+		return err
+	}
+
+	lifs, err := oc.GetIpInterfacesByServicePolicy(r.operatorConfig().ServicePolicies.Intercluster)
+	if err != nil {
+		log.Error(err, "Error getting created Intercluster LIF for inventory: "+lif.Name)
+		return err
+	}
+	inv := inventory(svmCR)
+	for _, val := range lifs.Records {
+		if val.Ip.Address == lif.IPAddress && ownedIndex(inv.InterclusterLifs, val.Name, val.Uuid) < 0 {
+			inv.InterclusterLifs = append(inv.InterclusterLifs, gateway.OwnedObject{Name: val.Name, Uuid: val.Uuid})
+			_ = r.updateStatus(ctx, svmCR)
+		}
+	}
+	return nil
+}
+
+// otherStorageVirtualMachines returns the other custom resources managing SVMs on the same cluster
+// custom resources that are being deleted no longer hold on to shared objects
+func (r *StorageVirtualMachineReconciler) otherStorageVirtualMachines(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine) ([]gateway.StorageVirtualMachine, error) {
+
+	svmList := &gateway.StorageVirtualMachineList{}
+	err := r.List(ctx, svmList)
+	if err != nil {
+		return nil, err
+	}
+
+	host := r.clusterHost(ctx, svmCR)
+	var others []gateway.StorageVirtualMachine
+	for _, val := range svmList.Items {
+		if val.UID == svmCR.UID || val.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if r.clusterHost(ctx, &val) != host {
+			continue
+		}
+		others = append(others, val)
+	}
+	return others, nil
+}
+
+// clusterHost returns the management host of the custom resource's cluster
+// from the referenced OntapCluster or the deprecated clusterHost
+func (r *StorageVirtualMachineReconciler) clusterHost(ctx context.Context, svmCR *gateway.StorageVirtualMachine) string {
+	host := svmCR.Spec.ClusterManagementHost
+	cluster, err := ontapcluster.Lookup(ctx, r.Client, svmCR)
+	if err == nil && cluster != nil {
+		host = cluster.Spec.ManagementHost
+	}
+	return ontapcluster.ManagementHost(host)
+}
+
+// clusterPeerUser returns the custom resource still using the cluster peer, or nil
+func clusterPeerUser(others []gateway.StorageVirtualMachine, clusterPeer gateway.OwnedObject) *gateway.StorageVirtualMachine {
+	for i, val := range others {
+		for _, status := range val.Status.Peers {
+			if status.ClusterPeerName == clusterPeer.Name {
+				return &others[i]
+			}
+		}
+		if val.Status.Inventory != nil && ownedIndex(val.Status.Inventory.ClusterPeers, clusterPeer.Name, clusterPeer.Uuid) >= 0 {
+			return &others[i]
+		}
+	}
+	return nil
+}
+
+// interclusterLifUser returns the custom resource still using the intercluster LIF, or nil
+func interclusterLifUser(others []gateway.StorageVirtualMachine, lif ontap.IpInterface) *gateway.StorageVirtualMachine {
+	for i, val := range others {
+		for _, peer := range peerConfigs(&val) {
+			for _, crLif := range peer.Lifs {
+				if crLif.IPAddress == lif.Ip.Address {
+					return &others[i]
+				}
+			}
+		}
+		if val.Status.Inventory != nil && ownedIndex(val.Status.Inventory.InterclusterLifs, lif.Name, lif.Uuid) >= 0 {
+			return &others[i]
+		}
+	}
+	return nil
+}
+
+// handOverClusterPeer moves a cluster peer into the inventory of the custom resource still using it
+// so the last user deletes it
+func (r *StorageVirtualMachineReconciler) handOverClusterPeer(ctx context.Context,
+	user *gateway.StorageVirtualMachine, clusterPeer gateway.OwnedObject) error {
+
+	inv := inventory(user)
+	if ownedIndex(inv.ClusterPeers, clusterPeer.Name, clusterPeer.Uuid) >= 0 {
+		return nil
+	}
+	inv.ClusterPeers = append(inv.ClusterPeers, clusterPeer)
+	return r.updateStatus(ctx, user)
+}
+
+// handOverInterclusterLif moves an intercluster LIF into the inventory of the custom resource still using it
+// so the last user deletes it
+func (r *StorageVirtualMachineReconciler) handOverInterclusterLif(ctx context.Context,
+	user *gateway.StorageVirtualMachine, lif gateway.OwnedObject) error {
+
+	inv := inventory(user)
+	if ownedIndex(inv.InterclusterLifs, lif.Name, lif.Uuid) >= 0 {
+		return nil
+	}
+	inv.InterclusterLifs = append(inv.InterclusterLifs, lif)
+	return r.updateStatus(ctx, user)
+}
+
+// releaseClusterPeer deletes a cluster peer of the inventory, or hands it over when another
+// custom resource still uses it, and reports whether it left the inventory
+func (r *StorageVirtualMachineReconciler) releaseClusterPeer(ctx context.Context,
+	owned gateway.OwnedObject, others []gateway.StorageVirtualMachine, clusterPeers []ontap.ClusterPeer,
+	oc *ontap.Client, log logr.Logger) bool {
+
+	for _, val := range clusterPeers {
+		if val.Name == owned.Name && owned.Uuid == "" {
+			// the creation request completed after it timed out
+			owned.Uuid = val.Uuid
+		}
+	}
+
+	if user := clusterPeerUser(others, owned); user != nil {
+		log.Info("Cluster peer " + owned.Name + " still used by " + user.Namespace + "/" + user.Name + " - handing it over")
+		err := r.handOverClusterPeer(ctx, user, owned)
+		if err != nil {
+			log.Error(err, "Error handing over cluster peer: "+owned.Name)
+			return false
+		}
+		return true
+	}
+
+	for _, val := range clusterPeers {
+		if val.Uuid != owned.Uuid {
+			continue
+		}
+		log.Info("Deleting cluster peer: " + val.Name)
+		err := oc.DeleteClusterPeer(val.Uuid)
+		if err != nil {
+			// the SVM peer deletion may still be in progress
+			log.Error(err, "Error deleting cluster peer: "+val.Name)
+			return false
+		}
+		log.Info("Cluster peer delete successful: " + val.Name)
+	}
+	return true
+}
+
+// releaseInterclusterLif deletes an intercluster LIF of the inventory, or hands it over when another
+// custom resource still uses it, and reports whether it left the inventory
+func (r *StorageVirtualMachineReconciler) releaseInterclusterLif(ctx context.Context,
+	owned gateway.OwnedObject, others []gateway.StorageVirtualMachine, lifs []ontap.IpInterface,
+	oc *ontap.Client, log logr.Logger) bool {
+
+	for _, val := range lifs {
+		if val.Uuid != owned.Uuid {
+			continue
+		}
+		if user := interclusterLifUser(others, val); user != nil {
+			log.Info("Intercluster LIF " + val.Ip.Address + " still used by " + user.Namespace + "/" + user.Name + " - handing it over")
+			err := r.handOverInterclusterLif(ctx, user, owned)
+			if err != nil {
+				log.Error(err, "Error handing over intercluster LIF: "+val.Ip.Address)
+				return false
+			}
+			return true
+		}
+
+		log.Info("Deleting intercluster LIF: " + val.Ip.Address)
+		err := oc.DeleteIpInterface(val.Uuid)
+		if err != nil {
+			log.Error(err, "Error deleting an intercluster LIF created for the custom resource: "+val.Ip.Address)
+			return false
+		}
+	}
+	// gone from the cluster already
+	return true
+}
+
+// releaseInventory deletes or hands over the cluster peers and intercluster LIFs of the inventory
+// and keeps the ones that failed in the inventory for the next attempt
+func (r *StorageVirtualMachineReconciler) releaseInventory(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	oc *ontap.Client, log logr.Logger) error {
+
+	if svmCR.Status.Inventory == nil {
+		return nil
+	}
+	inv := svmCR.Status.Inventory
+
+	others, err := r.otherStorageVirtualMachines(ctx, svmCR)
+	if err != nil {
+		log.Error(err, "Error listing StorageVirtualMachines sharing the cluster")
+		return err
+	}
+
+	if len(inv.ClusterPeers) > 0 {
+		clusterPeers, err := oc.GetClusterPeers()
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Error get cluster peers")
+			return err
+		}
+		var remaining []gateway.OwnedObject
+		for _, owned := range inv.ClusterPeers {
+			if !r.releaseClusterPeer(ctx, owned, others, clusterPeers.Records, oc, log) {
+				remaining = append(remaining, owned)
+			}
+		}
+		inv.ClusterPeers = remaining
+	}
+
+	if len(inv.InterclusterLifs) > 0 {
+		lifs, err := oc.GetIpInterfacesByServicePolicy(r.operatorConfig().ServicePolicies.Intercluster)
+		if err != nil {
+			log.Error(err, "Error getting Intercluster LIFs for cluster: "+oc.Host)
+			return err
+		}
+		var remaining []gateway.OwnedObject
+		for _, owned := range inv.InterclusterLifs {
+			if !r.releaseInterclusterLif(ctx, owned, others, lifs.Records, oc, log) {
+				remaining = append(remaining, owned)
+			}
+		}
+		inv.InterclusterLifs = remaining
+	}
+
+	err = r.updateStatus(ctx, svmCR)
+	if err != nil {
+		return err
+	}
+	if len(inv.ClusterPeers) > 0 || len(inv.InterclusterLifs) > 0 {
+		return errors.NewTooManyRequests("cluster peers or intercluster LIFs of the inventory still present - re-reconciling", 1)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/controller/ontap"
+
+	"github.com/go-logr/logr"
+)
+
+const JobOperationSvmCreate = "svmCreate"           //magic word
+const JobOperationSvmPatch = "svmPatch"             //magic word
+const JobOperationS3BucketCreate = "s3BucketCreate" //magic word
+const JobOperationS3BucketPatch = "s3BucketPatch"   //magic word
+const JobOperationVolumeCreate = "volumeCreate"     //magic word
+
+// ONTAP answers SVM, bucket and volume requests with a job that is polled until it completes
+// the job is recorded in the custom resource's status before polling starts
+// so a reconcile after an operator restart waits for the job instead of repeating the request
+// a job still running after the job timeout stays recorded and the reconcile reports it as waiting
+
+// findJob returns the index of the recorded job of the operation on the target
+func findJob(svmCR *gateway.StorageVirtualMachine, operation string, target string) int {
+	for i, val := range svmCR.Status.Jobs {
+		if val.Operation == operation && val.Target == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// recordJob writes the job to the custom resource's status
+func (r *StorageVirtualMachineReconciler) recordJob(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	operation string, target string, uuid string) error {
+
+	job := gateway.JobStatus{Operation: operation, Target: target, Uuid: uuid}
+	if i := findJob(svmCR, operation, target); i >= 0 {
+		svmCR.Status.Jobs[i] = job
+	} else {
+		svmCR.Status.Jobs = append(svmCR.Status.Jobs, job)
+	}
+	return r.updateStatus(ctx, svmCR)
+}
+
+// forgetJob removes the job of the operation on the target from the custom resource's status
+func (r *StorageVirtualMachineReconciler) forgetJob(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	operation string, target string) {
+
+	i := findJob(svmCR, operation, target)
+	if i < 0 {
+		return
+	}
+	svmCR.Status.Jobs = append(svmCR.Status.Jobs[:i:i], svmCR.Status.Jobs[i+1:]...)
+	_ = r.updateStatus(ctx, svmCR)
+}
+
+// resumeJob waits for the job of the operation on the target recorded by an earlier reconcile
+// and forgets it once it completed - found is false when no job was recorded
+func (r *StorageVirtualMachineReconciler) resumeJob(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	operation string, target string, oc *ontap.Client, log logr.Logger) (job ontap.Job, found bool, err error) {
+
+	i := findJob(svmCR, operation, target)
+	if i < 0 {
+		return job, false, nil
+	}
+
+	uuid := svmCR.Status.Jobs[i].Uuid
+	log.Info("Resuming " + operation + " job " + uuid + " for: " + target)
+	job, err = oc.WaitForJob(uuid)
+	if ontap.IsJobRunning(err) {
+		log.Info("Recorded " + operation + " job " + uuid + " still running for: " + target)
+		return job, true, err
+	} else if err != nil {
+		log.Error(err, "Recorded "+operation+" job "+uuid+" did not succeed for: "+target)
+	} else {
+		log.Info("Recorded " + operation + " job " + uuid + " succeeded for: " + target)
+	}
+	r.forgetJob(ctx, svmCR, operation, target)
+	return job, true, err
+}
+
+// resumeJobs waits for all recorded jobs of the operations
+// failures are only logged because the following reconcile repeats the requests
+func (r *StorageVirtualMachineReconciler) resumeJobs(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	oc *ontap.Client, log logr.Logger, operations ...string) {
+
+	for _, operation := range operations {
+		var targets []string
+		for _, val := range svmCR.Status.Jobs {
+			if val.Operation == operation {
+				targets = append(targets, val.Target)
+			}
+		}
+		for _, target := range targets {
+			_, _, _ = r.resumeJob(ctx, svmCR, operation, target, oc, log)
+		}
+	}
+}
+
+// runJob starts an asynchronous ONTAP request, records its job and waits for it
+// a job of the same operation on the target left by an earlier reconcile is waited for first
+// and the request isn't repeated while that job is still running
+func (r *StorageVirtualMachineReconciler) runJob(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	operation string, target string, start func() (string, error), oc *ontap.Client, log logr.Logger) (ontap.Job, error) {
+
+	job, _, err := r.resumeJob(ctx, svmCR, operation, target, oc, log)
+	if ontap.IsJobRunning(err) {
+		return job, err
+	}
+
+	job = ontap.Job{}
+	uuid, err := start()
+	if err != nil {
+		return job, err
+	}
+	if uuid == "" {
+		// completed synchronously
+		return job, nil
+	}
+
+	err = r.recordJob(ctx, svmCR, operation, target, uuid)
+	if err != nil {
+		log.Error(err, "Error recording "+operation+" job "+uuid+" for: "+target)
+	}
+
+	job, err = oc.WaitForJob(uuid)
+	if ontap.IsJobRunning(err) {
+		log.Info(operation + " job " + uuid + " still running for: " + target + " - waiting in the next reconcile")
+		return job, err
+	}
+	r.forgetJob(ctx, svmCR, operation, target)
+	return job, err
+}
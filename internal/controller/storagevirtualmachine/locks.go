@@ -0,0 +1,58 @@
+package controller
+
+import (
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/controller/ontap"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// Custom resources are reconciled in parallel when manager.maxConcurrentReconciles is above 1
+// - the work on an SVM is serialized per SVM, so custom resources naming the same SVM don't interleave
+// - mutations of cluster-scoped objects, such as cluster peers, intercluster LIFs, service policies
+//   and the inventories handing them over, are serialized per cluster management host
+// The SVM lock is always taken before the cluster lock and neither is taken twice
+// The COSI reconcilers share the SVM locks, so their bucket policy patches don't interleave
+
+// KeyedLocks hands out one mutex per key
+type KeyedLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// Lock blocks until the key is free and returns the function releasing it
+func (k *KeyedLocks) Lock(kind string, key string, log logr.Logger) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*sync.Mutex)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		k.locks[key] = l
+	}
+	k.mu.Unlock()
+
+	if !l.TryLock() {
+		log.Info("Waiting for the " + kind + " lock of: " + key)
+		l.Lock()
+	}
+	return l.Unlock
+}
+
+// LockSvm serializes the work on the SVM
+// the keys use the client's host, so clusterRef and the deprecated clusterHost share the locks of a cluster
+func LockSvm(locks *KeyedLocks, oc *ontap.Client, svmName string, log logr.Logger) func() {
+	return locks.Lock("SVM", oc.Host+"/"+svmName, log)
+}
+
+// lockSvm serializes the work on the custom resource's SVM
+func (r *StorageVirtualMachineReconciler) lockSvm(oc *ontap.Client, svmCR *gateway.StorageVirtualMachine, log logr.Logger) func() {
+	return LockSvm(r.SvmLocks, oc, svmCR.Spec.SvmName, log)
+}
+
+// lockCluster serializes the mutations of cluster-scoped objects on the client's cluster
+func (r *StorageVirtualMachineReconciler) lockCluster(oc *ontap.Client, log logr.Logger) func() {
+	return r.clusterLocks.Lock("cluster", oc.Host, log)
+}
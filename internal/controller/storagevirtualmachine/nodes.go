@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+	gateway "gateway/api/v1beta3"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const NodeIscsiIqnAnnotation = "gateway.netapp.com/iscsi-iqn" //magic word
+const NodeNvmeNqnAnnotation = "gateway.netapp.com/nvme-nqn"   //magic word
+
+// listNodes returns the Kubernetes nodes matching the label selector
+// a nil selector matches all nodes
+func (r *StorageVirtualMachineReconciler) listNodes(ctx context.Context, nodeSelector *metav1.LabelSelector) ([]corev1.Node, error) {
+	selector := labels.Everything()
+	if nodeSelector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(nodeSelector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var nodeList corev1.NodeList
+	err := r.List(ctx, &nodeList, client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, err
+	}
+	return nodeList.Items, nil
+}
+
+// NodeClientMatches returns the sorted, unique InternalIP addresses of the nodes
+// or their networks in CIDR notation when a prefix length is provided
+func NodeClientMatches(nodes []corev1.Node, prefixLength *int) []string {
+	found := make(map[string]bool)
+	for _, node := range nodes {
+		for _, address := range node.Status.Addresses {
+			if address.Type != corev1.NodeInternalIP {
+				continue
+			}
+			ip := net.ParseIP(address.Address)
+			if ip == nil {
+				continue
+			}
+			if prefixLength == nil {
+				found[ip.String()] = true
+				continue
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			if *prefixLength > bits {
+				continue
+			}
+			network := net.IPNet{IP: ip.Mask(net.CIDRMask(*prefixLength, bits)), Mask: net.CIDRMask(*prefixLength, bits)}
+			found[network.String()] = true
+		}
+	}
+
+	var matches []string
+	for val := range found {
+		matches = append(matches, val)
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// NodeAnnotationValues returns the sorted, unique values of the annotation on the nodes
+// nodes without the annotation are skipped
+func NodeAnnotationValues(nodes []corev1.Node, annotation string) []string {
+	found := make(map[string]bool)
+	for _, node := range nodes {
+		val := strings.TrimSpace(node.Annotations[annotation])
+		if val != "" {
+			found[val] = true
+		}
+	}
+
+	var values []string
+	for val := range found {
+		values = append(values, val)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// usesNodes reports whether the custom resource derives any configuration from Kubernetes nodes
+func usesNodes(svmCR *gateway.StorageVirtualMachine) bool {
+	if svmCR.Spec.NfsConfig != nil {
+		for _, policy := range svmCR.Spec.NfsConfig.ExportPolicies {
+			for _, rule := range policy.Rules {
+				if rule.ClientsFromNodes != nil {
+					return true
+				}
+			}
+		}
+	}
+	if svmCR.Spec.IscsiConfig != nil && svmCR.Spec.IscsiConfig.NodeIgroup != nil {
+		return true
+	}
+	if svmCR.Spec.NvmeConfig != nil && svmCR.Spec.NvmeConfig.NodeSubsystem != nil {
+		return true
+	}
+	return false
+}
+
+// nodeToStorageVirtualMachines enqueues every custom resource that derives configuration from nodes
+// a label change can move a node in or out of a selector so the selectors are evaluated during reconcile
+func (r *StorageVirtualMachineReconciler) nodeToStorageVirtualMachines(ctx context.Context, obj client.Object) []reconcile.Request {
+	var svmList gateway.StorageVirtualMachineList
+	err := r.List(ctx, &svmList)
+	if err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range svmList.Items {
+		if usesNodes(&svmList.Items[i]) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: svmList.Items[i].Namespace,
+				Name:      svmList.Items[i].Name,
+			}})
+		}
+	}
+	return requests
+}
+
+// nodeChangedPredicate ignores the periodic node status updates
+// only label, address and initiator annotation changes matter to the custom resources
+var nodeChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, ok := e.ObjectOld.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		newNode, ok := e.ObjectNew.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		return !reflect.DeepEqual(oldNode.Labels, newNode.Labels) ||
+			!reflect.DeepEqual(oldNode.Status.Addresses, newNode.Status.Addresses) ||
+			oldNode.Annotations[NodeIscsiIqnAnnotation] != newNode.Annotations[NodeIscsiIqnAnnotation] ||
+			oldNode.Annotations[NodeNvmeNqnAnnotation] != newNode.Annotations[NodeNvmeNqnAnnotation]
+	},
+}
@@ -0,0 +1,294 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/controller/ontap"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const PeerPassphraseKey = "passphrase"                //magic word
+const PeerPassphraseSecretSuffix = "-peer-passphrase" //magic word
+const PeerPassphraseLength = 32                       //magic word - bytes before encoding
+
+// peerSettings holds the effective remote side and passphrase of the custom resource's peering
+type peerSettings struct {
+	addresses        []string
+	svmName          string
+	passphrase       string
+	passphraseSecret *gateway.NamespacedName
+	// auto is set when the remote side is another custom resource in this Kubernetes cluster
+	auto bool
+	// initiator creates the cluster and SVM peer requests, the other side accepts them
+	initiator              bool
+	remoteClusterPeerState string
+}
+
+// peerSettings resolves the remote addresses, SVM name and passphrase from the remoteRef custom resource
+// and the passphrase secret, falling back to the remote and passphrase fields
+func (r *StorageVirtualMachineReconciler) peerSettings(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	peer *gateway.PeerSubSpec, log logr.Logger) (peerSettings, error) {
+
+	var settings peerSettings
+	settings.svmName = peer.Remote.Svmname
+	if peer.Remote.Ipaddress != "" {
+		settings.addresses = []string{peer.Remote.Ipaddress}
+	}
+	settings.passphrase = peer.Passphrase
+
+	passphraseRef := peer.PassphraseSecret
+	if passphraseRef != nil && passphraseRef.Namespace == "" {
+		passphraseRef = &gateway.NamespacedName{Name: passphraseRef.Name, Namespace: svmCR.Namespace}
+	}
+
+	if peer.RemoteRef != nil {
+		settings.auto = true
+		remoteNamespace := peer.RemoteRef.Namespace
+		if remoteNamespace == "" {
+			remoteNamespace = svmCR.Namespace
+		}
+		remoteCR := &gateway.StorageVirtualMachine{}
+		err := r.Get(ctx, types.NamespacedName{Name: peer.RemoteRef.Name, Namespace: remoteNamespace}, remoteCR)
+		if err != nil {
+			log.Error(err, "Error getting remote StorageVirtualMachine: "+remoteNamespace+"/"+peer.RemoteRef.Name)
+			return settings, err
+		}
+		// The remote custom resource must reference this one back
+		var remotePeer *gateway.PeerSubSpec
+		settings.addresses = nil
+		for _, val := range peerConfigs(remoteCR) {
+			if val.RemoteRef != nil && val.RemoteRef.Name == svmCR.Name &&
+				(val.RemoteRef.Namespace == svmCR.Namespace || (val.RemoteRef.Namespace == "" && remoteCR.Namespace == svmCR.Namespace)) {
+				remotePeer = &val
+			}
+			// intercluster LIFs belong to the cluster and serve all of its relationships
+			for _, lif := range val.Lifs {
+				settings.addresses = append(settings.addresses, lif.IPAddress)
+			}
+		}
+		if remotePeer == nil || len(settings.addresses) == 0 {
+			log.Info("Remote StorageVirtualMachine " + remoteNamespace + "/" + remoteCR.Name +
+				" has no peer with intercluster LIFs referencing this StorageVirtualMachine - requeuing")
+			return settings, errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"}, "waiting for cluster peer")
+		}
+
+		settings.svmName = remoteCR.Spec.SvmName
+		settings.initiator = peerInitiator(svmCR, remoteCR)
+		if remoteStatus := findPeerStatus(remoteCR, remotePeer.Name); remoteStatus != nil {
+			settings.remoteClusterPeerState = remoteStatus.ClusterPeerState
+		}
+
+		// Both sides derive the same secret from the pair with the initiator first
+		if passphraseRef == nil {
+			initiatorCR, acceptorCR := remoteCR, svmCR
+			if settings.initiator {
+				initiatorCR, acceptorCR = svmCR, remoteCR
+			}
+			passphraseRef = &gateway.NamespacedName{
+				Name:      initiatorCR.Name + "-" + acceptorCR.Name + PeerPassphraseSecretSuffix,
+				Namespace: initiatorCR.Namespace,
+			}
+		}
+	}
+
+	if passphraseRef != nil {
+		settings.passphraseSecret = passphraseRef
+		secret, err := r.getSecret(ctx, *passphraseRef, svmCR)
+		if err != nil && errors.IsNotFound(err) && settings.auto && settings.initiator {
+			secret, err = r.createPeerPassphraseSecret(ctx, svmCR, *passphraseRef, log)
+		}
+		if err != nil && errors.IsNotFound(err) {
+			log.Info("Waiting for peering passphrase secret " + passphraseRef.Namespace + "/" + passphraseRef.Name + " - requeuing")
+			return settings, errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com", Resource: "StorageVirtualMachine"}, "waiting for cluster peer")
+		} else if err != nil {
+			log.Error(err, "Error getting peering passphrase secret: "+passphraseRef.Name)
+			return settings, err
+		}
+		settings.passphrase = string(secret.Data[PeerPassphraseKey])
+	}
+
+	if settings.passphrase == "" {
+		return settings, fmt.Errorf("peering passphrase for %s is empty", peer.Name)
+	}
+	if len(settings.addresses) == 0 || settings.svmName == "" {
+		return settings, fmt.Errorf("peering %s requires remote.ipAddress and remote.svmName or remoteRef", peer.Name)
+	}
+	return settings, nil
+}
+
+// peerInitiator picks the same side of a pair of custom resources no matter which side asks
+func peerInitiator(svmCR *gateway.StorageVirtualMachine, remoteCR *gateway.StorageVirtualMachine) bool {
+	return svmCR.Namespace+"/"+svmCR.Name < remoteCR.Namespace+"/"+remoteCR.Name
+}
+
+// createPeerPassphraseSecret generates a random passphrase into a new secret owned by the custom resource
+func (r *StorageVirtualMachineReconciler) createPeerPassphraseSecret(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	ref gateway.NamespacedName, log logr.Logger) (*corev1.Secret, error) {
+
+	buf := make([]byte, PeerPassphraseLength)
+	_, err := rand.Read(buf)
+	if err != nil {
+		log.Error(err, "Error generating peering passphrase")
+		return nil, err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ref.Name,
+			Namespace: ref.Namespace,
+			Labels: map[string]string{
+				SvmNameLabel:      svmCR.Name,
+				SvmNamespaceLabel: svmCR.Namespace,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{PeerPassphraseKey: []byte(base64.RawURLEncoding.EncodeToString(buf))},
+	}
+
+	// owner references can't cross namespaces
+	if secret.Namespace == svmCR.Namespace {
+		err = controllerutil.SetOwnerReference(svmCR, secret, r.Scheme)
+		if err != nil {
+			log.Error(err, "Error setting owner reference on peering passphrase secret: "+ref.Name)
+			return nil, err
+		}
+	}
+
+	err = r.Create(ctx, secret)
+	if err != nil {
+		log.Error(err, "Error creating peering passphrase secret: "+ref.Namespace+"/"+ref.Name)
+		return nil, err
+	}
+	log.Info("Peering passphrase secret creation successful: " + ref.Name)
+	return secret, nil
+}
+
+// findPeerStatus returns the status of the named relationship
+func findPeerStatus(svmCR *gateway.StorageVirtualMachine, name string) *gateway.PeerStatus {
+	for i := range svmCR.Status.Peers {
+		if svmCR.Status.Peers[i].Name == name {
+			return &svmCR.Status.Peers[i]
+		}
+	}
+	return nil
+}
+
+// reportPeerStatus writes the relationship's peering state to the custom resource's status when it changed
+func (r *StorageVirtualMachineReconciler) reportPeerStatus(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	status gateway.PeerStatus) {
+
+	current := findPeerStatus(svmCR, status.Name)
+	if current != nil && reflect.DeepEqual(*current, status) {
+		return
+	}
+	if current != nil {
+		*current = status
+	} else {
+		svmCR.Status.Peers = append(svmCR.Status.Peers, status)
+	}
+	_ = r.updateStatus(ctx, svmCR)
+}
+
+// deleteRemovedPeers deletes the SVM peer and the cluster peer of the relationships
+// that are reported in the status but no longer defined in the custom resource
+func (r *StorageVirtualMachineReconciler) deleteRemovedPeers(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	peers []gateway.PeerSubSpec, oc *ontap.Client, log logr.Logger) {
+
+	var remaining []gateway.PeerStatus
+	for _, status := range svmCR.Status.Peers {
+		defined := false
+		for _, val := range peers {
+			if val.Name == status.Name {
+				defined = true
+			}
+		}
+		if defined {
+			remaining = append(remaining, status)
+			continue
+		}
+
+		if !r.deletePeerRelationship(ctx, svmCR, status, peers, oc, log) {
+			// try again on the next reconcile
+			remaining = append(remaining, status)
+		}
+	}
+
+	if len(remaining) != len(svmCR.Status.Peers) {
+		svmCR.Status.Peers = remaining
+		_ = r.updateStatus(ctx, svmCR)
+	}
+}
+
+// deletePeerRelationship deletes the local SVM's peer with the relationship's remote SVM
+// and the cluster peer when the operator created it and no other relationship uses it
+func (r *StorageVirtualMachineReconciler) deletePeerRelationship(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	status gateway.PeerStatus, peers []gateway.PeerSubSpec, oc *ontap.Client, log logr.Logger) bool {
+
+	clusterPeerName := status.ClusterPeerName
+	if clusterPeerName == "" {
+		clusterPeerName = status.Name
+	}
+
+	deleted := true
+	svmPeers, err := oc.GetSvmPeers(svmCR.Spec.SvmName)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Error retrieving SVM peers for removed peer: "+status.Name)
+		return false
+	}
+	for _, val := range svmPeers.Records {
+		if val.Peer.Cluster.Name != clusterPeerName || (status.RemoteSvmName != "" && val.Peer.Svm.Name != status.RemoteSvmName) {
+			continue
+		}
+		log.Info("SVM peer delete attempt for removed peer " + status.Name + ": " + val.Name)
+		err = oc.DeleteSvmPeer(val.Uuid)
+		if err != nil {
+			log.Error(err, "Error deleting SVM peer for removed peer: "+status.Name)
+			deleted = false
+		} else {
+			log.Info("SVM peer delete successful for removed peer: " + status.Name)
+		}
+	}
+
+	// relationships to other SVMs on the same remote cluster share the cluster peer
+	for _, val := range peers {
+		current := findPeerStatus(svmCR, val.Name)
+		if current != nil && current.ClusterPeerName == clusterPeerName {
+			return deleted
+		}
+	}
+
+	// only cluster peers the operator created for the custom resource are deleted
+	inv := inventory(svmCR)
+	i := ownedIndex(inv.ClusterPeers, clusterPeerName, "")
+	if i < 0 {
+		return deleted
+	}
+	owned := inv.ClusterPeers[i]
+
+	others, err := r.otherStorageVirtualMachines(ctx, svmCR)
+	if err != nil {
+		log.Error(err, "Error listing StorageVirtualMachines sharing the cluster for removed peer: "+status.Name)
+		return false
+	}
+	clusterPeers, err := oc.GetClusterPeers()
+	if err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "Error retrieving cluster peers for removed peer: "+status.Name)
+		return false
+	}
+	if !r.releaseClusterPeer(ctx, owned, others, clusterPeers.Records, oc, log) {
+		return false
+	}
+	inv.ClusterPeers = removeOwned(inv.ClusterPeers, owned.Name, owned.Uuid)
+	return deleted
+}
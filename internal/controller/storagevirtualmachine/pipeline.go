@@ -0,0 +1,318 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/controller/ontap"
+	"reflect"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Steps 6 to 17 run as a pipeline of reconcile units in registry order
+// a unit runs once the units it depends on succeeded - a failing unit only blocks its dependents
+// unless it is critical, which stops the pipeline
+// a unit using ONTAP features the cluster's version doesn't support isn't run
+
+// reconcileState is shared by the units of one reconcile
+type reconcileState struct {
+	svmCR *gateway.StorageVirtualMachine
+	oc    *ontap.Client
+	// svm is the SVM in ONTAP once STEP 6 found it or STEP 7 created it
+	svm    ontap.SvmByUUID
+	create bool
+}
+
+// reconcileUnit is one step of the pipeline
+type reconcileUnit struct {
+	name          string
+	conditionType string
+	dependsOn     []string
+	// dependsOnFor returns the further units the unit depends on for the custom resource
+	dependsOnFor func(svmCR *gateway.StorageVirtualMachine) []string
+	// critical units stop the pipeline when they don't succeed
+	critical bool
+	// features returns the version-dependent ONTAP features the unit uses for the custom resource
+	features func(svmCR *gateway.StorageVirtualMachine) []ontap.Feature
+	// objectFeatures returns the version-dependent features of single objects, which the unit skips on its own
+	objectFeatures func(svmCR *gateway.StorageVirtualMachine) []ontap.Feature
+	run            func(ctx context.Context, state *reconcileState, log logr.Logger) (gateway.StepResult, error)
+}
+
+// reconcileUnits returns the registry of the pipeline's units
+func (r *StorageVirtualMachineReconciler) reconcileUnits() []reconcileUnit {
+	return []reconcileUnit{
+		{name: "SvmCheck", conditionType: CONDITION_TYPE_SVM_FOUND, critical: true, run: r.runSvmCheck},
+		{name: "SvmCreation", conditionType: CONDITION_TYPE_SVM_CREATED, dependsOn: []string{"SvmCheck"},
+			critical: true, run: r.runSvmCreation},
+		{name: "SecurityAccount", conditionType: CONDITION_TYPE_VSADMIN_SECRET_UPDATE, dependsOn: []string{"SvmCreation"},
+			run: r.runSecurityAccount},
+		{name: "SvmUpdate", conditionType: CONDITION_TYPE_SVM_UPDATED, dependsOn: []string{"SvmCreation"},
+			run: r.runSvmUpdate},
+		{name: "ManagementLif", conditionType: CONDITION_TYPE_MANGEMENTLIF_UPSERT, dependsOn: []string{"SvmCreation"},
+			run: r.runManagementLif},
+		{name: "Aggregates", conditionType: CONDITION_TYPE_AGGREGATE_ASSIGNED, dependsOn: []string{"SvmCreation"},
+			run: r.runAggregates},
+		{name: "Nfs", conditionType: CONDITION_TYPE_NFS_SERVICE, dependsOn: []string{"SvmUpdate"},
+			features: nfsFeatures, run: r.runNfs},
+		{name: "Iscsi", conditionType: CONDITION_TYPE_ISCSI_SERVICE, dependsOn: []string{"SvmUpdate"},
+			run: r.runIscsi},
+		{name: "Nvme", conditionType: CONDITION_TYPE_NVME_SERVICE, dependsOn: []string{"SvmUpdate"},
+			features: nvmeFeatures, run: r.runNvme},
+		{name: "S3", conditionType: CONDITION_TYPE_S3_SERVICE, dependsOn: []string{"SvmUpdate"},
+			dependsOnFor: s3Dependencies, features: s3Features, objectFeatures: s3ObjectFeatures, run: r.runS3},
+		{name: "Peer", conditionType: CONDITION_TYPE_PEERCLUSTER_SERVICE, dependsOn: []string{"SvmCreation"},
+			run: r.runPeer},
+	}
+}
+
+// runPipeline runs the units and records their results in the custom resource's status
+// returns the results in registry order and the first error of a failed unit
+func (r *StorageVirtualMachineReconciler) runPipeline(ctx context.Context, state *reconcileState,
+	log logr.Logger) ([]gateway.StepStatus, error) {
+
+	var steps []gateway.StepStatus
+	var pipelineErr error
+	results := make(map[string]gateway.StepResult)
+	stopped := ""
+
+	for _, unit := range r.reconcileUnits() {
+		step := gateway.StepStatus{Name: unit.name, ConditionType: unit.conditionType}
+
+		blockedBy := ""
+		if stopped != "" {
+			blockedBy = stopped
+		}
+		dependencies := unit.dependsOn
+		if unit.dependsOnFor != nil {
+			dependencies = append(append([]string{}, dependencies...), unit.dependsOnFor(state.svmCR)...)
+		}
+		for _, dependency := range dependencies {
+			if blockedBy == "" && results[dependency] != gateway.StepResultSucceeded {
+				blockedBy = dependency
+			}
+		}
+
+		var unsupported []ontap.Feature
+		if blockedBy == "" && unit.features != nil {
+			unsupported = state.oc.Capabilities().Unsupported(unit.features(state.svmCR))
+		}
+
+		if blockedBy != "" {
+			step.Result = gateway.StepResultBlocked
+			step.Message = "blocked by " + blockedBy
+			log.Info("Reconcile step " + unit.name + " blocked by " + blockedBy + " - skipping")
+		} else if len(unsupported) > 0 {
+			step.Result = gateway.StepResultUnsupported
+			step.Message = "ONTAP " + state.oc.Capabilities().Version.String() + " doesn't support: " + ontap.Describe(unsupported)
+			log.Info("Reconcile step " + unit.name + " unsupported - skipping: " + step.Message)
+		} else {
+			result, err := unit.run(ctx, state, log)
+			step.Result = result
+			if err != nil {
+				step.Message = err.Error()
+			}
+			if result == gateway.StepResultFailed && pipelineErr == nil {
+				pipelineErr = err
+			}
+			if result == gateway.StepResultStopped || (unit.critical && result != gateway.StepResultSucceeded) {
+				stopped = unit.name
+				log.Info("Reconcile pipeline stopped at " + unit.name + ": " + string(result))
+			}
+		}
+
+		results[unit.name] = step.Result
+		steps = append(steps, step)
+	}
+
+	if !reflect.DeepEqual(state.svmCR.Status.Steps, steps) {
+		state.svmCR.Status.Steps = steps
+		_ = r.updateStatus(ctx, state.svmCR)
+	}
+	return steps, pipelineErr
+}
+
+// pipelineResult returns the most severe result of the steps
+// failed and waiting steps come before a stopped step since they need a requeue to recover,
+// while a stopped step only waits for a change of the custom resource
+func pipelineResult(steps []gateway.StepStatus) gateway.StepResult {
+	severity := map[gateway.StepResult]int{
+		gateway.StepResultStopped: 1,
+		gateway.StepResultWaiting: 2,
+		gateway.StepResultFailed:  3,
+	}
+	result := gateway.StepResultSucceeded
+	for _, val := range steps {
+		if severity[val.Result] > severity[result] {
+			result = val.Result
+		}
+	}
+	return result
+}
+
+// stepResult maps a step's error to its result
+// waiting for ONTAP or the remote side of a peering isn't a failure
+func stepResult(err error) (gateway.StepResult, error) {
+	if err == nil {
+		return gateway.StepResultSucceeded, nil
+	}
+	for _, val := range []string{"context deadline exceeded", "An introductory RPC to the peer address",
+		"waiting for cluster peer", "waiting for SVM peer", "waiting for job"} {
+		if strings.Contains(err.Error(), val) {
+			return gateway.StepResultWaiting, err
+		}
+	}
+	return gateway.StepResultFailed, err
+}
+
+// STEP 6
+func (r *StorageVirtualMachineReconciler) runSvmCheck(ctx context.Context, state *reconcileState,
+	log logr.Logger) (gateway.StepResult, error) {
+
+	svm, err := r.reconcileSvmCheck(ctx, state.svmCR, state.oc, log)
+	if err != nil && errors.IsNotFound(err) {
+		state.create = true
+		return gateway.StepResultSucceeded, nil
+	}
+	state.svm = svm
+	return stepResult(err)
+}
+
+// STEP 7
+func (r *StorageVirtualMachineReconciler) runSvmCreation(ctx context.Context, state *reconcileState,
+	log logr.Logger) (gateway.StepResult, error) {
+
+	if !state.create {
+		// SVM already created
+		log.Info("STEP 7: Create SVM - skipped because already created")
+		if state.svm.Uuid == "" {
+			// the uuid in the custom resource doesn't map to an SVM - not requeuing
+			return gateway.StepResultStopped, errors.NewNotFound(schema.GroupResource{Group: "gateway.netapp.com",
+				Resource: "StorageVirtualMachine"}, state.svmCR.GetSvmUuid())
+		}
+		return gateway.StepResultSucceeded, nil
+	}
+
+	_, err := r.reconcileSvmCreation(ctx, state.svmCR, state.oc, log)
+	if err != nil {
+		return stepResult(err)
+	}
+
+	// The following steps update the new SVM in the same reconcile
+	state.svm, err = state.oc.GetStorageVMByUUID(state.svmCR.GetSvmUuid())
+	if err != nil {
+		log.Error(err, "Error getting the created SVM - requeuing")
+		return gateway.StepResultFailed, err
+	}
+	return gateway.StepResultSucceeded, nil
+}
+
+// STEP 8 and 9
+func (r *StorageVirtualMachineReconciler) runSecurityAccount(ctx context.Context, state *reconcileState,
+	log logr.Logger) (gateway.StepResult, error) {
+
+	svmCR := state.svmCR
+	if svmCR.Spec.VsadminCredentialSecret.Name == "" {
+		return gateway.StepResultSucceeded, nil
+	}
+
+	// Look up SVM management credentials secret
+	vsAdminSecret, err := r.reconcileSecret(ctx, svmAdminRequest,
+		svmCR.Spec.VsadminCredentialSecret.Name,
+		svmCR.Spec.VsadminCredentialSecret.Namespace, svmCR, log)
+	if err != nil {
+		// not a valid secret - ignore
+		log.Error(err, "Error on SVM management credentials check, skipping Step 9 - not requeuing")
+		return gateway.StepResultSucceeded, nil
+	}
+
+	// Create or update SVM management credentials
+	err = r.reconcileSecurityAccount(ctx, svmCR, state.oc, vsAdminSecret, log)
+	if err != nil {
+		log.Error(err, "Error on SVM management credentials reconcile - requeuing")
+	}
+	return stepResult(err)
+}
+
+// STEP 10
+func (r *StorageVirtualMachineReconciler) runSvmUpdate(ctx context.Context, state *reconcileState,
+	log logr.Logger) (gateway.StepResult, error) {
+
+	return stepResult(r.reconcileSvmUpdate(ctx, state.svmCR, state.svm, state.oc, log))
+}
+
+// STEP 11
+func (r *StorageVirtualMachineReconciler) runManagementLif(ctx context.Context, state *reconcileState,
+	log logr.Logger) (gateway.StepResult, error) {
+
+	err := r.reconcileManagementLifUpdate(ctx, state.svmCR, state.svm.Uuid, state.oc, log)
+	if err != nil && strings.Contains(err.Error(), "Duplicate IP") {
+		log.Error(err, "Duplicated IP Address - stop reconcile")
+		return gateway.StepResultStopped, err
+	} else if err != nil {
+		log.Error(err, "Error during reconciling management LIF - requeuing")
+	}
+	return stepResult(err)
+}
+
+// STEP 12
+func (r *StorageVirtualMachineReconciler) runAggregates(ctx context.Context, state *reconcileState,
+	log logr.Logger) (gateway.StepResult, error) {
+
+	return stepResult(r.reconcileAggregates(ctx, state.svmCR, state.svm, state.oc, log))
+}
+
+// STEP 13
+func (r *StorageVirtualMachineReconciler) runNfs(ctx context.Context, state *reconcileState,
+	log logr.Logger) (gateway.StepResult, error) {
+
+	return stepResult(r.reconcileNfsUpdate(ctx, state.svmCR, state.svm.Uuid, state.oc, log))
+}
+
+// STEP 14
+func (r *StorageVirtualMachineReconciler) runIscsi(ctx context.Context, state *reconcileState,
+	log logr.Logger) (gateway.StepResult, error) {
+
+	return stepResult(r.reconcileIscsiUpdate(ctx, state.svmCR, state.svm.Uuid, state.oc, log))
+}
+
+// STEP 15
+func (r *StorageVirtualMachineReconciler) runNvme(ctx context.Context, state *reconcileState,
+	log logr.Logger) (gateway.StepResult, error) {
+
+	return stepResult(r.reconcileNvmeUpdate(ctx, state.svmCR, state.svm.Uuid, state.oc, log))
+}
+
+// STEP 16
+func (r *StorageVirtualMachineReconciler) runS3(ctx context.Context, state *reconcileState,
+	log logr.Logger) (gateway.StepResult, error) {
+
+	return stepResult(r.reconcileS3Update(ctx, state.svmCR, state.svm.Uuid, state.oc, log))
+}
+
+// STEP 17
+func (r *StorageVirtualMachineReconciler) runPeer(ctx context.Context, state *reconcileState,
+	log logr.Logger) (gateway.StepResult, error) {
+
+	// cluster peers and intercluster LIFs are shared by the SVMs of the cluster
+	defer r.lockCluster(state.oc, log)()
+	return stepResult(r.reconcilePeerUpdate(ctx, state.svmCR, state.svm.Uuid, state.oc, log))
+}
+
+// pipelineSummary describes the steps that didn't succeed for the reconcile log
+func pipelineSummary(steps []gateway.StepStatus) string {
+	var summary []string
+	for _, val := range steps {
+		if val.Result != gateway.StepResultSucceeded {
+			summary = append(summary, fmt.Sprintf("%s=%s", val.Name, val.Result))
+		}
+	}
+	if len(summary) == 0 {
+		return "all steps succeeded"
+	}
+	return strings.Join(summary, ", ")
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/config"
+
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const requeueJitter = 0.2 //magic number - up to 20% added to each delay
+
+// Custom resources are reconciled again according to the requeue section of the operator configuration
+// - configuration errors wait for a change of the custom resource or of a referenced secret
+// - transient errors and peers that aren't ready yet back off exponentially with jitter up to maxDelay
+// - successful reconciles come back after driftCheckInterval to correct changes made directly in ONTAP
+
+// requeueConfigError stops retrying until the custom resource or a referenced secret changes
+// the failing step already set its condition
+func requeueConfigError(err error) (ctrl.Result, error) {
+	if err == nil {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{}, reconcile.TerminalError(err)
+}
+
+// requeueTransient retries after the backoff of the rate limiter
+func requeueTransient(err error) (ctrl.Result, error) {
+	return ctrl.Result{}, err
+}
+
+// requeueWaiting retries after the backoff of the rate limiter without reporting an error
+func requeueWaiting() (ctrl.Result, error) {
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// requeueSucceeded schedules the next drift check, or the next S3 key rotation when it comes first
+// and resets the backoff of the custom resource
+func (r *StorageVirtualMachineReconciler) requeueSucceeded(svmCR *gateway.StorageVirtualMachine) (ctrl.Result, error) {
+	requeueAfter := r.operatorConfig().Requeue.DriftCheckInterval.Duration
+	if keyRequeue := s3KeyRequeue(svmCR); keyRequeue > 0 && keyRequeue < requeueAfter {
+		requeueAfter = keyRequeue
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// backoffRateLimiter delays the retries of a custom resource exponentially with jitter up to the policy's cap
+// the failures are forgotten once a reconcile succeeds
+type backoffRateLimiter struct {
+	policy   config.RequeueConfig
+	mu       sync.Mutex
+	failures map[reconcile.Request]int
+}
+
+func newBackoffRateLimiter(policy config.RequeueConfig) workqueue.TypedRateLimiter[reconcile.Request] {
+	return &backoffRateLimiter{policy: policy, failures: map[reconcile.Request]int{}}
+}
+
+func (b *backoffRateLimiter) When(item reconcile.Request) time.Duration {
+	b.mu.Lock()
+	failures := b.failures[item]
+	b.failures[item] = failures + 1
+	b.mu.Unlock()
+
+	return backoffDelay(b.policy, failures, rand.Float64())
+}
+
+func (b *backoffRateLimiter) Forget(item reconcile.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, item)
+}
+
+func (b *backoffRateLimiter) NumRequeues(item reconcile.Request) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures[item]
+}
+
+// backoffDelay returns the delay before retry number failures+1
+// random is between 0 and 1 and spreads retries of custom resources failing together
+func backoffDelay(policy config.RequeueConfig, failures int, random float64) time.Duration {
+	delay := float64(policy.BaseDelay.Duration) * math.Pow(2, float64(failures))
+	delay += delay * requeueJitter * random
+	if delay > float64(policy.MaxDelay.Duration) {
+		return policy.MaxDelay.Duration
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/config"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testRequeuePolicy() config.RequeueConfig {
+	return config.RequeueConfig{
+		BaseDelay: metav1.Duration{Duration: 5 * time.Second},
+		MaxDelay:  metav1.Duration{Duration: 5 * time.Minute},
+	}
+}
+
+func TestBackoffDelayDoubles(t *testing.T) {
+	policy := testRequeuePolicy()
+
+	for failures, expected := range []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second} {
+		actual := backoffDelay(policy, failures, 0)
+
+		if actual != expected {
+			t.Errorf("Expected %v after %d failures, but found %v", expected, failures, actual)
+		}
+	}
+}
+
+func TestBackoffDelayJitter(t *testing.T) {
+	policy := testRequeuePolicy()
+
+	lowest := backoffDelay(policy, 1, 0)
+	highest := backoffDelay(policy, 1, 1)
+	middle := backoffDelay(policy, 1, 0.5)
+
+	if lowest != 10*time.Second {
+		t.Errorf("Expected no jitter to give 10s, but found %v", lowest)
+	}
+	if highest != 12*time.Second {
+		t.Errorf("Expected the full jitter to add 20%% for 12s, but found %v", highest)
+	}
+	if middle <= lowest || middle >= highest {
+		t.Errorf("Expected the jitter to stay between %v and %v, but found %v", lowest, highest, middle)
+	}
+}
+
+func TestBackoffDelayCappedAtMaxDelay(t *testing.T) {
+	policy := testRequeuePolicy()
+
+	for _, failures := range []int{6, 10, 100} {
+		actual := backoffDelay(policy, failures, 1)
+
+		if actual != policy.MaxDelay.Duration {
+			t.Errorf("Expected the delay after %d failures to be capped at %v, but found %v",
+				failures, policy.MaxDelay.Duration, actual)
+		}
+	}
+}
+
+func TestPipelineResultFailedBeforeStopped(t *testing.T) {
+	steps := []gateway.StepStatus{
+		{Name: "Nfs", Result: gateway.StepResultFailed},
+		{Name: "Peer", Result: gateway.StepResultStopped},
+	}
+
+	actual := pipelineResult(steps)
+
+	if actual != gateway.StepResultFailed {
+		t.Errorf("Expected a failed step to requeue despite a later stopped step, but found %v", actual)
+	}
+}
+
+func TestPipelineResultWaitingBeforeStopped(t *testing.T) {
+	steps := []gateway.StepStatus{
+		{Name: "Peer", Result: gateway.StepResultStopped},
+		{Name: "S3", Result: gateway.StepResultWaiting},
+	}
+
+	actual := pipelineResult(steps)
+
+	if actual != gateway.StepResultWaiting {
+		t.Errorf("Expected a waiting step to requeue despite a stopped step, but found %v", actual)
+	}
+}
+
+func TestPipelineResultStopped(t *testing.T) {
+	steps := []gateway.StepStatus{
+		{Name: "Svm", Result: gateway.StepResultSucceeded},
+		{Name: "Peer", Result: gateway.StepResultStopped},
+		{Name: "S3", Result: gateway.StepResultBlocked},
+	}
+
+	actual := pipelineResult(steps)
+
+	if actual != gateway.StepResultStopped {
+		t.Errorf("Expected %v, but found %v", gateway.StepResultStopped, actual)
+	}
+}
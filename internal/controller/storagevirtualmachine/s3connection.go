@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"context"
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/controller/ontap"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const S3ConnectionSuffix = "-s3-connection" //magic word
+const S3DefaultRegion = "us-east-1"         //magic word - ONTAP ignores the region but SDKs require one
+
+// S3Connection holds the connection details shared by all S3 users of the SVM
+type S3Connection struct {
+	Endpoints []string
+	HttpPort  int
+	HttpsPort int
+	CaBundle  string
+}
+
+// s3Connection collects the S3 endpoints, ports and CA certificate of the SVM
+func (r *StorageVirtualMachineReconciler) s3Connection(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	uuid string, oc *ontap.Client, log logr.Logger) S3Connection {
+
+	var connection S3Connection
+	s3Config := svmCR.Spec.S3Config
+
+	httpsEnabled := s3Config.Https != nil && s3Config.Https.Enabled
+	httpEnabled := s3Config.Http != nil && s3Config.Http.Enabled
+	if httpsEnabled {
+		connection.HttpsPort = s3Config.Https.Port
+	}
+	if httpEnabled {
+		connection.HttpPort = s3Config.Http.Port
+	}
+
+	connection.Endpoints = S3Endpoints(s3Config)
+
+	if httpsEnabled {
+		if s3Config.Https.TlsSecret != nil {
+			secret, err := r.getSecret(ctx, *s3Config.Https.TlsSecret, svmCR)
+			if err != nil {
+				log.Error(err, "Error getting S3 tls secret for the CA bundle: "+s3Config.Https.TlsSecret.Name)
+			} else {
+				connection.CaBundle = string(secret.Data["ca.crt"])
+			}
+		} else if s3Config.Https.Certificate != nil {
+			certs, err := oc.GetCertificatesBySvmUuid(uuid, s3Config.Https.Certificate.CommonName, s3Config.Https.Certificate.Type)
+			if err != nil {
+				log.Error(err, "Error getting S3 CA certificate: "+s3Config.Https.Certificate.CommonName)
+			} else {
+				connection.CaBundle = certs.Records[0].PublicCertificate
+			}
+		}
+	}
+
+	return connection
+}
+
+// S3Endpoints returns the endpoint URLs of the S3 LIFs with the HTTPS endpoints first
+func S3Endpoints(s3Config *gateway.S3SubSpec) []string {
+	var endpoints []string
+	if s3Config.Https != nil && s3Config.Https.Enabled {
+		for _, lif := range s3Config.Lifs {
+			endpoints = append(endpoints, "https://"+net.JoinHostPort(lif.IPAddress, strconv.Itoa(s3Config.Https.Port)))
+		}
+	}
+	if s3Config.Http != nil && s3Config.Http.Enabled {
+		for _, lif := range s3Config.Lifs {
+			endpoints = append(endpoints, "http://"+net.JoinHostPort(lif.IPAddress, strconv.Itoa(s3Config.Http.Port)))
+		}
+	}
+	return endpoints
+}
+
+// S3UserBuckets returns the sorted bucket names the user can access through
+// the bucket policies or the server-level policies of the user's groups
+// buckets without a policy grant all users access
+func S3UserBuckets(s3Config *gateway.S3SubSpec, userName string) []string {
+	principals := map[string]bool{userName: true, "*": true}
+	var groupPolicies []string
+	for _, group := range s3Config.Groups {
+		for _, user := range group.Users {
+			if user == userName {
+				principals["group/"+group.Name] = true
+				groupPolicies = append(groupPolicies, group.Policies...)
+			}
+		}
+	}
+
+	found := make(map[string]bool)
+	for _, bucket := range s3Config.Buckets {
+		if bucket.Policy == nil {
+			found[bucket.Name] = true
+			continue
+		}
+		for _, statement := range bucket.Policy.Statements {
+			if statement.Effect == "deny" {
+				continue
+			}
+			for _, principal := range statement.Principals {
+				if principals[principal] {
+					found[bucket.Name] = true
+				}
+			}
+		}
+	}
+
+	for _, policyName := range groupPolicies {
+		for _, policy := range s3Config.Policies {
+			if policy.Name != policyName {
+				continue
+			}
+			for _, statement := range policy.Statements {
+				if statement.Effect == "deny" {
+					continue
+				}
+				for _, resource := range statement.Resources {
+					bucketName := strings.SplitN(resource, "/", 2)[0]
+					for _, bucket := range s3Config.Buckets {
+						if bucketName == "*" || bucketName == bucket.Name {
+							found[bucket.Name] = true
+						}
+					}
+				}
+			}
+		}
+		if policyName == "FullAccess" || policyName == "ReadOnlyAccess" { //magic word - built-in policies
+			for _, bucket := range s3Config.Buckets {
+				found[bucket.Name] = true
+			}
+		}
+	}
+
+	var buckets []string
+	for val := range found {
+		buckets = append(buckets, val)
+	}
+	sort.Strings(buckets)
+	return buckets
+}
+
+// reconcileS3UserConnection writes the connection details for the user to a config map next to the user's secret
+func (r *StorageVirtualMachineReconciler) reconcileS3UserConnection(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	user gateway.S3User, connection S3Connection, log logr.Logger) error {
+
+	namespace := svmCR.Namespace
+	if user.Namespace != nil {
+		namespace = *user.Namespace
+	}
+	name := user.Name + S3ConnectionSuffix
+
+	data := map[string]string{
+		"AWS_REGION":   S3DefaultRegion,
+		"S3_BUCKETS":   strings.Join(S3UserBuckets(svmCR.Spec.S3Config, user.Name), ","),
+		"S3_ENDPOINTS": strings.Join(connection.Endpoints, ","),
+		"S3_SECRET":    user.Name,
+	}
+	if len(connection.Endpoints) > 0 {
+		data["AWS_ENDPOINT_URL"] = connection.Endpoints[0]
+	}
+	if connection.HttpPort != 0 {
+		data["S3_HTTP_PORT"] = strconv.Itoa(connection.HttpPort)
+	}
+	if connection.HttpsPort != 0 {
+		data["S3_HTTPS_PORT"] = strconv.Itoa(connection.HttpsPort)
+	}
+	if connection.CaBundle != "" {
+		data["ca.crt"] = connection.CaBundle
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, configMap)
+	notFound := errors.IsNotFound(err)
+	if err != nil && !notFound {
+		log.Error(err, "Error getting S3 connection config map: "+namespace+"/"+name)
+		return err
+	}
+	if notFound {
+		configMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+	original := configMap.DeepCopy()
+
+	configMap.Data = data
+	if configMap.Labels == nil {
+		configMap.Labels = make(map[string]string)
+	}
+	configMap.Labels[SvmNameLabel] = svmCR.Name
+	configMap.Labels[SvmNamespaceLabel] = svmCR.Namespace
+	configMap.Labels[S3UserLabel] = user.Name
+
+	// owner references can't cross namespaces
+	if configMap.Namespace == svmCR.Namespace {
+		err = controllerutil.SetOwnerReference(svmCR, configMap, r.Scheme)
+		if err != nil {
+			log.Error(err, "Error setting owner reference on S3 connection config map: "+name)
+			return err
+		}
+	}
+
+	if notFound {
+		err = r.Create(ctx, configMap)
+		if err != nil {
+			log.Error(err, "Error creating S3 connection config map: "+namespace+"/"+name)
+			return err
+		}
+		log.Info("S3 connection config map creation successful: " + name)
+	} else if !reflect.DeepEqual(original.Data, configMap.Data) ||
+		!reflect.DeepEqual(original.Labels, configMap.Labels) ||
+		!reflect.DeepEqual(original.OwnerReferences, configMap.OwnerReferences) {
+		err = r.Update(ctx, configMap)
+		if err != nil {
+			log.Error(err, "Error updating S3 connection config map: "+namespace+"/"+name)
+			return err
+		}
+		log.Info("S3 connection config map update successful: " + name)
+	}
+
+	return nil
+}
@@ -0,0 +1,352 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/controller/ontap"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const S3RootUser = "root"              //magic word
+const S3CosiUserPrefix = "cosi-"       //magic word - users of COSI bucket accesses are left to the COSI provisioner
+const S3RotatedUserSuffix = "-rotated" //magic word - second ONTAP user of a user rotating its keys with a grace period
+
+const SvmNameLabel = "gateway.netapp.com/svm-name"           //magic word
+const SvmNamespaceLabel = "gateway.netapp.com/svm-namespace" //magic word
+const S3UserLabel = "gateway.netapp.com/s3-user"             //magic word
+
+const S3RotateKeysAnnotation = "gateway.netapp.com/rotate-keys"       //magic word
+const S3KeysGeneratedAnnotation = "gateway.netapp.com/keys-generated" //magic word
+
+const S3AccessKeyField = "accessKeyID"                     //magic word
+const S3SecretKeyField = "secretAccessKey"                 //magic word
+const S3PreviousAccessKeyField = "previousAccessKeyID"     //magic word
+const S3PreviousSecretKeyField = "previousSecretAccessKey" //magic word
+
+// reconcileS3UserSecret keeps the user's secret in sync with its ONTAP keys
+// created holds the keys of a user that was just created, otherwise the keys are regenerated when
+// the secret is missing, a rotation is requested with an annotation or the rotation interval elapsed
+// ONTAP keeps a single key pair per user, so regenerated keys replace the previous ones immediately -
+// with a grace period the new keys belong to the alternate ONTAP user instead and the previous keys
+// stay valid and in the secret until the period ends
+func (r *StorageVirtualMachineReconciler) reconcileS3UserSecret(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	user gateway.S3User, previous *gateway.S3UserStatus, created *ontap.S3User,
+	uuid string, oc *ontap.Client, log logr.Logger) (gateway.S3UserStatus, error) {
+
+	ref := gateway.NamespacedName{Name: user.Name, Namespace: svmCR.Namespace}
+	if user.Namespace != nil {
+		ref.Namespace = *user.Namespace
+	}
+	ontapUser := s3OntapUser(user.Name, previous)
+	userStatus := gateway.S3UserStatus{Name: user.Name, Secret: ref}
+	if previous != nil {
+		userStatus.PreviousOntapUser = previous.PreviousOntapUser
+		userStatus.PreviousKeysExpire = previous.PreviousKeysExpire
+	}
+
+	secret, err := r.getSecret(ctx, ref, svmCR)
+	notFound := errors.IsNotFound(err)
+	if err != nil && !notFound {
+		log.Error(err, "Error getting S3 user secret: "+ref.Namespace+"/"+ref.Name)
+		return userStatus, err
+	}
+	if notFound {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: ref.Name, Namespace: ref.Namespace},
+			Type:       corev1.SecretTypeOpaque,
+		}
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	original := secret.DeepCopy()
+	now := time.Now().UTC()
+
+	keys := created
+	if keys == nil {
+		reason := ""
+		if notFound {
+			// keys can't be read back from ONTAP so they are regenerated
+			reason = "secret missing"
+		} else if secret.Annotations[S3RotateKeysAnnotation] == "true" {
+			reason = "rotation requested"
+		} else if user.RotationInterval != nil && !now.Before(s3KeysGenerated(secret).Add(user.RotationInterval.Duration)) {
+			reason = "rotation interval elapsed"
+		}
+
+		if reason != "" && user.GracePeriod != nil && len(secret.Data[S3AccessKeyField]) > 0 {
+			next := s3AlternateUser(user.Name, ontapUser)
+			log.Info("S3 user key rotation attempt (" + reason + "): " + user.Name + " - new keys for ONTAP user: " + next)
+			keys, err = rotateS3UserKeys(next, uuid, oc, log)
+			if err != nil {
+				return userStatus, err
+			}
+			log.Info("S3 user key rotation successful: " + user.Name + " - previous keys valid until the grace period ends")
+
+			secret.Data[S3PreviousAccessKeyField] = secret.Data[S3AccessKeyField]
+			secret.Data[S3PreviousSecretKeyField] = secret.Data[S3SecretKeyField]
+			userStatus.PreviousOntapUser = ontapUser
+			userStatus.PreviousKeysExpire = &metav1.Time{Time: now.Add(user.GracePeriod.Duration)}
+			ontapUser = next
+		} else if reason != "" {
+			log.Info("S3 user key regeneration attempt (" + reason + "): " + user.Name)
+			regenerated, err := oc.RegenerateS3UserKeys(uuid, ontapUser)
+			if err != nil {
+				log.Error(err, "Error occurred when regenerating S3 user keys: "+user.Name)
+				return userStatus, err
+			}
+			if len(regenerated.Records) == 0 {
+				err = fmt.Errorf("no keys returned for S3 user %s", ontapUser)
+				log.Error(err, "Error occurred when regenerating S3 user keys: "+user.Name)
+				return userStatus, err
+			}
+			keys = &regenerated.Records[0]
+			log.Info("S3 user key regeneration successful: " + user.Name)
+		}
+	}
+
+	// the ONTAP user of the previous keys is deleted once groups and bucket policies no longer grant it
+	if userStatus.PreviousKeysExpire != nil && !now.Before(userStatus.PreviousKeysExpire.Time) {
+		log.Info("S3 user grace period ended - removing previous keys: " + user.Name)
+		userStatus.PreviousOntapUser = ""
+		userStatus.PreviousKeysExpire = nil
+	}
+	if ontapUser != user.Name {
+		userStatus.OntapUser = ontapUser
+	}
+
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+
+	if keys != nil {
+		secret.Data[S3AccessKeyField] = []byte(keys.AccessKey)
+		secret.Data[S3SecretKeyField] = []byte(keys.SecretKey)
+		// StringData of secrets created by earlier versions takes precedence over Data on write
+		secret.StringData = nil
+		secret.Annotations[S3KeysGeneratedAnnotation] = now.Format(time.RFC3339)
+		delete(secret.Annotations, S3RotateKeysAnnotation)
+	}
+	if userStatus.PreviousOntapUser == "" {
+		delete(secret.Data, S3PreviousAccessKeyField)
+		delete(secret.Data, S3PreviousSecretKeyField)
+	}
+
+	if secret.Labels == nil {
+		secret.Labels = make(map[string]string)
+	}
+	secret.Labels[SvmNameLabel] = svmCR.Name
+	secret.Labels[SvmNamespaceLabel] = svmCR.Namespace
+	secret.Labels[S3UserLabel] = user.Name
+
+	// owner references can't cross namespaces
+	if secret.Namespace == svmCR.Namespace {
+		err = controllerutil.SetOwnerReference(svmCR, secret, r.Scheme)
+		if err != nil {
+			log.Error(err, "Error setting owner reference on S3 user secret: "+user.Name)
+			return userStatus, err
+		}
+	}
+
+	if notFound {
+		err = r.Create(ctx, secret)
+		if err != nil {
+			log.Error(err, "Error creating S3 user secret: "+ref.Namespace+"/"+ref.Name)
+			return userStatus, err
+		}
+		log.Info("S3 user secret creation successful: " + user.Name)
+	} else if !reflect.DeepEqual(original.Data, secret.Data) ||
+		!reflect.DeepEqual(original.Labels, secret.Labels) ||
+		!reflect.DeepEqual(original.Annotations, secret.Annotations) ||
+		!reflect.DeepEqual(original.OwnerReferences, secret.OwnerReferences) {
+		err = r.Update(ctx, secret)
+		if err != nil {
+			log.Error(err, "Error updating S3 user secret: "+ref.Namespace+"/"+ref.Name)
+			return userStatus, err
+		}
+		log.Info("S3 user secret update successful: " + user.Name)
+	}
+
+	userStatus.KeysGenerated = &metav1.Time{Time: s3KeysGenerated(secret)}
+	return userStatus, nil
+}
+
+// rotateS3UserKeys creates the ONTAP user holding the next keys of a user with a grace period
+// a user left from the rotation before the previous one is replaced
+func rotateS3UserKeys(name string, uuid string, oc *ontap.Client, log logr.Logger) (*ontap.S3User, error) {
+	existing, err := oc.GetS3UserByNameAndSvmUuid(name, uuid)
+	if err != nil {
+		log.Error(err, "Error getting S3 user: "+name)
+		return nil, err
+	}
+	if existing.NumRecords > 0 {
+		log.Info("S3 user delete attempt (keys of an earlier rotation): " + name)
+		err = oc.DeleteS3User(uuid, name)
+		if err != nil {
+			log.Error(err, "Error occurred when deleting S3 user: "+name)
+			return nil, err
+		}
+	}
+	created, err := CreateUser(gateway.S3User{Name: name}, uuid, oc, log)
+	if err != nil {
+		return nil, err
+	}
+	if len(created.Records) == 0 {
+		err = fmt.Errorf("no keys returned for S3 user %s", name)
+		log.Error(err, "Error occurred when creating S3 user: "+name)
+		return nil, err
+	}
+	return &created.Records[0], nil
+}
+
+// s3OntapUser returns the ONTAP user holding the user's current keys
+func s3OntapUser(name string, userStatus *gateway.S3UserStatus) string {
+	if userStatus != nil && userStatus.OntapUser != "" {
+		return userStatus.OntapUser
+	}
+	return name
+}
+
+// s3AlternateUser returns the ONTAP user getting the next keys of a user with a grace period
+// the user alternates between its name and the name with the rotated suffix
+func s3AlternateUser(name string, ontapUser string) string {
+	if ontapUser == name {
+		return name + S3RotatedUserSuffix
+	}
+	return name
+}
+
+// s3OntapUsers returns the ONTAP users holding valid keys of the user - both during a grace period
+func s3OntapUsers(userStatus gateway.S3UserStatus) []string {
+	users := []string{s3OntapUser(userStatus.Name, &userStatus)}
+	if userStatus.PreviousOntapUser != "" {
+		users = append(users, userStatus.PreviousOntapUser)
+	}
+	return users
+}
+
+// s3KeysGenerated returns when the secret's keys were generated
+// secrets created by earlier versions fall back to their creation time
+func s3KeysGenerated(secret *corev1.Secret) time.Time {
+	generated, err := time.Parse(time.RFC3339, secret.Annotations[S3KeysGeneratedAnnotation])
+	if err != nil {
+		return secret.CreationTimestamp.Time
+	}
+	return generated
+}
+
+// findS3UserStatus returns the recorded status of the user, nil when the operator doesn't manage the user
+func findS3UserStatus(userStatuses []gateway.S3UserStatus, name string) *gateway.S3UserStatus {
+	for i := range userStatuses {
+		if userStatuses[i].Name == name {
+			return &userStatuses[i]
+		}
+	}
+	return nil
+}
+
+// setS3UserStatus records the status of the user, replacing its previous status
+func setS3UserStatus(svmCR *gateway.StorageVirtualMachine, userStatus gateway.S3UserStatus) {
+	if svmCR.Status.S3 == nil {
+		svmCR.Status.S3 = &gateway.S3Status{}
+	}
+	for i := range svmCR.Status.S3.Users {
+		if svmCR.Status.S3.Users[i].Name == userStatus.Name {
+			svmCR.Status.S3.Users[i] = userStatus
+			return
+		}
+	}
+	svmCR.Status.S3.Users = append(svmCR.Status.S3.Users, userStatus)
+}
+
+// s3UserOwner returns the recorded status of the user the ONTAP user belongs to
+// with key rotations the ONTAP users holding the current or the previous keys can have the rotated suffix
+func s3UserOwner(userStatuses []gateway.S3UserStatus, ontapUser string) *gateway.S3UserStatus {
+	for i := range userStatuses {
+		name := userStatuses[i].Name
+		if ontapUser == name || ontapUser == name+S3RotatedUserSuffix ||
+			ontapUser == userStatuses[i].OntapUser || ontapUser == userStatuses[i].PreviousOntapUser {
+			return &userStatuses[i]
+		}
+	}
+	return nil
+}
+
+// deleteS3UserObjects deletes the secrets and connection config maps the operator created for a user of this custom resource
+func (r *StorageVirtualMachineReconciler) deleteS3UserObjects(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine, name string, log logr.Logger) {
+
+	labels := client.MatchingLabels{
+		SvmNameLabel:      svmCR.Name,
+		SvmNamespaceLabel: svmCR.Namespace,
+		S3UserLabel:       name,
+	}
+
+	var secrets corev1.SecretList
+	err := r.List(ctx, &secrets, labels)
+	if err != nil {
+		log.Error(err, "Error listing S3 user secrets: "+name)
+	}
+	var configMaps corev1.ConfigMapList
+	err = r.List(ctx, &configMaps, labels)
+	if err != nil {
+		log.Error(err, "Error listing S3 connection config maps: "+name)
+	}
+
+	var objects []client.Object
+	for i := range secrets.Items {
+		objects = append(objects, &secrets.Items[i])
+	}
+	for i := range configMaps.Items {
+		objects = append(objects, &configMaps.Items[i])
+	}
+	for _, obj := range objects {
+		err = r.Delete(ctx, obj)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "Error deleting S3 user object: "+obj.GetNamespace()+"/"+obj.GetName())
+		} else {
+			log.Info("S3 user object delete successful: " + obj.GetNamespace() + "/" + obj.GetName())
+		}
+	}
+}
+
+// s3KeyRequeue returns how long until the next key rotation or grace period end
+// zero means nothing is scheduled
+func s3KeyRequeue(svmCR *gateway.StorageVirtualMachine) time.Duration {
+	if svmCR.Status.S3 == nil || svmCR.Spec.S3Config == nil {
+		return 0
+	}
+
+	now := time.Now()
+	var next time.Duration
+	schedule := func(at time.Time) {
+		wait := at.Sub(now)
+		if wait < time.Second {
+			wait = time.Second
+		}
+		if next == 0 || wait < next {
+			next = wait
+		}
+	}
+
+	for _, userStatus := range svmCR.Status.S3.Users {
+		if userStatus.PreviousKeysExpire != nil {
+			schedule(userStatus.PreviousKeysExpire.Time)
+		}
+		for _, user := range svmCR.Spec.S3Config.Users {
+			if user.Name == userStatus.Name && user.RotationInterval != nil && userStatus.KeysGenerated != nil {
+				schedule(userStatus.KeysGenerated.Add(user.RotationInterval.Duration))
+			}
+		}
+	}
+	return next
+}
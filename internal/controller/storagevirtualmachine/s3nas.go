@@ -0,0 +1,287 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	gateway "gateway/api/v1beta3"
+	"gateway/internal/controller/ontap"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const S3NameMappingDirection = "s3_unix" //magic word
+const NasVolumeSecurityStyle = "unix"    //magic word
+
+// s3Dependencies returns the units S3 depends on beyond the SVM update
+// NAS buckets are served from NFS exports, so only a custom resource with NAS buckets waits for NFS
+func s3Dependencies(svmCR *gateway.StorageVirtualMachine) []string {
+	if svmCR.Spec.S3Config == nil || !svmCR.Spec.S3Config.Enabled {
+		return nil
+	}
+	for _, bucket := range svmCR.Spec.S3Config.Buckets {
+		if isNasBucket(bucket) {
+			return []string{"Nfs"}
+		}
+	}
+	return nil
+}
+
+func isNasBucket(bucket gateway.S3Bucket) bool {
+	return bucket.Type == "nas" || bucket.Nas != nil //magic word
+}
+
+// nasBucketPath returns the path served by a NAS bucket after validating that the volume
+// is mounted at that path and exported by the SVM's NFS configuration
+// the volume is created first when it doesn't exist and a size is defined
+func (r *StorageVirtualMachineReconciler) nasBucketPath(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	definedBucket gateway.S3Bucket, uuid string, oc *ontap.Client, log logr.Logger) (string, error) {
+
+	nas := definedBucket.Nas
+	nfsConfig := svmCR.Spec.NfsConfig
+	if nfsConfig == nil || !nfsConfig.Enabled {
+		return "", fmt.Errorf("NAS bucket %s requires NFS to be enabled on the SVM", definedBucket.Name)
+	}
+	assigned := nasExportPolicy(nfsConfig, nas.Volume)
+	if assigned == "" {
+		return "", fmt.Errorf("no export policy in nfs.exportPolicies is assigned to volume %s of NAS bucket %s",
+			nas.Volume, definedBucket.Name)
+	}
+
+	volume, err := oc.GetVolumeByName(uuid, nas.Volume)
+	if err != nil && errors.IsNotFound(err) && nas.Size > 0 {
+		err = r.createNasVolume(ctx, svmCR, nas, uuid, oc, log)
+		if err != nil {
+			return "", err
+		}
+		volume, err = oc.GetVolumeByName(uuid, nas.Volume)
+	}
+	if err != nil && errors.IsNotFound(err) {
+		return "", fmt.Errorf("volume %s of NAS bucket %s doesn't exist - set nas.size to create it", nas.Volume, definedBucket.Name)
+	} else if err != nil {
+		log.Error(err, "Error getting volume "+nas.Volume+" of NAS bucket: "+definedBucket.Name)
+		return "", err
+	}
+
+	junctionPath := volume.Nas.Path
+	if junctionPath == "" {
+		return "", fmt.Errorf("volume %s of NAS bucket %s is not mounted in the SVM namespace", nas.Volume, definedBucket.Name)
+	}
+	path := nas.Path
+	if path == "" {
+		path = junctionPath
+	}
+	if path != junctionPath && !strings.HasPrefix(path, strings.TrimSuffix(junctionPath, "/")+"/") {
+		return "", fmt.Errorf("path %s of NAS bucket %s is not below junction path %s of volume %s",
+			path, definedBucket.Name, junctionPath, nas.Volume)
+	}
+
+	// NFS and S3 clients share the data only when NFS clients can reach the path
+	// through the export policy the custom resource assigns to the volume
+	if volume.Nas.ExportPolicy.Name != assigned {
+		return "", fmt.Errorf("volume %s of NAS bucket %s uses export policy %s instead of %s assigned in nfs.exportPolicies",
+			nas.Volume, definedBucket.Name, volume.Nas.ExportPolicy.Name, assigned)
+	}
+	rules, err := oc.GetNfsExportRules(volume.Nas.ExportPolicy.Id)
+	if err != nil {
+		log.Error(err, "Error getting rules of export policy "+volume.Nas.ExportPolicy.Name+" for NAS bucket: "+definedBucket.Name)
+		return "", err
+	}
+	if rules.NumRecords == 0 {
+		return "", fmt.Errorf("export policy %s of volume %s has no rules - NFS clients can't access the path of NAS bucket %s",
+			volume.Nas.ExportPolicy.Name, nas.Volume, definedBucket.Name)
+	}
+
+	return path, nil
+}
+
+// createNasVolume creates the volume of a NAS bucket with the export policy the custom resource assigns to it
+// the creation job is recorded like the bucket jobs, so a volume still being created isn't requested again
+func (r *StorageVirtualMachineReconciler) createNasVolume(ctx context.Context, svmCR *gateway.StorageVirtualMachine,
+	nas *gateway.S3NasBucket, uuid string, oc *ontap.Client, log logr.Logger) error {
+
+	var newVolume ontap.Volume
+	newVolume.Name = nas.Volume
+	newVolume.Svm.Uuid = uuid
+	newVolume.Size = nas.Size
+	newVolume.Comment = defaultComment
+	if len(svmCR.Spec.Aggregates) > 0 {
+		newVolume.Aggregates = []ontap.Resource{{Name: svmCR.Spec.Aggregates[0].Name}}
+	}
+	newVolume.Nas.SecurityStyle = NasVolumeSecurityStyle
+	newVolume.Nas.Path = "/" + nas.Volume
+	if nas.Path != "" {
+		newVolume.Nas.Path = nas.Path
+	}
+	newVolume.Nas.ExportPolicy.Name = nasExportPolicy(svmCR.Spec.NfsConfig, nas.Volume)
+
+	jsonPayload, err := json.Marshal(newVolume)
+	if err != nil {
+		//error creating the json body
+		log.Error(err, "Error creating the json payload for volume creation: "+nas.Volume)
+		return err
+	}
+
+	if oc.Debug {
+		log.Info("[DEBUG] NAS bucket volume creation payload: " + fmt.Sprintf("%#v\n", newVolume))
+	}
+
+	log.Info("NAS bucket volume creation attempt: " + nas.Volume)
+	_, err = r.runJob(ctx, svmCR, JobOperationVolumeCreate, nas.Volume, func() (string, error) {
+		return oc.StartVolumeCreation(jsonPayload)
+	}, oc, log)
+	if err != nil {
+		log.Error(err, "Error occurred when creating NAS bucket volume: "+nas.Volume)
+		return err
+	}
+	log.Info("NAS bucket volume creation successful: " + nas.Volume)
+	return nil
+}
+
+// nasExportPolicy returns the export policy the custom resource assigns to the volume
+func nasExportPolicy(nfsConfig *gateway.NfsSubSpec, volume string) string {
+	assigned := ""
+	for _, policy := range nfsExportPolicies(nfsConfig) {
+		for _, val := range policy.Volumes {
+			if val == volume {
+				assigned = policy.Name
+			}
+		}
+	}
+	return assigned
+}
+
+// reconcileS3NameMappings keeps the S3 to UNIX name mappings in the order of the custom resource
+// and deletes the mappings the operator applied before that are no longer defined
+// ONTAP name mappings have no comment, so applied holds the mappings recorded in the status -
+// only their indexes are patched, mappings created by others keep their position and the
+// defined mappings that don't fit around them are added after the last mapping
+func reconcileS3NameMappings(mappings []gateway.S3NameMapping, applied []gateway.S3NameMapping,
+	uuid string, oc *ontap.Client, log logr.Logger) error {
+	mappingsRetrieved, err := oc.GetNameMappingsBySvmUuid(uuid, S3NameMappingDirection)
+	if err != nil {
+		log.Error(err, "Error getting S3 name mappings for SVM: "+uuid+" - requeuing")
+		return err
+	}
+
+	current := make(map[int]ontap.NameMapping)
+	last := 0
+	for _, val := range mappingsRetrieved.Records {
+		current[val.Index] = val
+		if val.Index > last {
+			last = val.Index
+		}
+	}
+
+	// indexes holding a defined mapping
+	kept := make(map[int]bool)
+	for i, val := range mappings {
+		index := i + 1
+		existing, ok := current[index]
+		if ok && existing.Pattern == val.Pattern && existing.Replacement == val.Replacement && !kept[index] {
+			kept[index] = true
+			continue
+		}
+
+		if ok && !kept[index] && containsNameMapping(applied, existing) {
+			patchMapping := ontap.NameMapping{Pattern: val.Pattern, Replacement: val.Replacement}
+			jsonPayload, err := json.Marshal(patchMapping)
+			if err != nil {
+				//error creating the json body
+				log.Error(err, fmt.Sprintf("Error creating the json payload for S3 name mapping update %v", index))
+				return err
+			}
+
+			log.Info(fmt.Sprintf("S3 name mapping update attempt: %v", index))
+			err = oc.PatchNameMapping(uuid, S3NameMappingDirection, index, jsonPayload)
+			if err != nil {
+				log.Error(err, fmt.Sprintf("Error occurred when updating S3 name mapping: %v", index))
+				return err
+			}
+			log.Info(fmt.Sprintf("S3 name mapping update successful: %v", index))
+			current[index] = ontap.NameMapping{Index: index, Pattern: val.Pattern, Replacement: val.Replacement}
+			kept[index] = true
+			continue
+		}
+
+		// the index is taken by a mapping created by others - a defined mapping already placed elsewhere stays there
+		if found := findNameMapping(current, kept, val); found > 0 {
+			kept[found] = true
+			continue
+		}
+
+		index = last + 1
+		if ok {
+			log.Info(fmt.Sprintf("S3 name mapping %v is not managed by the operator - adding the defined mapping at %v", i+1, index))
+		}
+
+		var newMapping ontap.NameMapping
+		newMapping.Svm.Uuid = uuid
+		newMapping.Direction = S3NameMappingDirection
+		newMapping.Index = index
+		newMapping.Pattern = val.Pattern
+		newMapping.Replacement = val.Replacement
+
+		jsonPayload, err := json.Marshal(newMapping)
+		if err != nil {
+			//error creating the json body
+			log.Error(err, fmt.Sprintf("Error creating the json payload for S3 name mapping creation %v", index))
+			return err
+		}
+
+		if oc.Debug {
+			log.Info("[DEBUG] S3 name mapping creation payload: " + fmt.Sprintf("%#v\n", newMapping))
+		}
+
+		log.Info(fmt.Sprintf("S3 name mapping creation attempt: %v", index))
+		err = oc.CreateNameMapping(jsonPayload)
+		if err != nil {
+			log.Error(err, fmt.Sprintf("Error occurred when creating S3 name mapping: %v", index))
+			return err
+		}
+		log.Info(fmt.Sprintf("S3 name mapping creation successful: %v", index))
+		current[index] = newMapping
+		kept[index] = true
+		last = index
+	}
+
+	// Delete the applied mappings that aren't defined from the last index so the remaining indexes don't shift
+	for index := last; index > 0; index-- {
+		existing, ok := current[index]
+		if !ok || kept[index] || !containsNameMapping(applied, existing) {
+			continue
+		}
+		log.Info(fmt.Sprintf("S3 name mapping delete attempt: %v", index))
+		err = oc.DeleteNameMapping(uuid, S3NameMappingDirection, index)
+		if err != nil {
+			log.Error(err, fmt.Sprintf("Error occurred when deleting S3 name mapping: %v", index))
+			// don't requeue on failed delete request
+		} else {
+			log.Info(fmt.Sprintf("S3 name mapping delete successful: %v", index))
+		}
+	}
+
+	return nil
+}
+
+// findNameMapping returns the first index of the mapping among those not holding another defined mapping, 0 when not found
+func findNameMapping(current map[int]ontap.NameMapping, kept map[int]bool, mapping gateway.S3NameMapping) int {
+	found := 0
+	for index, val := range current {
+		if !kept[index] && val.Pattern == mapping.Pattern && val.Replacement == mapping.Replacement && (found == 0 || index < found) {
+			found = index
+		}
+	}
+	return found
+}
+
+func containsNameMapping(mappings []gateway.S3NameMapping, mapping ontap.NameMapping) bool {
+	for _, val := range mappings {
+		if val.Pattern == mapping.Pattern && val.Replacement == mapping.Replacement {
+			return true
+		}
+	}
+	return false
+}
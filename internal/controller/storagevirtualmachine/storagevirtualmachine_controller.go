@@ -9,30 +9,46 @@ package controller
 import (
 	"context"
 	gateway "gateway/api/v1beta3"
-	"strings"
-	"time"
+	"gateway/internal/config"
+	ontapcluster "gateway/internal/controller/ontapcluster"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
-const (
-	trustSSL = true
-	debugOn  = true
-)
-
 // StorageVirtualMachineReconciler reconciles a StorageVirtualMachine object
 type StorageVirtualMachineReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder // Added to support events
+	// Config is the operator configuration - nil uses the defaults
+	Config *config.OperatorConfiguration
+	// Clients holds the clients of OntapClusters - shared with the OntapCluster reconciler
+	Clients *ontapcluster.Clients
+	// SvmLocks serialize the work per SVM - shared with the COSI reconcilers
+	SvmLocks *KeyedLocks
+
+	// Locks of concurrent reconciles
+	clusterLocks KeyedLocks
+}
+
+// operatorConfig returns the operator configuration or the defaults
+func (r *StorageVirtualMachineReconciler) operatorConfig() *config.OperatorConfiguration {
+	if r.Config == nil {
+		return config.Default()
+	}
+	return r.Config
 }
 
 //+kubebuilder:rbac:groups=gateway.netapp.com,resources=storagevirtualmachines,verbs=get;list;watch;create;update;patch;delete
@@ -44,7 +60,16 @@ type StorageVirtualMachineReconciler struct {
 
 // ADDED to support access to secrets
 // This helped:  https://github.com/kubernetes-sigs/kubebuilder/issues/549
-// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+
+// ADDED to support S3 connection details
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;delete
+
+// ADDED to support NFS export clients from node addresses
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+
+// ADDED to support clusterRef
+//+kubebuilder:rbac:groups=gateway.netapp.com,resources=ontapclusters,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -61,13 +86,6 @@ func (r *StorageVirtualMachineReconciler) Reconcile(ctx context.Context, req ctr
 	log := log.FromContext(ctx).WithValues("Request.Namespace", req.Namespace, "Request.Name", req.Name)
 	log.Info("RECONCILE START")
 
-	// This works.
-	// It is a hack to stop the second reconcile that occurrs
-	// immediately after the first reconcile.
-	// If this is not present it causes errors while updating conditions.
-	// TODO: Check this statement: https://groups.google.com/g/kubebuilder/c/tULj-TRM9ts
-	time.Sleep(1 * time.Second)
-
 	// STEP 1
 	// Check for existing of CR object -
 	// if doesn't exist or error retrieving, log error and exit reconcile
@@ -76,188 +94,87 @@ func (r *StorageVirtualMachineReconciler) Reconcile(ctx context.Context, req ctr
 	if err != nil && errors.IsNotFound(err) {
 		return ctrl.Result{Requeue: false}, nil
 	} else if err != nil {
-		return ctrl.Result{RequeueAfter: 30 * time.Second}, err //re-reconcile
+		return requeueTransient(err) //re-reconcile
 	}
 
 	// STEP 2
-	// Get cluster management host
-	host, err := r.reconcileClusterHost(ctx, svmCR, log)
+	// Get the referenced OntapCluster and the cluster management host
+	ontapCluster, err := r.reconcileOntapCluster(ctx, svmCR, log)
+	if err != nil && errors.IsNotFound(err) && svmCR.GetDeletionTimestamp() != nil {
+		return r.reconcileDeletionWithoutCluster(ctx, svmCR, log) // the cluster can't be reached - let the custom resource go
+	} else if err != nil && errors.IsNotFound(err) {
+		return requeueConfigError(err) // no OntapCluster - wait for it to be created
+	} else if err != nil {
+		return requeueTransient(err) //re-reconcile
+	}
+	host, err := r.reconcileClusterHost(ctx, svmCR, ontapCluster, log)
 	if err != nil {
-		return ctrl.Result{Requeue: false}, nil // not a valid cluster Url - stop reconcile
+		return requeueConfigError(err) // not a valid cluster Url - wait for a change of the custom resource
 	}
 
 	// STEP 3
-	// Look up cluster admin secret
+	// Look up cluster admin secret - the OntapCluster's replaces the deprecated clusterCredentials
+	credentials := types.NamespacedName{
+		Name:      svmCR.Spec.ClusterCredentialSecret.Name,
+		Namespace: svmCR.Spec.ClusterCredentialSecret.Namespace,
+	}
+	if ontapCluster != nil {
+		credentials = ontapcluster.CredentialsKey(ontapCluster)
+	}
 	adminSecret, err := r.reconcileSecret(ctx, clusterAdminRequest,
-		svmCR.Spec.ClusterCredentialSecret.Name,
-		svmCR.Spec.ClusterCredentialSecret.Namespace, svmCR, log)
-	if err != nil {
-		return ctrl.Result{Requeue: false}, nil // not a valid secret - stop reconcile
+		credentials.Name, credentials.Namespace, svmCR, log)
+	if err != nil && (errors.IsNotFound(err) || errors.IsBadRequest(err)) {
+		return requeueConfigError(err) // not a valid secret - wait for the secret to be created or fixed
+	} else if err != nil {
+		return requeueTransient(err) //re-reconcile
 	}
 
 	// STEP 4
 	// Create ONTAP client
-	oc, err := r.reconcileGetClient(ctx, svmCR, adminSecret, host, trustSSL, log)
+	oc, err := r.reconcileGetClient(ctx, svmCR, ontapCluster, adminSecret, host, log)
 	if err != nil {
-		return ctrl.Result{RequeueAfter: 30 * time.Second}, err //got another error - re-reconcile
+		return requeueTransient(err) //got another error - re-reconcile
 	}
 
+	// Serialize the work on the SVM with other custom resources naming it
+	defer r.lockSvm(oc, svmCR, log)()
+
 	// STEP 5
 	// Check to see if deleting custom resource and handle the deletion
 	isSMVMarkedToBeDeleted := svmCR.GetDeletionTimestamp() != nil
 	if isSMVMarkedToBeDeleted {
 		_, err = r.reconcileDeletions(ctx, svmCR, oc, log)
 		if err != nil {
-			return ctrl.Result{RequeueAfter: 30 * time.Second}, err //got another error - re-reconcile
+			return requeueTransient(err) //got another error - re-reconcile
 		} else {
 			return ctrl.Result{Requeue: false}, nil //stop reconcile
 		}
 	}
 
-	create := false // Define variable whether to create svm or update it - default to false
+	// STEPS 6 to 17
+	// Check, create and update the SVM and its services
+	state := &reconcileState{svmCR: svmCR, oc: oc}
+	steps, err := r.runPipeline(ctx, state, log)
+	log.Info("Reconcile pipeline result: " + pipelineSummary(steps))
 
-	// STEP 6
-	// Check to see if svmCR has uuid and then check if svm can be looked up on that uuid
-	svmRetrieved, err := r.reconcileSvmCheck(ctx, svmCR, oc, log)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			create = true
-		} else {
-			// some other error
-			return ctrl.Result{RequeueAfter: 30 * time.Second}, err // got another error - re-reconcile
-		}
+	// Report the management endpoint - it changes when the client fails over
+	if svmCR.Status.ClusterEndpoint != oc.ActiveHost() {
+		svmCR.Status.ClusterEndpoint = oc.ActiveHost()
+		_ = r.updateStatus(ctx, svmCR)
 	}
-
-	if create {
-		// STEP 7
-		// Reconcile SVM creation
-		_, err = r.reconcileSvmCreation(ctx, svmCR, oc, log)
-		if err != nil {
-			return ctrl.Result{RequeueAfter: 30 * time.Second}, err //got another error - re-reconcile
-		}
-	} else {
-		// SVM already created
-		log.Info("STEP 7: Create SVM - skipped because already created")
-	}
-
-	// STEP 8
-	// Check to see if SVM management credentials is available
-	if svmCR.Spec.VsadminCredentialSecret.Name != "" {
-		// Look up SVM management credentials secret
-		vsAdminSecret, err := r.reconcileSecret(ctx, svmAdminRequest,
-			svmCR.Spec.VsadminCredentialSecret.Name,
-			svmCR.Spec.VsadminCredentialSecret.Namespace, svmCR, log)
-		if err != nil {
-			log.Error(err, "Error on SVM management credentials check, skipping Step 9 - not requeuing")
-			// not a valid secret - ignore
-			// Don't stop result
-			//return ctrl.Result{Requeue: false}, nil
-		} else {
-
-			// STEP 9
-			// Create or update SVM management credentials
-			err = r.reconcileSecurityAccount(ctx, svmCR, oc, vsAdminSecret, log)
-			// if err != nil && errors.IsNotFound(err) {
-			// 	log.Error(err, "Error on SVM management credentials not found, skipping Step 9 - not requeuing")
-			// 	return ctrl.Result{Requeue: false}, nil // not a valid secret - ignore
-			// } else
-			if err != nil {
-				log.Error(err, "Error on SVM management credentials reconcile - requeuing")
-				return ctrl.Result{RequeueAfter: 30 * time.Second}, err
-			}
-			log.Info("Creation of SVM complete - re-reconciling to complete configuration")
-		}
-
-	}
-
-	// Check whether we need to update the SVM
-	if !create {
-
-		// STEP 10
-		// Reconcile SVM update
-		err = r.reconcileSvmUpdate(ctx, svmCR, svmRetrieved, oc, log)
-		if err != nil {
-			return ctrl.Result{RequeueAfter: 30 * time.Second}, err
-		}
-
-		if svmRetrieved.Uuid != "" {
-			// STEP 11
-			// Reconcile Management LIF information
-			err = r.reconcileManagementLifUpdate(ctx, svmCR, svmRetrieved.Uuid, oc, log)
-			if err != nil {
-				if strings.Contains(err.Error(), "Duplicate IP") {
-					log.Error(err, "Duplicated IP Address - stop reconcile")
-					return ctrl.Result{Requeue: false}, nil
-				}
-				log.Error(err, "Error during reconciling management LIF - requeuing")
-				return ctrl.Result{RequeueAfter: 30 * time.Second}, err
-			}
-
-			// STEP 12
-			// Reconcile Aggregates
-			err = r.reconcileAggregates(ctx, svmCR, svmRetrieved, oc, log)
-			if err != nil {
-				return ctrl.Result{RequeueAfter: 30 * time.Second}, err
-			}
-
-			// STEP 13
-			// Reconcile NFS information
-			err = r.reconcileNfsUpdate(ctx, svmCR, svmRetrieved.Uuid, oc, log)
-			if err != nil {
-				return ctrl.Result{RequeueAfter: 30 * time.Second}, err
-			}
-
-			// STEP 14
-			// Reconcile iSCSI information
-			err = r.reconcileIscsiUpdate(ctx, svmCR, svmRetrieved.Uuid, oc, log)
-			if err != nil {
-				return ctrl.Result{RequeueAfter: 30 * time.Second}, err
-			}
-
-			// STEP 15
-			// Reconcile NVMe information
-			err = r.reconcileNvmeUpdate(ctx, svmCR, svmRetrieved.Uuid, oc, log)
-			if err != nil {
-				return ctrl.Result{RequeueAfter: 30 * time.Second}, err
-			}
-
-			// STEP 16
-			// Reconcile S3 information
-			err = r.reconcileS3Update(ctx, svmCR, svmRetrieved.Uuid, oc, log)
-			if err != nil {
-				return ctrl.Result{RequeueAfter: 30 * time.Second}, err
-			}
-
-			// STEP 17
-			// Reconcile Peer information
-			//oc.Debug = true
-			err = r.reconcilePeerUpdate(ctx, svmCR, svmRetrieved.Uuid, oc, log)
-			if err != nil {
-				if strings.Contains(err.Error(), "context deadline exceeded") {
-					//oc.Debug = false
-					return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
-				}
-				if strings.Contains(err.Error(), "An introductory RPC to the peer address") {
-					//oc.Debug = false
-					return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
-				}
-				if strings.Contains(err.Error(), "waiting for cluster peer") {
-					//oc.Debug = false
-					return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
-				}
-				if strings.Contains(err.Error(), "waiting for SVM peer") {
-					//oc.Debug = false
-					return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
-				}
-				//oc.Debug = false
-				return ctrl.Result{RequeueAfter: 30 * time.Second}, err
-			}
-		}
-
+	switch pipelineResult(steps) {
+	case gateway.StepResultStopped:
+		return ctrl.Result{Requeue: false}, nil //stop reconcile - wait for a change of the custom resource
+	case gateway.StepResultFailed:
+		return requeueTransient(err) //got another error - re-reconcile
+	case gateway.StepResultWaiting:
+		return requeueWaiting() //peer or ONTAP not ready yet - re-reconcile
 	}
 
 	log.Info("RECONCILE END")
-	return ctrl.Result{Requeue: false}, nil //no error - end reconcile
+
+	// Come back for the next drift check or scheduled S3 key rotation
+	return r.requeueSucceeded(svmCR)
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -265,7 +182,26 @@ func (r *StorageVirtualMachineReconciler) Reconcile(ctx context.Context, req ctr
 // From this: https://github.com/kubernetes-sigs/kubebuilder/issues/618
 
 func (r *StorageVirtualMachineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Config == nil {
+		r.Config = config.Default()
+	}
+	if r.Clients == nil {
+		r.Clients = ontapcluster.NewClients()
+	}
+	if r.SvmLocks == nil {
+		r.SvmLocks = &KeyedLocks{}
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&gateway.StorageVirtualMachine{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.nodeToStorageVirtualMachines),
+			builder.WithPredicates(nodeChangedPredicate)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.secretToStorageVirtualMachines),
+			builder.WithPredicates(secretChangedPredicate)).
+		Watches(&gateway.OntapCluster{}, handler.EnqueueRequestsFromMapFunc(r.ontapClusterToStorageVirtualMachines),
+			builder.WithPredicates(ontapClusterChangedPredicate)).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.operatorConfig().Manager.MaxConcurrentReconciles,
+			RateLimiter:             newBackoffRateLimiter(r.operatorConfig().Requeue),
+		}).
 		Complete(r)
 }
@@ -73,3 +73,15 @@ func (reconciler *StorageVirtualMachineReconciler) deleteCondition(ctx context.C
 	}
 	return nil
 }
+
+func (reconciler *StorageVirtualMachineReconciler) updateStatus(ctx context.Context,
+	svmCR *gateway.StorageVirtualMachine) error {
+
+	log := log.FromContext(ctx)
+	err := reconciler.Client.Status().Update(ctx, svmCR)
+	if err != nil {
+		log.Error(err, "Updating the status of the custom resource failed")
+		return err
+	}
+	return nil
+}